@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"smartDriver/internal/config"
 	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/geocode"
 	"smartDriver/pkg/iiko"
+	"smartDriver/pkg/lateness"
+	"smartDriver/pkg/orderstatus"
 	"time"
+	_ "time/tzdata"
 )
 
 func main() {
@@ -19,21 +23,37 @@ func main() {
 	ctx := context.Background()
 
 	if err := db.InitConnection(cfg); err != nil {
-		fmt.Errorf("failed to init database connection: %v", err)
+		log.Fatalf("failed to init database connection: %v", err)
+	}
+
+	centrifugo.Init(cfg)
+	geocode.Init(cfg)
+
+	if err := orderstatus.Init(cfg); err != nil {
+		log.Fatalf("failed to load order status mapping: %v", err)
 	}
 
 	service := iiko.NewOrderPollingService(
 		db.Pool,
 		db.Repository,
-		"http://localhost:8000", // Centrifugo URL
-		"your-api-key",          // Centrifugo API key
-		time.Second*60,          // Poll interval
+		cfg.Centrifugo.URL,
+		cfg.Centrifugo.APIKey,
+		time.Second*60, // Poll interval
 	)
 
 	if err := service.Start(ctx); err != nil {
 		log.Fatal(err)
 	}
 
+	// Alert dispatchers about orders missing their promised time
+	monitor := lateness.NewMonitor(
+		db.Repository,
+		centrifugo.Default,
+		cfg.Lateness.CheckInterval,
+		cfg.Lateness.AtRiskThreshold,
+	)
+	monitor.Start(ctx)
+
 	// Keep the service running
 	select {}
 }
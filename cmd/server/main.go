@@ -7,7 +7,15 @@ import (
 	"smartDriver/internal/config"
 	"smartDriver/internal/db"
 	httptransport "smartDriver/internal/transport/http"
+	"smartDriver/internal/transport/http/handler"
+	"smartDriver/pkg/blob"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/delivery"
+	"smartDriver/pkg/dispatch"
+	"smartDriver/pkg/geocode"
 	"smartDriver/pkg/log"
+	"smartDriver/pkg/orderstatus"
+	"smartDriver/pkg/route"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
@@ -16,26 +24,6 @@ import (
 	_ "github.com/danielgtaylor/huma/v2/formats/cbor"
 )
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if token == "" {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		session, err := db.Repository.GetSessionByToken(r.Context(), token)
-		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		ctx := context.WithValue(r.Context(), "user_id", session.UserID)
-		ctx = context.WithValue(ctx, "session_token", token)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
-
 func main() {
 	cfg, err := config.Load()
 	log.MustInit(cfg)
@@ -47,10 +35,27 @@ func main() {
 		log.SugaredLogger.Errorf("failed to init database connection: %v", err)
 	}
 
+	centrifugo.Init(cfg)
+	geocode.Init(cfg)
+	delivery.Init(cfg)
+	route.Init(cfg)
+
+	if err := orderstatus.Init(cfg); err != nil {
+		log.SugaredLogger.Fatalf("failed to load order status mapping: %v", err)
+	}
+
+	if err := blob.Init(cfg); err != nil {
+		log.SugaredLogger.Fatalf("failed to init blob storage: %v", err)
+	}
+
 	router := chi.NewMux()
 	api := humachi.New(router, huma.DefaultConfig("SmartDriver", "0.5.3"))
+	api.UseMiddleware(httptransport.AuthMiddleware(api))
 	httptransport.Register(api)
 
+	// Create due rides for organizations with automatic dispatch enabled
+	dispatch.NewWorker(handler.AutoDispatch, cfg.Dispatch.Interval, cfg.Dispatch.Lead).Start(context.Background())
+
 	log.SugaredLogger.Infof("HTTP server is listening on %s:%s", cfg.Server.Host, cfg.Server.Port)
 	if err := http.ListenAndServe(fmt.Sprintf(":%s", cfg.Server.Port), router); err != nil {
 		log.SugaredLogger.Fatalf("failed to listen given address: %v", err)
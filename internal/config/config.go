@@ -11,10 +11,17 @@ import (
 
 // Config holds all configuration settings
 type Config struct {
-	Server     ServerConfig
-	Database   DatabaseConfig
-	Centrifugo CentrifugoConfig
-	Parser     ParserConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Centrifugo  CentrifugoConfig
+	Parser      ParserConfig
+	Lateness    LatenessConfig
+	OrderStatus OrderStatusConfig
+	Geocoder    GeocoderConfig
+	Blob        BlobConfig
+	Delivery    DeliveryConfig
+	Route       RouteConfig
+	Dispatch    DispatchConfig
 }
 
 type ServerConfig struct {
@@ -45,6 +52,59 @@ type ParserConfig struct {
 	WorkerCount     int
 }
 
+// OrderStatusConfig points to a JSON file overriding the iiko status mapping
+type OrderStatusConfig struct {
+	MappingFile string
+}
+
+// GeocoderConfig configures the Nominatim compatible geocoder
+type GeocoderConfig struct {
+	URL          string
+	UserAgent    string
+	Language     string
+	CountryCodes string
+	Timeout      time.Duration
+	MinInterval  time.Duration
+	CacheTTL     time.Duration
+	CacheSize    int
+}
+
+// BlobConfig selects where uploaded files such as delivery photos are kept
+type BlobConfig struct {
+	Driver      string
+	LocalDir    string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+// DeliveryConfig limits proof of delivery uploads
+type DeliveryConfig struct {
+	MaxUploadSize int64
+}
+
+// RouteConfig describes driving between stops for route sequencing
+type RouteConfig struct {
+	AverageSpeed  int // km/h
+	DetourPercent int // road distance relative to the straight line
+	ServiceTime   time.Duration
+}
+
+// DispatchConfig controls automatic ride creation
+type DispatchConfig struct {
+	Interval time.Duration
+	Lead     time.Duration
+}
+
+// LatenessConfig controls alerts about orders missing their promised time
+type LatenessConfig struct {
+	CheckInterval   time.Duration
+	AtRiskThreshold time.Duration
+}
+
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
 	// Load .env file if it exists
@@ -85,6 +145,59 @@ func Load() (*Config, error) {
 		WorkerCount:     getIntOrDefault("PARSER_WORKER_COUNT", 5),
 	}
 
+	// Lateness alerts configuration
+	cfg.Lateness = LatenessConfig{
+		CheckInterval:   getDurationOrDefault("LATENESS_CHECK_INTERVAL", time.Minute),
+		AtRiskThreshold: getDurationOrDefault("LATENESS_AT_RISK_THRESHOLD", 10*time.Minute),
+	}
+
+	// Order status configuration
+	cfg.OrderStatus = OrderStatusConfig{
+		MappingFile: getEnvOrDefault("ORDER_STATUS_MAPPING_FILE", ""),
+	}
+
+	// Geocoder configuration
+	cfg.Geocoder = GeocoderConfig{
+		URL:          getEnvOrDefault("GEOCODER_URL", "https://nominatim.openstreetmap.org"),
+		UserAgent:    getEnvOrDefault("GEOCODER_USER_AGENT", "smartDriver"),
+		Language:     getEnvOrDefault("GEOCODER_LANGUAGE", "ru"),
+		CountryCodes: getEnvOrDefault("GEOCODER_COUNTRY_CODES", "ru"),
+		Timeout:      getDurationOrDefault("GEOCODER_TIMEOUT", 5*time.Second),
+		MinInterval:  getDurationOrDefault("GEOCODER_MIN_INTERVAL", time.Second),
+		CacheTTL:     getDurationOrDefault("GEOCODER_CACHE_TTL", 24*time.Hour),
+		CacheSize:    getIntOrDefault("GEOCODER_CACHE_SIZE", 10000),
+	}
+
+	// Blob storage configuration
+	cfg.Blob = BlobConfig{
+		Driver:      getEnvOrDefault("BLOB_DRIVER", "local"),
+		LocalDir:    getEnvOrDefault("BLOB_LOCAL_DIR", "data/blobs"),
+		S3Endpoint:  getEnvOrDefault("S3_ENDPOINT", ""),
+		S3Region:    getEnvOrDefault("S3_REGION", "us-east-1"),
+		S3Bucket:    getEnvOrDefault("S3_BUCKET", ""),
+		S3AccessKey: getEnvOrDefault("S3_ACCESS_KEY", ""),
+		S3SecretKey: getEnvOrDefault("S3_SECRET_KEY", ""),
+		S3PathStyle: getEnvOrDefault("S3_PATH_STYLE", "true") == "true",
+	}
+
+	// Proof of delivery configuration
+	cfg.Delivery = DeliveryConfig{
+		MaxUploadSize: int64(getIntOrDefault("DELIVERY_MAX_UPLOAD_SIZE", 10<<20)),
+	}
+
+	// Route sequencing configuration
+	cfg.Route = RouteConfig{
+		AverageSpeed:  getIntOrDefault("ROUTE_AVERAGE_SPEED", 25),
+		DetourPercent: getIntOrDefault("ROUTE_DETOUR_PERCENT", 130),
+		ServiceTime:   getDurationOrDefault("ROUTE_SERVICE_TIME", 5*time.Minute),
+	}
+
+	// Automatic dispatch configuration
+	cfg.Dispatch = DispatchConfig{
+		Interval: getDurationOrDefault("DISPATCH_INTERVAL", time.Minute),
+		Lead:     getDurationOrDefault("DISPATCH_LEAD", 10*time.Minute),
+	}
+
 	return cfg, err
 }
 
@@ -45,11 +45,16 @@ func (q *Queries) DeleteBranch(ctx context.Context, id int64) error {
 }
 
 const getBranch = `-- name: GetBranch :one
-SELECT id, name, location, organization_id FROM branches WHERE id = $1
+SELECT id, name, location, organization_id FROM branches WHERE id = $1 AND organization_id = $2
 `
 
-func (q *Queries) GetBranch(ctx context.Context, id int64) (Branch, error) {
-	row := q.db.QueryRow(ctx, getBranch, id)
+type GetBranchParams struct {
+	ID             int64 `json:"id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) GetBranch(ctx context.Context, arg GetBranchParams) (Branch, error) {
+	row := q.db.QueryRow(ctx, getBranch, arg.ID, arg.OrganizationID)
 	var i Branch
 	err := row.Scan(
 		&i.ID,
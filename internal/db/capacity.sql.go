@@ -0,0 +1,246 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: capacity.sql
+
+package db
+
+import (
+	"context"
+)
+
+const deleteProductSize = `-- name: DeleteProductSize :execrows
+DELETE FROM product_sizes
+WHERE organization_id = $1 AND product_id = $2
+`
+
+type DeleteProductSizeParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	ProductID      string `json:"product_id"`
+}
+
+func (q *Queries) DeleteProductSize(ctx context.Context, arg DeleteProductSizeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteProductSize, arg.OrganizationID, arg.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getVehicleLimits = `-- name: GetVehicleLimits :one
+SELECT organization_id, vehicle_type, max_orders, max_weight, max_volume, max_distance
+FROM vehicle_limits
+WHERE organization_id = $1 AND vehicle_type = $2
+`
+
+type GetVehicleLimitsParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	VehicleType    string `json:"vehicle_type"`
+}
+
+func (q *Queries) GetVehicleLimits(ctx context.Context, arg GetVehicleLimitsParams) (VehicleLimit, error) {
+	row := q.db.QueryRow(ctx, getVehicleLimits, arg.OrganizationID, arg.VehicleType)
+	var i VehicleLimit
+	err := row.Scan(
+		&i.OrganizationID,
+		&i.VehicleType,
+		&i.MaxOrders,
+		&i.MaxWeight,
+		&i.MaxVolume,
+		&i.MaxDistance,
+	)
+	return i, err
+}
+
+const listOrderSizes = `-- name: ListOrderSizes :many
+SELECT oi.order_id,
+       SUM(oi.amount * COALESCE(ps.weight, $1::integer))::float8 AS weight,
+       SUM(oi.amount * COALESCE(ps.volume, $2::integer))::float8 AS volume
+FROM order_items oi
+         JOIN orders o ON o.id = oi.order_id
+         LEFT JOIN product_sizes ps ON ps.organization_id = o.organization_id AND ps.product_id = oi.product_id
+WHERE o.organization_id = $3
+  AND oi.order_id = ANY ($4::bigint[])
+GROUP BY oi.order_id
+`
+
+type ListOrderSizesParams struct {
+	DefaultWeight  int32   `json:"default_weight"`
+	DefaultVolume  int32   `json:"default_volume"`
+	OrganizationID int64   `json:"organization_id"`
+	OrderIds       []int64 `json:"order_ids"`
+}
+
+type ListOrderSizesRow struct {
+	OrderID int64   `json:"order_id"`
+	Weight  float64 `json:"weight"`
+	Volume  float64 `json:"volume"`
+}
+
+// Total size of the items of each order, products missing from the catalog
+// count with the default unit size. Orders without items are not returned.
+func (q *Queries) ListOrderSizes(ctx context.Context, arg ListOrderSizesParams) ([]ListOrderSizesRow, error) {
+	rows, err := q.db.Query(ctx, listOrderSizes,
+		arg.DefaultWeight,
+		arg.DefaultVolume,
+		arg.OrganizationID,
+		arg.OrderIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderSizesRow
+	for rows.Next() {
+		var i ListOrderSizesRow
+		if err := rows.Scan(&i.OrderID, &i.Weight, &i.Volume); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductSizes = `-- name: ListProductSizes :many
+SELECT organization_id, product_id, weight, volume
+FROM product_sizes
+WHERE organization_id = $1
+ORDER BY product_id
+`
+
+func (q *Queries) ListProductSizes(ctx context.Context, organizationID int64) ([]ProductSize, error) {
+	rows, err := q.db.Query(ctx, listProductSizes, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductSize
+	for rows.Next() {
+		var i ProductSize
+		if err := rows.Scan(
+			&i.OrganizationID,
+			&i.ProductID,
+			&i.Weight,
+			&i.Volume,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVehicleLimits = `-- name: ListVehicleLimits :many
+SELECT organization_id, vehicle_type, max_orders, max_weight, max_volume, max_distance
+FROM vehicle_limits
+WHERE organization_id = $1
+ORDER BY vehicle_type
+`
+
+func (q *Queries) ListVehicleLimits(ctx context.Context, organizationID int64) ([]VehicleLimit, error) {
+	rows, err := q.db.Query(ctx, listVehicleLimits, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VehicleLimit
+	for rows.Next() {
+		var i VehicleLimit
+		if err := rows.Scan(
+			&i.OrganizationID,
+			&i.VehicleType,
+			&i.MaxOrders,
+			&i.MaxWeight,
+			&i.MaxVolume,
+			&i.MaxDistance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertProductSize = `-- name: UpsertProductSize :one
+INSERT INTO product_sizes (organization_id, product_id, weight, volume)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (organization_id, product_id) DO UPDATE
+    SET weight = excluded.weight,
+        volume = excluded.volume
+RETURNING organization_id, product_id, weight, volume
+`
+
+type UpsertProductSizeParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	ProductID      string `json:"product_id"`
+	Weight         int32  `json:"weight"`
+	Volume         int32  `json:"volume"`
+}
+
+func (q *Queries) UpsertProductSize(ctx context.Context, arg UpsertProductSizeParams) (ProductSize, error) {
+	row := q.db.QueryRow(ctx, upsertProductSize,
+		arg.OrganizationID,
+		arg.ProductID,
+		arg.Weight,
+		arg.Volume,
+	)
+	var i ProductSize
+	err := row.Scan(
+		&i.OrganizationID,
+		&i.ProductID,
+		&i.Weight,
+		&i.Volume,
+	)
+	return i, err
+}
+
+const upsertVehicleLimits = `-- name: UpsertVehicleLimits :one
+INSERT INTO vehicle_limits (organization_id, vehicle_type, max_orders, max_weight, max_volume, max_distance)
+VALUES ($1, $2, $3, $4,
+        $5, $6)
+ON CONFLICT (organization_id, vehicle_type) DO UPDATE
+    SET max_orders   = excluded.max_orders,
+        max_weight   = excluded.max_weight,
+        max_volume   = excluded.max_volume,
+        max_distance = excluded.max_distance
+RETURNING organization_id, vehicle_type, max_orders, max_weight, max_volume, max_distance
+`
+
+type UpsertVehicleLimitsParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	VehicleType    string `json:"vehicle_type"`
+	MaxOrders      *int32 `json:"max_orders"`
+	MaxWeight      *int32 `json:"max_weight"`
+	MaxVolume      *int32 `json:"max_volume"`
+	MaxDistance    *int32 `json:"max_distance"`
+}
+
+func (q *Queries) UpsertVehicleLimits(ctx context.Context, arg UpsertVehicleLimitsParams) (VehicleLimit, error) {
+	row := q.db.QueryRow(ctx, upsertVehicleLimits,
+		arg.OrganizationID,
+		arg.VehicleType,
+		arg.MaxOrders,
+		arg.MaxWeight,
+		arg.MaxVolume,
+		arg.MaxDistance,
+	)
+	var i VehicleLimit
+	err := row.Scan(
+		&i.OrganizationID,
+		&i.VehicleType,
+		&i.MaxOrders,
+		&i.MaxWeight,
+		&i.MaxVolume,
+		&i.MaxDistance,
+	)
+	return i, err
+}
@@ -0,0 +1,287 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: cash.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const confirmRideCashHandover = `-- name: ConfirmRideCashHandover :one
+UPDATE ride_cash_reports
+SET expected       = $1,
+    received       = $2,
+    confirmed_by   = $3,
+    confirmed_at   = CURRENT_TIMESTAMP,
+    branch_comment = $4
+WHERE ride_id = $5
+  AND confirmed_at IS NULL
+RETURNING id, ride_id, expected, collected, reported_by, reported_at, driver_comment, received, confirmed_by, confirmed_at, branch_comment
+`
+
+type ConfirmRideCashHandoverParams struct {
+	Expected      pgtype.Numeric `json:"expected"`
+	Received      pgtype.Numeric `json:"received"`
+	ConfirmedBy   *int64         `json:"confirmed_by"`
+	BranchComment string         `json:"branch_comment"`
+	RideID        int64          `json:"ride_id"`
+}
+
+func (q *Queries) ConfirmRideCashHandover(ctx context.Context, arg ConfirmRideCashHandoverParams) (RideCashReport, error) {
+	row := q.db.QueryRow(ctx, confirmRideCashHandover,
+		arg.Expected,
+		arg.Received,
+		arg.ConfirmedBy,
+		arg.BranchComment,
+		arg.RideID,
+	)
+	var i RideCashReport
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.Expected,
+		&i.Collected,
+		&i.ReportedBy,
+		&i.ReportedAt,
+		&i.DriverComment,
+		&i.Received,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.BranchComment,
+	)
+	return i, err
+}
+
+const getRideCashReport = `-- name: GetRideCashReport :one
+SELECT id, ride_id, expected, collected, reported_by, reported_at, driver_comment, received, confirmed_by, confirmed_at, branch_comment FROM ride_cash_reports
+WHERE ride_id = $1
+`
+
+func (q *Queries) GetRideCashReport(ctx context.Context, rideID int64) (RideCashReport, error) {
+	row := q.db.QueryRow(ctx, getRideCashReport, rideID)
+	var i RideCashReport
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.Expected,
+		&i.Collected,
+		&i.ReportedBy,
+		&i.ReportedAt,
+		&i.DriverComment,
+		&i.Received,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.BranchComment,
+	)
+	return i, err
+}
+
+const listCashDiscrepancies = `-- name: ListCashDiscrepancies :many
+SELECT r.driver_id,
+       COALESCE(u.name, '')::text                                              AS driver_name,
+       COALESCE(u.surname, '')::text                                           AS driver_surname,
+       (r.created_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date       AS day,
+       COUNT(*)                                                                AS ride_count,
+       COUNT(rcr.confirmed_at)                                                 AS confirmed_count,
+       SUM(rcr.expected)::numeric                                              AS expected,
+       SUM(rcr.collected)::numeric                                             AS collected,
+       COALESCE(SUM(rcr.received), 0)::numeric                                 AS received,
+       SUM(COALESCE(rcr.received, rcr.collected) - rcr.expected)::numeric      AS discrepancy
+FROM ride_cash_reports rcr
+         JOIN rides r ON r.id = rcr.ride_id
+         JOIN branches b ON b.id = r.branch_id
+         JOIN organizations org ON org.id = b.organization_id
+         LEFT JOIN drivers d ON d.id = r.driver_id
+         LEFT JOIN users u ON u.id = d.user_id
+WHERE b.organization_id = $1
+  AND (r.created_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date BETWEEN $2::date AND $3::date
+  AND ($4::bigint IS NULL OR r.driver_id = $4)
+GROUP BY r.driver_id, u.name, u.surname, day
+HAVING NOT $5::boolean
+    OR SUM(COALESCE(rcr.received, rcr.collected) - rcr.expected) <> 0
+ORDER BY day DESC, r.driver_id
+`
+
+type ListCashDiscrepanciesParams struct {
+	OrganizationID    int64       `json:"organization_id"`
+	DateFrom          pgtype.Date `json:"date_from"`
+	DateTo            pgtype.Date `json:"date_to"`
+	DriverID          *int64      `json:"driver_id"`
+	OnlyDiscrepancies bool        `json:"only_discrepancies"`
+}
+
+type ListCashDiscrepanciesRow struct {
+	DriverID       *int64         `json:"driver_id"`
+	DriverName     string         `json:"driver_name"`
+	DriverSurname  string         `json:"driver_surname"`
+	Day            pgtype.Date    `json:"day"`
+	RideCount      int64          `json:"ride_count"`
+	ConfirmedCount int64          `json:"confirmed_count"`
+	Expected       pgtype.Numeric `json:"expected"`
+	Collected      pgtype.Numeric `json:"collected"`
+	Received       pgtype.Numeric `json:"received"`
+	Discrepancy    pgtype.Numeric `json:"discrepancy"`
+}
+
+// Sums cash reports per ride driver and local day of the ride start
+func (q *Queries) ListCashDiscrepancies(ctx context.Context, arg ListCashDiscrepanciesParams) ([]ListCashDiscrepanciesRow, error) {
+	rows, err := q.db.Query(ctx, listCashDiscrepancies,
+		arg.OrganizationID,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.DriverID,
+		arg.OnlyDiscrepancies,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCashDiscrepanciesRow
+	for rows.Next() {
+		var i ListCashDiscrepanciesRow
+		if err := rows.Scan(
+			&i.DriverID,
+			&i.DriverName,
+			&i.DriverSurname,
+			&i.Day,
+			&i.RideCount,
+			&i.ConfirmedCount,
+			&i.Expected,
+			&i.Collected,
+			&i.Received,
+			&i.Discrepancy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRideCashOrders = `-- name: ListRideCashOrders :many
+SELECT o.id, o.customer_name, o.phone, o.city, o.street, o.apartment, o.floor, o.doorphone, o.building, o.entrance, o.comment, o.cost, o.status, o.location, o.created_at, o.external_id, o.branch_id, o.out_of_zone, o.organization_id, o.iiko_organization_id, o.guest_count, o.courier_name, o.courier_phone, o.cash_to_collect, o.promised_at, o.lateness, o.source, o.iiko_status, o.iiko_delivery_status, o.location_source, o.customer_id
+FROM orders o
+WHERE o.id IN (SELECT d.order_id FROM ride_order_detachments d WHERE d.ride_id = $1
+               UNION
+               SELECT rto.order_id FROM rides_to_orders rto WHERE rto.ride_id = $1
+               UNION
+               SELECT dc.order_id FROM delivery_confirmations dc WHERE dc.ride_id = $1)
+  AND (o.status = 'delivered'
+    OR EXISTS (SELECT 1 FROM delivery_confirmations dc WHERE dc.order_id = o.id AND dc.ride_id = $1))
+ORDER BY o.id
+`
+
+// Orders the driver of a ride handed over: delivered while on the ride or
+// confirmed with a proof of delivery from the ride
+func (q *Queries) ListRideCashOrders(ctx context.Context, rideID int64) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listRideCashOrders, rideID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerName,
+			&i.Phone,
+			&i.City,
+			&i.Street,
+			&i.Apartment,
+			&i.Floor,
+			&i.Doorphone,
+			&i.Building,
+			&i.Entrance,
+			&i.Comment,
+			&i.Cost,
+			&i.Status,
+			&i.Location,
+			&i.CreatedAt,
+			&i.ExternalID,
+			&i.BranchID,
+			&i.OutOfZone,
+			&i.OrganizationID,
+			&i.IikoOrganizationID,
+			&i.GuestCount,
+			&i.CourierName,
+			&i.CourierPhone,
+			&i.CashToCollect,
+			&i.PromisedAt,
+			&i.Lateness,
+			&i.Source,
+			&i.IikoStatus,
+			&i.IikoDeliveryStatus,
+			&i.LocationSource,
+			&i.CustomerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertRideCashReport = `-- name: UpsertRideCashReport :one
+INSERT INTO ride_cash_reports (
+    ride_id,
+    expected,
+    collected,
+    reported_by,
+    driver_comment
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (ride_id) DO UPDATE
+    SET expected       = EXCLUDED.expected,
+        collected      = EXCLUDED.collected,
+        reported_by    = EXCLUDED.reported_by,
+        reported_at    = CURRENT_TIMESTAMP,
+        driver_comment = EXCLUDED.driver_comment
+WHERE ride_cash_reports.confirmed_at IS NULL
+RETURNING id, ride_id, expected, collected, reported_by, reported_at, driver_comment, received, confirmed_by, confirmed_at, branch_comment
+`
+
+type UpsertRideCashReportParams struct {
+	RideID        int64          `json:"ride_id"`
+	Expected      pgtype.Numeric `json:"expected"`
+	Collected     pgtype.Numeric `json:"collected"`
+	ReportedBy    *int64         `json:"reported_by"`
+	DriverComment string         `json:"driver_comment"`
+}
+
+// Drivers may correct their report until the branch confirms the handover
+func (q *Queries) UpsertRideCashReport(ctx context.Context, arg UpsertRideCashReportParams) (RideCashReport, error) {
+	row := q.db.QueryRow(ctx, upsertRideCashReport,
+		arg.RideID,
+		arg.Expected,
+		arg.Collected,
+		arg.ReportedBy,
+		arg.DriverComment,
+	)
+	var i RideCashReport
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.Expected,
+		&i.Collected,
+		&i.ReportedBy,
+		&i.ReportedAt,
+		&i.DriverComment,
+		&i.Received,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.BranchComment,
+	)
+	return i, err
+}
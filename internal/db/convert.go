@@ -0,0 +1,14 @@
+package db
+
+import (
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NumericFromFloat converts a float to a numeric column value
+func NumericFromFloat(f float64) pgtype.Numeric {
+	var n pgtype.Numeric
+	_ = n.Scan(strconv.FormatFloat(f, 'f', -1, 64))
+	return n
+}
@@ -0,0 +1,417 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: customers.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createCustomerFlag = `-- name: CreateCustomerFlag :one
+INSERT INTO customer_flags (customer_id, flag, comment, created_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (customer_id, flag) DO UPDATE
+    SET comment    = EXCLUDED.comment,
+        created_by = EXCLUDED.created_by,
+        created_at = CURRENT_TIMESTAMP
+RETURNING id, customer_id, flag, comment, created_by, created_at
+`
+
+type CreateCustomerFlagParams struct {
+	CustomerID int64  `json:"customer_id"`
+	Flag       string `json:"flag"`
+	Comment    string `json:"comment"`
+	CreatedBy  *int64 `json:"created_by"`
+}
+
+func (q *Queries) CreateCustomerFlag(ctx context.Context, arg CreateCustomerFlagParams) (CustomerFlag, error) {
+	row := q.db.QueryRow(ctx, createCustomerFlag,
+		arg.CustomerID,
+		arg.Flag,
+		arg.Comment,
+		arg.CreatedBy,
+	)
+	var i CustomerFlag
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.Flag,
+		&i.Comment,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteCustomerFlag = `-- name: DeleteCustomerFlag :execrows
+DELETE FROM customer_flags
+WHERE id = $1 AND customer_id = $2
+`
+
+type DeleteCustomerFlagParams struct {
+	ID         int64 `json:"id"`
+	CustomerID int64 `json:"customer_id"`
+}
+
+func (q *Queries) DeleteCustomerFlag(ctx context.Context, arg DeleteCustomerFlagParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCustomerFlag, arg.ID, arg.CustomerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getCustomer = `-- name: GetCustomer :one
+SELECT id, organization_id, phone, name, notes, created_at, updated_at FROM customers
+WHERE id = $1 AND organization_id = $2
+`
+
+type GetCustomerParams struct {
+	ID             int64 `json:"id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) GetCustomer(ctx context.Context, arg GetCustomerParams) (Customer, error) {
+	row := q.db.QueryRow(ctx, getCustomer, arg.ID, arg.OrganizationID)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Phone,
+		&i.Name,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listCustomerAddresses = `-- name: ListCustomerAddresses :many
+SELECT id, customer_id, city, street, building, apartment, entrance, floor, doorphone, location, use_count, last_used_at FROM customer_addresses
+WHERE customer_id = $1
+ORDER BY last_used_at DESC, id DESC
+`
+
+func (q *Queries) ListCustomerAddresses(ctx context.Context, customerID int64) ([]CustomerAddress, error) {
+	rows, err := q.db.Query(ctx, listCustomerAddresses, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CustomerAddress
+	for rows.Next() {
+		var i CustomerAddress
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.City,
+			&i.Street,
+			&i.Building,
+			&i.Apartment,
+			&i.Entrance,
+			&i.Floor,
+			&i.Doorphone,
+			&i.Location,
+			&i.UseCount,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerFlags = `-- name: ListCustomerFlags :many
+SELECT id, customer_id, flag, comment, created_by, created_at FROM customer_flags
+WHERE customer_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListCustomerFlags(ctx context.Context, customerID int64) ([]CustomerFlag, error) {
+	rows, err := q.db.Query(ctx, listCustomerFlags, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CustomerFlag
+	for rows.Next() {
+		var i CustomerFlag
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.Flag,
+			&i.Comment,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomerOrders = `-- name: ListCustomerOrders :many
+SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id, branch_id, out_of_zone, organization_id, iiko_organization_id, guest_count, courier_name, courier_phone, cash_to_collect, promised_at, lateness, source, iiko_status, iiko_delivery_status, location_source, customer_id FROM orders
+WHERE customer_id = $1 AND organization_id = $2
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListCustomerOrdersParams struct {
+	CustomerID     *int64 `json:"customer_id"`
+	OrganizationID int64  `json:"organization_id"`
+	Limit          int32  `json:"limit"`
+	Offset         int32  `json:"offset"`
+}
+
+func (q *Queries) ListCustomerOrders(ctx context.Context, arg ListCustomerOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listCustomerOrders,
+		arg.CustomerID,
+		arg.OrganizationID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerName,
+			&i.Phone,
+			&i.City,
+			&i.Street,
+			&i.Apartment,
+			&i.Floor,
+			&i.Doorphone,
+			&i.Building,
+			&i.Entrance,
+			&i.Comment,
+			&i.Cost,
+			&i.Status,
+			&i.Location,
+			&i.CreatedAt,
+			&i.ExternalID,
+			&i.BranchID,
+			&i.OutOfZone,
+			&i.OrganizationID,
+			&i.IikoOrganizationID,
+			&i.GuestCount,
+			&i.CourierName,
+			&i.CourierPhone,
+			&i.CashToCollect,
+			&i.PromisedAt,
+			&i.Lateness,
+			&i.Source,
+			&i.IikoStatus,
+			&i.IikoDeliveryStatus,
+			&i.LocationSource,
+			&i.CustomerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomers = `-- name: ListCustomers :many
+SELECT c.id, c.organization_id, c.phone, c.name, c.notes, c.created_at, c.updated_at, COUNT(o.id) AS order_count
+FROM customers c
+         LEFT JOIN orders o ON o.customer_id = c.id
+WHERE c.organization_id = $1
+  AND ($2::text IS NULL
+    OR c.phone LIKE '%' || $2 || '%'
+    OR c.name ILIKE '%' || $2 || '%')
+  AND ($3::boolean IS NULL
+    OR EXISTS (SELECT 1 FROM customer_flags f WHERE f.customer_id = c.id) = $3)
+GROUP BY c.id
+ORDER BY c.updated_at DESC, c.id DESC
+LIMIT $5 OFFSET $4
+`
+
+type ListCustomersParams struct {
+	OrganizationID int64   `json:"organization_id"`
+	Search         *string `json:"search"`
+	Flagged        *bool   `json:"flagged"`
+	RowOffset      int32   `json:"row_offset"`
+	RowLimit       int32   `json:"row_limit"`
+}
+
+type ListCustomersRow struct {
+	ID             int64            `json:"id"`
+	OrganizationID int64            `json:"organization_id"`
+	Phone          string           `json:"phone"`
+	Name           string           `json:"name"`
+	Notes          string           `json:"notes"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+	OrderCount     int64            `json:"order_count"`
+}
+
+func (q *Queries) ListCustomers(ctx context.Context, arg ListCustomersParams) ([]ListCustomersRow, error) {
+	rows, err := q.db.Query(ctx, listCustomers,
+		arg.OrganizationID,
+		arg.Search,
+		arg.Flagged,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCustomersRow
+	for rows.Next() {
+		var i ListCustomersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.Phone,
+			&i.Name,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.OrderCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateCustomer = `-- name: UpdateCustomer :one
+UPDATE customers
+SET name       = $3,
+    notes      = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND organization_id = $2
+RETURNING id, organization_id, phone, name, notes, created_at, updated_at
+`
+
+type UpdateCustomerParams struct {
+	ID             int64  `json:"id"`
+	OrganizationID int64  `json:"organization_id"`
+	Name           string `json:"name"`
+	Notes          string `json:"notes"`
+}
+
+func (q *Queries) UpdateCustomer(ctx context.Context, arg UpdateCustomerParams) (Customer, error) {
+	row := q.db.QueryRow(ctx, updateCustomer,
+		arg.ID,
+		arg.OrganizationID,
+		arg.Name,
+		arg.Notes,
+	)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Phone,
+		&i.Name,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertCustomer = `-- name: UpsertCustomer :one
+INSERT INTO customers (organization_id, phone, name)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, phone) DO UPDATE
+    SET name       = CASE WHEN EXCLUDED.name <> '' THEN EXCLUDED.name ELSE customers.name END,
+        updated_at = CASE
+                         WHEN EXCLUDED.name <> '' AND EXCLUDED.name <> customers.name THEN CURRENT_TIMESTAMP
+                         ELSE customers.updated_at END
+RETURNING id, organization_id, phone, name, notes, created_at, updated_at
+`
+
+type UpsertCustomerParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	Phone          string `json:"phone"`
+	Name           string `json:"name"`
+}
+
+// Keeps the latest non-empty name the customer gave, the customer only counts
+// as updated when the name changes
+func (q *Queries) UpsertCustomer(ctx context.Context, arg UpsertCustomerParams) (Customer, error) {
+	row := q.db.QueryRow(ctx, upsertCustomer, arg.OrganizationID, arg.Phone, arg.Name)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Phone,
+		&i.Name,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertCustomerAddress = `-- name: UpsertCustomerAddress :exec
+INSERT INTO customer_addresses (customer_id, city, street, building, apartment, entrance, floor, doorphone, location)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, point($9::float8, $10::float8))
+ON CONFLICT (customer_id, city, street, building, apartment) DO UPDATE
+    SET entrance     = COALESCE(EXCLUDED.entrance, customer_addresses.entrance),
+        floor        = COALESCE(EXCLUDED.floor, customer_addresses.floor),
+        doorphone    = COALESCE(NULLIF(EXCLUDED.doorphone, ''), customer_addresses.doorphone),
+        location     = CASE
+                           WHEN EXCLUDED.location ~= point(0, 0) THEN customer_addresses.location
+                           ELSE EXCLUDED.location END,
+        use_count    = customer_addresses.use_count + 1,
+        last_used_at = CURRENT_TIMESTAMP
+`
+
+type UpsertCustomerAddressParams struct {
+	CustomerID int64   `json:"customer_id"`
+	City       string  `json:"city"`
+	Street     string  `json:"street"`
+	Building   string  `json:"building"`
+	Apartment  string  `json:"apartment"`
+	Entrance   *int32  `json:"entrance"`
+	Floor      *int32  `json:"floor"`
+	Doorphone  *string `json:"doorphone"`
+	Lng        float64 `json:"lng"`
+	Lat        float64 `json:"lat"`
+}
+
+// Counts how often an address is used and keeps its latest real coordinates
+func (q *Queries) UpsertCustomerAddress(ctx context.Context, arg UpsertCustomerAddressParams) error {
+	_, err := q.db.Exec(ctx, upsertCustomerAddress,
+		arg.CustomerID,
+		arg.City,
+		arg.Street,
+		arg.Building,
+		arg.Apartment,
+		arg.Entrance,
+		arg.Floor,
+		arg.Doorphone,
+		arg.Lng,
+		arg.Lat,
+	)
+	return err
+}
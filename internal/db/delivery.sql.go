@@ -0,0 +1,205 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: delivery.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createDeliveryAttachment = `-- name: CreateDeliveryAttachment :one
+INSERT INTO delivery_attachments (
+    confirmation_id,
+    kind,
+    storage_key,
+    content_type,
+    size
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id, confirmation_id, kind, storage_key, content_type, size, created_at
+`
+
+type CreateDeliveryAttachmentParams struct {
+	ConfirmationID int64  `json:"confirmation_id"`
+	Kind           string `json:"kind"`
+	StorageKey     string `json:"storage_key"`
+	ContentType    string `json:"content_type"`
+	Size           int64  `json:"size"`
+}
+
+func (q *Queries) CreateDeliveryAttachment(ctx context.Context, arg CreateDeliveryAttachmentParams) (DeliveryAttachment, error) {
+	row := q.db.QueryRow(ctx, createDeliveryAttachment,
+		arg.ConfirmationID,
+		arg.Kind,
+		arg.StorageKey,
+		arg.ContentType,
+		arg.Size,
+	)
+	var i DeliveryAttachment
+	err := row.Scan(
+		&i.ID,
+		&i.ConfirmationID,
+		&i.Kind,
+		&i.StorageKey,
+		&i.ContentType,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createDeliveryConfirmation = `-- name: CreateDeliveryConfirmation :one
+INSERT INTO delivery_confirmations (
+    order_id,
+    ride_id,
+    confirmed_by,
+    delivered_at,
+    location,
+    accuracy,
+    recipient_name,
+    comment
+) VALUES (
+    $1, $2, $3, $4,
+    point($5::float8, $6::float8), $7, $8, $9
+)
+ON CONFLICT (order_id) DO NOTHING
+RETURNING id, order_id, ride_id, confirmed_by, delivered_at, location, accuracy, recipient_name, comment, created_at
+`
+
+type CreateDeliveryConfirmationParams struct {
+	OrderID       int64            `json:"order_id"`
+	RideID        *int64           `json:"ride_id"`
+	ConfirmedBy   *int64           `json:"confirmed_by"`
+	DeliveredAt   pgtype.Timestamp `json:"delivered_at"`
+	Lng           float64          `json:"lng"`
+	Lat           float64          `json:"lat"`
+	Accuracy      *float64         `json:"accuracy"`
+	RecipientName string           `json:"recipient_name"`
+	Comment       string           `json:"comment"`
+}
+
+func (q *Queries) CreateDeliveryConfirmation(ctx context.Context, arg CreateDeliveryConfirmationParams) (DeliveryConfirmation, error) {
+	row := q.db.QueryRow(ctx, createDeliveryConfirmation,
+		arg.OrderID,
+		arg.RideID,
+		arg.ConfirmedBy,
+		arg.DeliveredAt,
+		arg.Lng,
+		arg.Lat,
+		arg.Accuracy,
+		arg.RecipientName,
+		arg.Comment,
+	)
+	var i DeliveryConfirmation
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.RideID,
+		&i.ConfirmedBy,
+		&i.DeliveredAt,
+		&i.Location,
+		&i.Accuracy,
+		&i.RecipientName,
+		&i.Comment,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDeliveryAttachment = `-- name: GetDeliveryAttachment :one
+SELECT da.id, da.confirmation_id, da.kind, da.storage_key, da.content_type, da.size, da.created_at
+FROM delivery_attachments da
+         JOIN delivery_confirmations dc ON dc.id = da.confirmation_id
+         JOIN orders o ON o.id = dc.order_id
+WHERE da.id = $1 AND dc.order_id = $2 AND o.organization_id = $3
+`
+
+type GetDeliveryAttachmentParams struct {
+	ID             int64 `json:"id"`
+	OrderID        int64 `json:"order_id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) GetDeliveryAttachment(ctx context.Context, arg GetDeliveryAttachmentParams) (DeliveryAttachment, error) {
+	row := q.db.QueryRow(ctx, getDeliveryAttachment, arg.ID, arg.OrderID, arg.OrganizationID)
+	var i DeliveryAttachment
+	err := row.Scan(
+		&i.ID,
+		&i.ConfirmationID,
+		&i.Kind,
+		&i.StorageKey,
+		&i.ContentType,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDeliveryConfirmation = `-- name: GetDeliveryConfirmation :one
+SELECT dc.id, dc.order_id, dc.ride_id, dc.confirmed_by, dc.delivered_at, dc.location, dc.accuracy, dc.recipient_name, dc.comment, dc.created_at
+FROM delivery_confirmations dc
+         JOIN orders o ON o.id = dc.order_id
+WHERE dc.order_id = $1 AND o.organization_id = $2
+`
+
+type GetDeliveryConfirmationParams struct {
+	OrderID        int64 `json:"order_id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) GetDeliveryConfirmation(ctx context.Context, arg GetDeliveryConfirmationParams) (DeliveryConfirmation, error) {
+	row := q.db.QueryRow(ctx, getDeliveryConfirmation, arg.OrderID, arg.OrganizationID)
+	var i DeliveryConfirmation
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.RideID,
+		&i.ConfirmedBy,
+		&i.DeliveredAt,
+		&i.Location,
+		&i.Accuracy,
+		&i.RecipientName,
+		&i.Comment,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listDeliveryAttachments = `-- name: ListDeliveryAttachments :many
+SELECT id, confirmation_id, kind, storage_key, content_type, size, created_at FROM delivery_attachments
+WHERE confirmation_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListDeliveryAttachments(ctx context.Context, confirmationID int64) ([]DeliveryAttachment, error) {
+	rows, err := q.db.Query(ctx, listDeliveryAttachments, confirmationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeliveryAttachment
+	for rows.Next() {
+		var i DeliveryAttachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConfirmationID,
+			&i.Kind,
+			&i.StorageKey,
+			&i.ContentType,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
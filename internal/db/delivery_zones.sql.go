@@ -0,0 +1,118 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: delivery_zones.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createDeliveryZone = `-- name: CreateDeliveryZone :one
+INSERT INTO delivery_zones (branch_id, name, area)
+    VALUES ($1, $2, $3) RETURNING id, branch_id, name, area
+`
+
+type CreateDeliveryZoneParams struct {
+	BranchID int64          `json:"branch_id"`
+	Name     string         `json:"name"`
+	Area     pgtype.Polygon `json:"area"`
+}
+
+func (q *Queries) CreateDeliveryZone(ctx context.Context, arg CreateDeliveryZoneParams) (DeliveryZone, error) {
+	row := q.db.QueryRow(ctx, createDeliveryZone, arg.BranchID, arg.Name, arg.Area)
+	var i DeliveryZone
+	err := row.Scan(
+		&i.ID,
+		&i.BranchID,
+		&i.Name,
+		&i.Area,
+	)
+	return i, err
+}
+
+const deleteBranchDeliveryZones = `-- name: DeleteBranchDeliveryZones :exec
+DELETE FROM delivery_zones
+WHERE branch_id = $1
+`
+
+func (q *Queries) DeleteBranchDeliveryZones(ctx context.Context, branchID int64) error {
+	_, err := q.db.Exec(ctx, deleteBranchDeliveryZones, branchID)
+	return err
+}
+
+const listBranchDeliveryZones = `-- name: ListBranchDeliveryZones :many
+SELECT id, branch_id, name, area FROM delivery_zones
+WHERE branch_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListBranchDeliveryZones(ctx context.Context, branchID int64) ([]DeliveryZone, error) {
+	rows, err := q.db.Query(ctx, listBranchDeliveryZones, branchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeliveryZone
+	for rows.Next() {
+		var i DeliveryZone
+		if err := rows.Scan(
+			&i.ID,
+			&i.BranchID,
+			&i.Name,
+			&i.Area,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrganizationDeliveryZones = `-- name: ListOrganizationDeliveryZones :many
+SELECT dz.id, dz.branch_id, dz.name, dz.area, b.location AS branch_location
+FROM delivery_zones dz
+         JOIN branches b ON b.id = dz.branch_id
+WHERE b.organization_id = $1
+ORDER BY dz.id
+`
+
+type ListOrganizationDeliveryZonesRow struct {
+	ID             int64          `json:"id"`
+	BranchID       int64          `json:"branch_id"`
+	Name           string         `json:"name"`
+	Area           pgtype.Polygon `json:"area"`
+	BranchLocation pgtype.Point   `json:"branch_location"`
+}
+
+func (q *Queries) ListOrganizationDeliveryZones(ctx context.Context, organizationID int64) ([]ListOrganizationDeliveryZonesRow, error) {
+	rows, err := q.db.Query(ctx, listOrganizationDeliveryZones, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrganizationDeliveryZonesRow
+	for rows.Next() {
+		var i ListOrganizationDeliveryZonesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BranchID,
+			&i.Name,
+			&i.Area,
+			&i.BranchLocation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,246 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: dispatch.sql
+
+package db
+
+import (
+	"context"
+)
+
+const countOrdersOnActiveRides = `-- name: CountOrdersOnActiveRides :one
+SELECT COUNT(*)
+FROM rides_to_orders rto
+         JOIN rides r ON r.id = rto.ride_id
+WHERE r.ended_at IS NULL
+  AND rto.order_id = ANY ($1::bigint[])
+`
+
+func (q *Queries) CountOrdersOnActiveRides(ctx context.Context, orderIds []int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countOrdersOnActiveRides, orderIds)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getDispatchSettings = `-- name: GetDispatchSettings :one
+SELECT id, auto_dispatch, ride_capacity, dispatch_radius, dispatch_window_minutes
+FROM organizations
+WHERE id = $1
+`
+
+type GetDispatchSettingsRow struct {
+	ID                    int64 `json:"id"`
+	AutoDispatch          bool  `json:"auto_dispatch"`
+	RideCapacity          int32 `json:"ride_capacity"`
+	DispatchRadius        int32 `json:"dispatch_radius"`
+	DispatchWindowMinutes int32 `json:"dispatch_window_minutes"`
+}
+
+func (q *Queries) GetDispatchSettings(ctx context.Context, id int64) (GetDispatchSettingsRow, error) {
+	row := q.db.QueryRow(ctx, getDispatchSettings, id)
+	var i GetDispatchSettingsRow
+	err := row.Scan(
+		&i.ID,
+		&i.AutoDispatch,
+		&i.RideCapacity,
+		&i.DispatchRadius,
+		&i.DispatchWindowMinutes,
+	)
+	return i, err
+}
+
+const listAutoDispatchBranches = `-- name: ListAutoDispatchBranches :many
+SELECT b.id, b.name, b.location, b.organization_id
+FROM branches b
+         JOIN organizations org ON org.id = b.organization_id
+WHERE org.auto_dispatch AND org.balance > 0
+ORDER BY b.id
+`
+
+// Branches of paying organizations that let rides be created automatically
+func (q *Queries) ListAutoDispatchBranches(ctx context.Context) ([]Branch, error) {
+	rows, err := q.db.Query(ctx, listAutoDispatchBranches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Branch
+	for rows.Next() {
+		var i Branch
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Location,
+			&i.OrganizationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDispatchableOrders = `-- name: ListDispatchableOrders :many
+SELECT o.id, o.customer_name, o.phone, o.city, o.street, o.apartment, o.floor, o.doorphone, o.building, o.entrance, o.comment, o.cost, o.status, o.location, o.created_at, o.external_id, o.branch_id, o.out_of_zone, o.organization_id, o.iiko_organization_id, o.guest_count, o.courier_name, o.courier_phone, o.cash_to_collect, o.promised_at, o.lateness, o.source, o.iiko_status, o.iiko_delivery_status, o.location_source, o.customer_id
+FROM orders o
+         LEFT JOIN rides_to_orders rto ON rto.order_id = o.id
+WHERE rto.ride_id IS NULL
+  AND o.organization_id = $1
+  AND o.branch_id = $2
+  AND o.status = 'ready'
+  AND o.location_source <> 'missing'
+ORDER BY o.id
+`
+
+type ListDispatchableOrdersParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	BranchID       *int64 `json:"branch_id"`
+}
+
+// Ready orders of a branch waiting for a ride that have a usable location
+func (q *Queries) ListDispatchableOrders(ctx context.Context, arg ListDispatchableOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listDispatchableOrders, arg.OrganizationID, arg.BranchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerName,
+			&i.Phone,
+			&i.City,
+			&i.Street,
+			&i.Apartment,
+			&i.Floor,
+			&i.Doorphone,
+			&i.Building,
+			&i.Entrance,
+			&i.Comment,
+			&i.Cost,
+			&i.Status,
+			&i.Location,
+			&i.CreatedAt,
+			&i.ExternalID,
+			&i.BranchID,
+			&i.OutOfZone,
+			&i.OrganizationID,
+			&i.IikoOrganizationID,
+			&i.GuestCount,
+			&i.CourierName,
+			&i.CourierPhone,
+			&i.CashToCollect,
+			&i.PromisedAt,
+			&i.Lateness,
+			&i.Source,
+			&i.IikoStatus,
+			&i.IikoDeliveryStatus,
+			&i.LocationSource,
+			&i.CustomerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIdleDrivers = `-- name: ListIdleDrivers :many
+SELECT d.id, d.organization_id, d.user_id, d.phone, d.vehicle_type, d.home_branch_id, d.status, d.created_at, d.updated_at
+FROM drivers d
+WHERE d.organization_id = $1
+  AND d.status = 'on_duty'
+  AND NOT EXISTS (SELECT 1 FROM rides r WHERE r.driver_id = d.id AND r.ended_at IS NULL)
+ORDER BY d.home_branch_id IS DISTINCT FROM $2::bigint, d.updated_at
+`
+
+type ListIdleDriversParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	BranchID       *int64 `json:"branch_id"`
+}
+
+// Drivers on duty without an active ride, drivers of the branch first
+func (q *Queries) ListIdleDrivers(ctx context.Context, arg ListIdleDriversParams) ([]Driver, error) {
+	rows, err := q.db.Query(ctx, listIdleDrivers, arg.OrganizationID, arg.BranchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Driver
+	for rows.Next() {
+		var i Driver
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.UserID,
+			&i.Phone,
+			&i.VehicleType,
+			&i.HomeBranchID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateDispatchSettings = `-- name: UpdateDispatchSettings :one
+UPDATE organizations
+SET auto_dispatch           = $1,
+    ride_capacity           = $2,
+    dispatch_radius         = $3,
+    dispatch_window_minutes = $4
+WHERE id = $5
+RETURNING id, auto_dispatch, ride_capacity, dispatch_radius, dispatch_window_minutes
+`
+
+type UpdateDispatchSettingsParams struct {
+	AutoDispatch          bool  `json:"auto_dispatch"`
+	RideCapacity          int32 `json:"ride_capacity"`
+	DispatchRadius        int32 `json:"dispatch_radius"`
+	DispatchWindowMinutes int32 `json:"dispatch_window_minutes"`
+	ID                    int64 `json:"id"`
+}
+
+type UpdateDispatchSettingsRow struct {
+	ID                    int64 `json:"id"`
+	AutoDispatch          bool  `json:"auto_dispatch"`
+	RideCapacity          int32 `json:"ride_capacity"`
+	DispatchRadius        int32 `json:"dispatch_radius"`
+	DispatchWindowMinutes int32 `json:"dispatch_window_minutes"`
+}
+
+func (q *Queries) UpdateDispatchSettings(ctx context.Context, arg UpdateDispatchSettingsParams) (UpdateDispatchSettingsRow, error) {
+	row := q.db.QueryRow(ctx, updateDispatchSettings,
+		arg.AutoDispatch,
+		arg.RideCapacity,
+		arg.DispatchRadius,
+		arg.DispatchWindowMinutes,
+		arg.ID,
+	)
+	var i UpdateDispatchSettingsRow
+	err := row.Scan(
+		&i.ID,
+		&i.AutoDispatch,
+		&i.RideCapacity,
+		&i.DispatchRadius,
+		&i.DispatchWindowMinutes,
+	)
+	return i, err
+}
@@ -0,0 +1,280 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: drivers.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createDriver = `-- name: CreateDriver :one
+INSERT INTO drivers (
+    organization_id,
+    user_id,
+    phone,
+    vehicle_type,
+    home_branch_id,
+    status
+)
+SELECT u.organization_id, u.id, $1, $2, $3, $4
+FROM users u
+WHERE u.id = $5 AND u.organization_id = $6
+RETURNING id, organization_id, user_id, phone, vehicle_type, home_branch_id, status, created_at, updated_at
+`
+
+type CreateDriverParams struct {
+	Phone          string `json:"phone"`
+	VehicleType    string `json:"vehicle_type"`
+	HomeBranchID   *int64 `json:"home_branch_id"`
+	Status         string `json:"status"`
+	UserID         int64  `json:"user_id"`
+	OrganizationID int64  `json:"organization_id"`
+}
+
+// Only users of the organization can become its drivers
+func (q *Queries) CreateDriver(ctx context.Context, arg CreateDriverParams) (Driver, error) {
+	row := q.db.QueryRow(ctx, createDriver,
+		arg.Phone,
+		arg.VehicleType,
+		arg.HomeBranchID,
+		arg.Status,
+		arg.UserID,
+		arg.OrganizationID,
+	)
+	var i Driver
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Phone,
+		&i.VehicleType,
+		&i.HomeBranchID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getDriver = `-- name: GetDriver :one
+SELECT d.id, d.organization_id, d.user_id, d.phone, d.vehicle_type, d.home_branch_id, d.status, d.created_at, d.updated_at,
+       COALESCE(u.name, '')::text    AS name,
+       COALESCE(u.surname, '')::text AS surname,
+       ar.id                         AS active_ride_id
+FROM drivers d
+         JOIN users u ON u.id = d.user_id
+         LEFT JOIN rides ar ON ar.driver_id = d.id AND ar.ended_at IS NULL
+WHERE d.id = $1 AND d.organization_id = $2
+`
+
+type GetDriverParams struct {
+	ID             int64 `json:"id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+type GetDriverRow struct {
+	ID             int64            `json:"id"`
+	OrganizationID int64            `json:"organization_id"`
+	UserID         int64            `json:"user_id"`
+	Phone          string           `json:"phone"`
+	VehicleType    string           `json:"vehicle_type"`
+	HomeBranchID   *int64           `json:"home_branch_id"`
+	Status         string           `json:"status"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+	Name           string           `json:"name"`
+	Surname        string           `json:"surname"`
+	ActiveRideID   *int64           `json:"active_ride_id"`
+}
+
+func (q *Queries) GetDriver(ctx context.Context, arg GetDriverParams) (GetDriverRow, error) {
+	row := q.db.QueryRow(ctx, getDriver, arg.ID, arg.OrganizationID)
+	var i GetDriverRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Phone,
+		&i.VehicleType,
+		&i.HomeBranchID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Surname,
+		&i.ActiveRideID,
+	)
+	return i, err
+}
+
+const getDriverActiveRideID = `-- name: GetDriverActiveRideID :one
+SELECT id FROM rides
+WHERE driver_id = $1 AND ended_at IS NULL
+`
+
+func (q *Queries) GetDriverActiveRideID(ctx context.Context, driverID *int64) (int64, error) {
+	row := q.db.QueryRow(ctx, getDriverActiveRideID, driverID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getDriverByUserID = `-- name: GetDriverByUserID :one
+SELECT id, organization_id, user_id, phone, vehicle_type, home_branch_id, status, created_at, updated_at
+FROM drivers
+WHERE user_id = $1 AND organization_id = $2
+`
+
+type GetDriverByUserIDParams struct {
+	UserID         int64 `json:"user_id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) GetDriverByUserID(ctx context.Context, arg GetDriverByUserIDParams) (Driver, error) {
+	row := q.db.QueryRow(ctx, getDriverByUserID, arg.UserID, arg.OrganizationID)
+	var i Driver
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Phone,
+		&i.VehicleType,
+		&i.HomeBranchID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listDrivers = `-- name: ListDrivers :many
+SELECT d.id, d.organization_id, d.user_id, d.phone, d.vehicle_type, d.home_branch_id, d.status, d.created_at, d.updated_at,
+       COALESCE(u.name, '')::text    AS name,
+       COALESCE(u.surname, '')::text AS surname,
+       ar.id                         AS active_ride_id
+FROM drivers d
+         JOIN users u ON u.id = d.user_id
+         LEFT JOIN rides ar ON ar.driver_id = d.id AND ar.ended_at IS NULL
+WHERE d.organization_id = $1
+  AND ($2::text IS NULL OR d.status = $2)
+  AND ($3::bigint IS NULL OR d.home_branch_id = $3)
+ORDER BY d.id
+`
+
+type ListDriversParams struct {
+	OrganizationID int64   `json:"organization_id"`
+	Status         *string `json:"status"`
+	BranchID       *int64  `json:"branch_id"`
+}
+
+type ListDriversRow struct {
+	ID             int64            `json:"id"`
+	OrganizationID int64            `json:"organization_id"`
+	UserID         int64            `json:"user_id"`
+	Phone          string           `json:"phone"`
+	VehicleType    string           `json:"vehicle_type"`
+	HomeBranchID   *int64           `json:"home_branch_id"`
+	Status         string           `json:"status"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+	Name           string           `json:"name"`
+	Surname        string           `json:"surname"`
+	ActiveRideID   *int64           `json:"active_ride_id"`
+}
+
+func (q *Queries) ListDrivers(ctx context.Context, arg ListDriversParams) ([]ListDriversRow, error) {
+	rows, err := q.db.Query(ctx, listDrivers, arg.OrganizationID, arg.Status, arg.BranchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDriversRow
+	for rows.Next() {
+		var i ListDriversRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.UserID,
+			&i.Phone,
+			&i.VehicleType,
+			&i.HomeBranchID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Surname,
+			&i.ActiveRideID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setRideDriver = `-- name: SetRideDriver :exec
+UPDATE rides
+SET driver_id = $2
+WHERE id = $1
+`
+
+type SetRideDriverParams struct {
+	ID       int64  `json:"id"`
+	DriverID *int64 `json:"driver_id"`
+}
+
+func (q *Queries) SetRideDriver(ctx context.Context, arg SetRideDriverParams) error {
+	_, err := q.db.Exec(ctx, setRideDriver, arg.ID, arg.DriverID)
+	return err
+}
+
+const updateDriver = `-- name: UpdateDriver :one
+UPDATE drivers
+SET phone          = $1,
+    vehicle_type   = $2,
+    home_branch_id = $3,
+    status         = $4,
+    updated_at     = CURRENT_TIMESTAMP
+WHERE id = $5 AND organization_id = $6
+RETURNING id, organization_id, user_id, phone, vehicle_type, home_branch_id, status, created_at, updated_at
+`
+
+type UpdateDriverParams struct {
+	Phone          string `json:"phone"`
+	VehicleType    string `json:"vehicle_type"`
+	HomeBranchID   *int64 `json:"home_branch_id"`
+	Status         string `json:"status"`
+	ID             int64  `json:"id"`
+	OrganizationID int64  `json:"organization_id"`
+}
+
+func (q *Queries) UpdateDriver(ctx context.Context, arg UpdateDriverParams) (Driver, error) {
+	row := q.db.QueryRow(ctx, updateDriver,
+		arg.Phone,
+		arg.VehicleType,
+		arg.HomeBranchID,
+		arg.Status,
+		arg.ID,
+		arg.OrganizationID,
+	)
+	var i Driver
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Phone,
+		&i.VehicleType,
+		&i.HomeBranchID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,131 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: eta.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getOrderStopETA = `-- name: GetOrderStopETA :one
+SELECT eta
+FROM rides_to_orders
+WHERE order_id = $1 AND stop_status IN ('pending', 'arrived')
+`
+
+// Estimate of the stop the driver is on the way to, if any
+func (q *Queries) GetOrderStopETA(ctx context.Context, orderID int64) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, getOrderStopETA, orderID)
+	var eta pgtype.Timestamp
+	err := row.Scan(&eta)
+	return eta, err
+}
+
+const getVehicleProfile = `-- name: GetVehicleProfile :one
+SELECT organization_id, vehicle_type, speed, service_time
+FROM vehicle_profiles
+WHERE organization_id = $1 AND vehicle_type = $2
+`
+
+type GetVehicleProfileParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	VehicleType    string `json:"vehicle_type"`
+}
+
+func (q *Queries) GetVehicleProfile(ctx context.Context, arg GetVehicleProfileParams) (VehicleProfile, error) {
+	row := q.db.QueryRow(ctx, getVehicleProfile, arg.OrganizationID, arg.VehicleType)
+	var i VehicleProfile
+	err := row.Scan(
+		&i.OrganizationID,
+		&i.VehicleType,
+		&i.Speed,
+		&i.ServiceTime,
+	)
+	return i, err
+}
+
+const listVehicleProfiles = `-- name: ListVehicleProfiles :many
+SELECT organization_id, vehicle_type, speed, service_time
+FROM vehicle_profiles
+WHERE organization_id = $1
+ORDER BY vehicle_type
+`
+
+func (q *Queries) ListVehicleProfiles(ctx context.Context, organizationID int64) ([]VehicleProfile, error) {
+	rows, err := q.db.Query(ctx, listVehicleProfiles, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VehicleProfile
+	for rows.Next() {
+		var i VehicleProfile
+		if err := rows.Scan(
+			&i.OrganizationID,
+			&i.VehicleType,
+			&i.Speed,
+			&i.ServiceTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateRideStopETA = `-- name: UpdateRideStopETA :exec
+UPDATE rides_to_orders
+SET eta = $1
+WHERE ride_id = $2 AND order_id = $3
+`
+
+type UpdateRideStopETAParams struct {
+	Eta     pgtype.Timestamp `json:"eta"`
+	RideID  int64            `json:"ride_id"`
+	OrderID int64            `json:"order_id"`
+}
+
+func (q *Queries) UpdateRideStopETA(ctx context.Context, arg UpdateRideStopETAParams) error {
+	_, err := q.db.Exec(ctx, updateRideStopETA, arg.Eta, arg.RideID, arg.OrderID)
+	return err
+}
+
+const upsertVehicleProfile = `-- name: UpsertVehicleProfile :one
+INSERT INTO vehicle_profiles (organization_id, vehicle_type, speed, service_time)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (organization_id, vehicle_type) DO UPDATE
+    SET speed        = excluded.speed,
+        service_time = excluded.service_time
+RETURNING organization_id, vehicle_type, speed, service_time
+`
+
+type UpsertVehicleProfileParams struct {
+	OrganizationID int64   `json:"organization_id"`
+	VehicleType    string  `json:"vehicle_type"`
+	Speed          float64 `json:"speed"`
+	ServiceTime    int32   `json:"service_time"`
+}
+
+func (q *Queries) UpsertVehicleProfile(ctx context.Context, arg UpsertVehicleProfileParams) (VehicleProfile, error) {
+	row := q.db.QueryRow(ctx, upsertVehicleProfile,
+		arg.OrganizationID,
+		arg.VehicleType,
+		arg.Speed,
+		arg.ServiceTime,
+	)
+	var i VehicleProfile
+	err := row.Scan(
+		&i.OrganizationID,
+		&i.VehicleType,
+		&i.Speed,
+		&i.ServiceTime,
+	)
+	return i, err
+}
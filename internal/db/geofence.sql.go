@@ -0,0 +1,132 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: geofence.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createRideGeofenceEvent = `-- name: CreateRideGeofenceEvent :one
+INSERT INTO ride_geofence_events (ride_id, kind, order_id, event, location, occurred_at)
+VALUES ($1, $2, $3, $4, point($5::float8, $6::float8), $7)
+RETURNING id, ride_id, kind, order_id, event, location, occurred_at
+`
+
+type CreateRideGeofenceEventParams struct {
+	RideID     int64            `json:"ride_id"`
+	Kind       string           `json:"kind"`
+	OrderID    *int64           `json:"order_id"`
+	Event      string           `json:"event"`
+	Lng        float64          `json:"lng"`
+	Lat        float64          `json:"lat"`
+	OccurredAt pgtype.Timestamp `json:"occurred_at"`
+}
+
+func (q *Queries) CreateRideGeofenceEvent(ctx context.Context, arg CreateRideGeofenceEventParams) (RideGeofenceEvent, error) {
+	row := q.db.QueryRow(ctx, createRideGeofenceEvent,
+		arg.RideID,
+		arg.Kind,
+		arg.OrderID,
+		arg.Event,
+		arg.Lng,
+		arg.Lat,
+		arg.OccurredAt,
+	)
+	var i RideGeofenceEvent
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.Kind,
+		&i.OrderID,
+		&i.Event,
+		&i.Location,
+		&i.OccurredAt,
+	)
+	return i, err
+}
+
+const getGeofenceSettings = `-- name: GetGeofenceSettings :one
+SELECT id, stop_radius, branch_radius
+FROM organizations
+WHERE id = $1
+`
+
+type GetGeofenceSettingsRow struct {
+	ID           int64 `json:"id"`
+	StopRadius   int32 `json:"stop_radius"`
+	BranchRadius int32 `json:"branch_radius"`
+}
+
+func (q *Queries) GetGeofenceSettings(ctx context.Context, id int64) (GetGeofenceSettingsRow, error) {
+	row := q.db.QueryRow(ctx, getGeofenceSettings, id)
+	var i GetGeofenceSettingsRow
+	err := row.Scan(&i.ID, &i.StopRadius, &i.BranchRadius)
+	return i, err
+}
+
+const listRideGeofenceEvents = `-- name: ListRideGeofenceEvents :many
+SELECT id, ride_id, kind, order_id, event, location, occurred_at
+FROM ride_geofence_events
+WHERE ride_id = $1
+ORDER BY occurred_at, id
+`
+
+func (q *Queries) ListRideGeofenceEvents(ctx context.Context, rideID int64) ([]RideGeofenceEvent, error) {
+	rows, err := q.db.Query(ctx, listRideGeofenceEvents, rideID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RideGeofenceEvent
+	for rows.Next() {
+		var i RideGeofenceEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.RideID,
+			&i.Kind,
+			&i.OrderID,
+			&i.Event,
+			&i.Location,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateGeofenceSettings = `-- name: UpdateGeofenceSettings :one
+UPDATE organizations
+SET stop_radius   = $1,
+    branch_radius = $2
+WHERE id = $3
+RETURNING id, stop_radius, branch_radius
+`
+
+type UpdateGeofenceSettingsParams struct {
+	StopRadius   int32 `json:"stop_radius"`
+	BranchRadius int32 `json:"branch_radius"`
+	ID           int64 `json:"id"`
+}
+
+type UpdateGeofenceSettingsRow struct {
+	ID           int64 `json:"id"`
+	StopRadius   int32 `json:"stop_radius"`
+	BranchRadius int32 `json:"branch_radius"`
+}
+
+func (q *Queries) UpdateGeofenceSettings(ctx context.Context, arg UpdateGeofenceSettingsParams) (UpdateGeofenceSettingsRow, error) {
+	row := q.db.QueryRow(ctx, updateGeofenceSettings, arg.StopRadius, arg.BranchRadius, arg.ID)
+	var i UpdateGeofenceSettingsRow
+	err := row.Scan(&i.ID, &i.StopRadius, &i.BranchRadius)
+	return i, err
+}
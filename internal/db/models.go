@@ -15,30 +15,191 @@ type Branch struct {
 	OrganizationID int64        `json:"organization_id"`
 }
 
+type Customer struct {
+	ID             int64            `json:"id"`
+	OrganizationID int64            `json:"organization_id"`
+	Phone          string           `json:"phone"`
+	Name           string           `json:"name"`
+	Notes          string           `json:"notes"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+}
+
+type CustomerAddress struct {
+	ID         int64            `json:"id"`
+	CustomerID int64            `json:"customer_id"`
+	City       string           `json:"city"`
+	Street     string           `json:"street"`
+	Building   string           `json:"building"`
+	Apartment  string           `json:"apartment"`
+	Entrance   *int32           `json:"entrance"`
+	Floor      *int32           `json:"floor"`
+	Doorphone  *string          `json:"doorphone"`
+	Location   pgtype.Point     `json:"location"`
+	UseCount   int32            `json:"use_count"`
+	LastUsedAt pgtype.Timestamp `json:"last_used_at"`
+}
+
+type CustomerFlag struct {
+	ID         int64            `json:"id"`
+	CustomerID int64            `json:"customer_id"`
+	Flag       string           `json:"flag"`
+	Comment    string           `json:"comment"`
+	CreatedBy  *int64           `json:"created_by"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type DeliveryAttachment struct {
+	ID             int64            `json:"id"`
+	ConfirmationID int64            `json:"confirmation_id"`
+	Kind           string           `json:"kind"`
+	StorageKey     string           `json:"storage_key"`
+	ContentType    string           `json:"content_type"`
+	Size           int64            `json:"size"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+}
+
+type DeliveryConfirmation struct {
+	ID            int64            `json:"id"`
+	OrderID       int64            `json:"order_id"`
+	RideID        *int64           `json:"ride_id"`
+	ConfirmedBy   *int64           `json:"confirmed_by"`
+	DeliveredAt   pgtype.Timestamp `json:"delivered_at"`
+	Location      pgtype.Point     `json:"location"`
+	Accuracy      *float64         `json:"accuracy"`
+	RecipientName string           `json:"recipient_name"`
+	Comment       string           `json:"comment"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type DeliveryZone struct {
+	ID       int64          `json:"id"`
+	BranchID int64          `json:"branch_id"`
+	Name     string         `json:"name"`
+	Area     pgtype.Polygon `json:"area"`
+}
+
+type Driver struct {
+	ID             int64            `json:"id"`
+	OrganizationID int64            `json:"organization_id"`
+	UserID         int64            `json:"user_id"`
+	Phone          string           `json:"phone"`
+	VehicleType    string           `json:"vehicle_type"`
+	HomeBranchID   *int64           `json:"home_branch_id"`
+	Status         string           `json:"status"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+}
+
+type DriverPosition struct {
+	ID         int64            `json:"id"`
+	DriverID   int64            `json:"driver_id"`
+	RideID     *int64           `json:"ride_id"`
+	Location   pgtype.Point     `json:"location"`
+	Accuracy   *float64         `json:"accuracy"`
+	Speed      *float64         `json:"speed"`
+	RecordedAt pgtype.Timestamp `json:"recorded_at"`
+	ReceivedAt pgtype.Timestamp `json:"received_at"`
+}
+
+type EarningAdjustment struct {
+	ID         int64            `json:"id"`
+	DriverID   int64            `json:"driver_id"`
+	Kind       string           `json:"kind"`
+	Amount     pgtype.Numeric   `json:"amount"`
+	RideID     *int64           `json:"ride_id"`
+	OrderID    *int64           `json:"order_id"`
+	Comment    string           `json:"comment"`
+	OccurredAt pgtype.Timestamp `json:"occurred_at"`
+	CreatedBy  *int64           `json:"created_by"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
 type Order struct {
+	ID                 int64            `json:"id"`
+	CustomerName       string           `json:"customer_name"`
+	Phone              *string          `json:"phone"`
+	City               *string          `json:"city"`
+	Street             *string          `json:"street"`
+	Apartment          *string          `json:"apartment"`
+	Floor              *int32           `json:"floor"`
+	Doorphone          *string          `json:"doorphone"`
+	Building           *string          `json:"building"`
+	Entrance           *int32           `json:"entrance"`
+	Comment            *string          `json:"comment"`
+	Cost               pgtype.Numeric   `json:"cost"`
+	Status             string           `json:"status"`
+	Location           pgtype.Point     `json:"location"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	ExternalID         string           `json:"external_id"`
+	BranchID           *int64           `json:"branch_id"`
+	OutOfZone          bool             `json:"out_of_zone"`
+	OrganizationID     int64            `json:"organization_id"`
+	IikoOrganizationID *string          `json:"iiko_organization_id"`
+	GuestCount         *int32           `json:"guest_count"`
+	CourierName        *string          `json:"courier_name"`
+	CourierPhone       *string          `json:"courier_phone"`
+	CashToCollect      pgtype.Numeric   `json:"cash_to_collect"`
+	PromisedAt         pgtype.Timestamp `json:"promised_at"`
+	Lateness           string           `json:"lateness"`
+	Source             string           `json:"source"`
+	IikoStatus         *string          `json:"iiko_status"`
+	IikoDeliveryStatus *string          `json:"iiko_delivery_status"`
+	LocationSource     string           `json:"location_source"`
+	CustomerID         *int64           `json:"customer_id"`
+}
+
+type OrderEvent struct {
 	ID           int64            `json:"id"`
-	CustomerName string           `json:"customer_name"`
-	Phone        *string          `json:"phone"`
-	City         *string          `json:"city"`
-	Street       *string          `json:"street"`
-	Apartment    *string          `json:"apartment"`
-	Floor        *int32           `json:"floor"`
-	Doorphone    *string          `json:"doorphone"`
-	Building     *string          `json:"building"`
-	Entrance     *int32           `json:"entrance"`
-	Comment      *string          `json:"comment"`
-	Cost         pgtype.Numeric   `json:"cost"`
-	Status       *string          `json:"status"`
-	Location     pgtype.Point     `json:"location"`
+	OrderID      int64            `json:"order_id"`
+	OldStatus    *string          `json:"old_status"`
+	NewStatus    string           `json:"new_status"`
+	Source       string           `json:"source"`
+	IikoRevision *int64           `json:"iiko_revision"`
+	OccurredAt   pgtype.Timestamp `json:"occurred_at"`
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
-	ExternalID   string           `json:"external_id"`
+	UserID       *int64           `json:"user_id"`
+}
+
+type OrderItem struct {
+	ID        int64          `json:"id"`
+	OrderID   int64          `json:"order_id"`
+	Position  int32          `json:"position"`
+	ProductID *string        `json:"product_id"`
+	Name      string         `json:"name"`
+	Amount    pgtype.Numeric `json:"amount"`
+	Price     pgtype.Numeric `json:"price"`
+	Cost      pgtype.Numeric `json:"cost"`
+	Comment   *string        `json:"comment"`
+}
+
+type OrderPayment struct {
+	ID              int64          `json:"id"`
+	OrderID         int64          `json:"order_id"`
+	Kind            string         `json:"kind"`
+	PaymentTypeName *string        `json:"payment_type_name"`
+	Sum             pgtype.Numeric `json:"sum"`
+}
+
+type OrderTrackingLink struct {
+	ID        int64            `json:"id"`
+	OrderID   int64            `json:"order_id"`
+	Token     string           `json:"token"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
 }
 
 type Organization struct {
-	ID           int64          `json:"id"`
-	Name         string         `json:"name"`
-	Balance      pgtype.Numeric `json:"balance"`
-	IikoApiToken string         `json:"iiko_api_token"`
+	ID                    int64          `json:"id"`
+	Name                  string         `json:"name"`
+	Balance               pgtype.Numeric `json:"balance"`
+	IikoApiToken          string         `json:"iiko_api_token"`
+	Timezone              string         `json:"timezone"`
+	AutoDispatch          bool           `json:"auto_dispatch"`
+	RideCapacity          int32          `json:"ride_capacity"`
+	DispatchRadius        int32          `json:"dispatch_radius"`
+	DispatchWindowMinutes int32          `json:"dispatch_window_minutes"`
+	StopRadius            int32          `json:"stop_radius"`
+	BranchRadius          int32          `json:"branch_radius"`
 }
 
 type OrganizationPlan struct {
@@ -49,6 +210,17 @@ type OrganizationPlan struct {
 	EndDate        pgtype.Date `json:"end_date"`
 }
 
+type PayRule struct {
+	ID             int64            `json:"id"`
+	OrganizationID int64            `json:"organization_id"`
+	BranchID       *int64           `json:"branch_id"`
+	PerOrder       pgtype.Numeric   `json:"per_order"`
+	PerKm          pgtype.Numeric   `json:"per_km"`
+	PerHour        pgtype.Numeric   `json:"per_hour"`
+	MinimumPerRide pgtype.Numeric   `json:"minimum_per_ride"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+}
+
 type Payment struct {
 	ID             int64            `json:"id"`
 	OrganizationID int64            `json:"organization_id"`
@@ -70,22 +242,91 @@ type PlanFeature struct {
 	FeatureName string `json:"feature_name"`
 }
 
+type ProductSize struct {
+	OrganizationID int64  `json:"organization_id"`
+	ProductID      string `json:"product_id"`
+	Weight         int32  `json:"weight"`
+	Volume         int32  `json:"volume"`
+}
+
 type Revision struct {
 	RevisionID     int64  `json:"revision_id"`
 	OrganizationID *int64 `json:"organization_id"`
 }
 
 type Ride struct {
-	ID        int64            `json:"id"`
-	BranchID  int64            `json:"branch_id"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	EndedAt   pgtype.Timestamp `json:"ended_at"`
+	ID         int64            `json:"id"`
+	BranchID   int64            `json:"branch_id"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	EndedAt    pgtype.Timestamp `json:"ended_at"`
+	DriverID   *int64           `json:"driver_id"`
+	Status     string           `json:"status"`
+	DepartedAt pgtype.Timestamp `json:"departed_at"`
+	StartedAt  pgtype.Timestamp `json:"started_at"`
+	Version    int32            `json:"version"`
+}
+
+type RideCashReport struct {
+	ID            int64            `json:"id"`
+	RideID        int64            `json:"ride_id"`
+	Expected      pgtype.Numeric   `json:"expected"`
+	Collected     pgtype.Numeric   `json:"collected"`
+	ReportedBy    *int64           `json:"reported_by"`
+	ReportedAt    pgtype.Timestamp `json:"reported_at"`
+	DriverComment string           `json:"driver_comment"`
+	Received      pgtype.Numeric   `json:"received"`
+	ConfirmedBy   *int64           `json:"confirmed_by"`
+	ConfirmedAt   pgtype.Timestamp `json:"confirmed_at"`
+	BranchComment string           `json:"branch_comment"`
+}
+
+type RideEarning struct {
+	RideID       int64            `json:"ride_id"`
+	DriverID     int64            `json:"driver_id"`
+	PayRuleID    *int64           `json:"pay_rule_id"`
+	Orders       int32            `json:"orders"`
+	Distance     float64          `json:"distance"`
+	Duration     int32            `json:"duration"`
+	OrderPay     pgtype.Numeric   `json:"order_pay"`
+	DistancePay  pgtype.Numeric   `json:"distance_pay"`
+	TimePay      pgtype.Numeric   `json:"time_pay"`
+	GuaranteePay pgtype.Numeric   `json:"guarantee_pay"`
+	Total        pgtype.Numeric   `json:"total"`
+	CalculatedAt pgtype.Timestamp `json:"calculated_at"`
+}
+
+type RideGeofenceEvent struct {
+	ID         int64            `json:"id"`
+	RideID     int64            `json:"ride_id"`
+	Kind       string           `json:"kind"`
+	OrderID    *int64           `json:"order_id"`
+	Event      string           `json:"event"`
+	Location   pgtype.Point     `json:"location"`
+	OccurredAt pgtype.Timestamp `json:"occurred_at"`
+}
+
+type RideOrderDetachment struct {
+	ID         int64            `json:"id"`
+	RideID     int64            `json:"ride_id"`
+	OrderID    int64            `json:"order_id"`
+	Reason     string           `json:"reason"`
+	Source     string           `json:"source"`
+	DetachedAt pgtype.Timestamp `json:"detached_at"`
 }
 
 type RidesToOrder struct {
-	ID      int64 `json:"id"`
-	RideID  int64 `json:"ride_id"`
-	OrderID int64 `json:"order_id"`
+	ID             int64            `json:"id"`
+	RideID         int64            `json:"ride_id"`
+	OrderID        int64            `json:"order_id"`
+	Sequence       *int32           `json:"sequence"`
+	LegDistance    *float64         `json:"leg_distance"`
+	PlannedArrival pgtype.Timestamp `json:"planned_arrival"`
+	StopStatus     string           `json:"stop_status"`
+	ArrivedAt      pgtype.Timestamp `json:"arrived_at"`
+	DeliveredAt    pgtype.Timestamp `json:"delivered_at"`
+	FailedAt       pgtype.Timestamp `json:"failed_at"`
+	FailureReason  string           `json:"failure_reason"`
+	Eta            pgtype.Timestamp `json:"eta"`
 }
 
 type Role struct {
@@ -120,3 +361,19 @@ type UserRole struct {
 	RoleID int64 `json:"role_id"`
 	UserID int64 `json:"user_id"`
 }
+
+type VehicleLimit struct {
+	OrganizationID int64  `json:"organization_id"`
+	VehicleType    string `json:"vehicle_type"`
+	MaxOrders      *int32 `json:"max_orders"`
+	MaxWeight      *int32 `json:"max_weight"`
+	MaxVolume      *int32 `json:"max_volume"`
+	MaxDistance    *int32 `json:"max_distance"`
+}
+
+type VehicleProfile struct {
+	OrganizationID int64   `json:"organization_id"`
+	VehicleType    string  `json:"vehicle_type"`
+	Speed          float64 `json:"speed"`
+	ServiceTime    int32   `json:"service_time"`
+}
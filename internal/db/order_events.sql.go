@@ -0,0 +1,113 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: order_events.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createOrderEvent = `-- name: CreateOrderEvent :one
+INSERT INTO order_events (
+    order_id,
+    old_status,
+    new_status,
+    source,
+    iiko_revision,
+    occurred_at,
+    user_id
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7
+         )
+RETURNING id, order_id, old_status, new_status, source, iiko_revision, occurred_at, created_at, user_id
+`
+
+type CreateOrderEventParams struct {
+	OrderID      int64            `json:"order_id"`
+	OldStatus    *string          `json:"old_status"`
+	NewStatus    string           `json:"new_status"`
+	Source       string           `json:"source"`
+	IikoRevision *int64           `json:"iiko_revision"`
+	OccurredAt   pgtype.Timestamp `json:"occurred_at"`
+	UserID       *int64           `json:"user_id"`
+}
+
+func (q *Queries) CreateOrderEvent(ctx context.Context, arg CreateOrderEventParams) (OrderEvent, error) {
+	row := q.db.QueryRow(ctx, createOrderEvent,
+		arg.OrderID,
+		arg.OldStatus,
+		arg.NewStatus,
+		arg.Source,
+		arg.IikoRevision,
+		arg.OccurredAt,
+		arg.UserID,
+	)
+	var i OrderEvent
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.OldStatus,
+		&i.NewStatus,
+		&i.Source,
+		&i.IikoRevision,
+		&i.OccurredAt,
+		&i.CreatedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const getStatusBeforeAssignment = `-- name: GetStatusBeforeAssignment :one
+SELECT old_status
+FROM order_events
+WHERE order_id = $1 AND new_status = 'assigned'
+ORDER BY occurred_at DESC, id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetStatusBeforeAssignment(ctx context.Context, orderID int64) (*string, error) {
+	row := q.db.QueryRow(ctx, getStatusBeforeAssignment, orderID)
+	var old_status *string
+	err := row.Scan(&old_status)
+	return old_status, err
+}
+
+const listOrderEvents = `-- name: ListOrderEvents :many
+SELECT id, order_id, old_status, new_status, source, iiko_revision, occurred_at, created_at, user_id FROM order_events
+WHERE order_id = $1
+ORDER BY occurred_at, id
+`
+
+func (q *Queries) ListOrderEvents(ctx context.Context, orderID int64) ([]OrderEvent, error) {
+	rows, err := q.db.Query(ctx, listOrderEvents, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderEvent
+	for rows.Next() {
+		var i OrderEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.OldStatus,
+			&i.NewStatus,
+			&i.Source,
+			&i.IikoRevision,
+			&i.OccurredAt,
+			&i.CreatedAt,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
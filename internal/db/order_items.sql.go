@@ -0,0 +1,88 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: order_items.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createOrderItem = `-- name: CreateOrderItem :exec
+INSERT INTO order_items (order_id, position, product_id, name, amount, price, cost, comment)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateOrderItemParams struct {
+	OrderID   int64          `json:"order_id"`
+	Position  int32          `json:"position"`
+	ProductID *string        `json:"product_id"`
+	Name      string         `json:"name"`
+	Amount    pgtype.Numeric `json:"amount"`
+	Price     pgtype.Numeric `json:"price"`
+	Cost      pgtype.Numeric `json:"cost"`
+	Comment   *string        `json:"comment"`
+}
+
+func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) error {
+	_, err := q.db.Exec(ctx, createOrderItem,
+		arg.OrderID,
+		arg.Position,
+		arg.ProductID,
+		arg.Name,
+		arg.Amount,
+		arg.Price,
+		arg.Cost,
+		arg.Comment,
+	)
+	return err
+}
+
+const deleteOrderItems = `-- name: DeleteOrderItems :exec
+DELETE FROM order_items
+WHERE order_id = $1
+`
+
+func (q *Queries) DeleteOrderItems(ctx context.Context, orderID int64) error {
+	_, err := q.db.Exec(ctx, deleteOrderItems, orderID)
+	return err
+}
+
+const listOrderItems = `-- name: ListOrderItems :many
+SELECT id, order_id, position, product_id, name, amount, price, cost, comment FROM order_items
+WHERE order_id = $1
+ORDER BY position
+`
+
+func (q *Queries) ListOrderItems(ctx context.Context, orderID int64) ([]OrderItem, error) {
+	rows, err := q.db.Query(ctx, listOrderItems, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderItem
+	for rows.Next() {
+		var i OrderItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.Position,
+			&i.ProductID,
+			&i.Name,
+			&i.Amount,
+			&i.Price,
+			&i.Cost,
+			&i.Comment,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
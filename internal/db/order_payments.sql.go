@@ -0,0 +1,76 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: order_payments.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createOrderPayment = `-- name: CreateOrderPayment :exec
+INSERT INTO order_payments (order_id, kind, payment_type_name, sum)
+    VALUES ($1, $2, $3, $4)
+`
+
+type CreateOrderPaymentParams struct {
+	OrderID         int64          `json:"order_id"`
+	Kind            string         `json:"kind"`
+	PaymentTypeName *string        `json:"payment_type_name"`
+	Sum             pgtype.Numeric `json:"sum"`
+}
+
+func (q *Queries) CreateOrderPayment(ctx context.Context, arg CreateOrderPaymentParams) error {
+	_, err := q.db.Exec(ctx, createOrderPayment,
+		arg.OrderID,
+		arg.Kind,
+		arg.PaymentTypeName,
+		arg.Sum,
+	)
+	return err
+}
+
+const deleteOrderPayments = `-- name: DeleteOrderPayments :exec
+DELETE FROM order_payments
+WHERE order_id = $1
+`
+
+func (q *Queries) DeleteOrderPayments(ctx context.Context, orderID int64) error {
+	_, err := q.db.Exec(ctx, deleteOrderPayments, orderID)
+	return err
+}
+
+const listOrderPayments = `-- name: ListOrderPayments :many
+SELECT id, order_id, kind, payment_type_name, sum FROM order_payments
+WHERE order_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListOrderPayments(ctx context.Context, orderID int64) ([]OrderPayment, error) {
+	rows, err := q.db.Query(ctx, listOrderPayments, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderPayment
+	for rows.Next() {
+		var i OrderPayment
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.Kind,
+			&i.PaymentTypeName,
+			&i.Sum,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
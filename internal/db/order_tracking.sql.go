@@ -0,0 +1,130 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: order_tracking.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createOrderTrackingLink = `-- name: CreateOrderTrackingLink :one
+INSERT INTO order_tracking_links (order_id, token)
+VALUES ($1, $2)
+ON CONFLICT (order_id) DO UPDATE SET order_id = excluded.order_id
+RETURNING id, order_id, token, created_at
+`
+
+type CreateOrderTrackingLinkParams struct {
+	OrderID int64  `json:"order_id"`
+	Token   string `json:"token"`
+}
+
+// Returns the existing link of the order, so the token stays stable
+func (q *Queries) CreateOrderTrackingLink(ctx context.Context, arg CreateOrderTrackingLinkParams) (OrderTrackingLink, error) {
+	row := q.db.QueryRow(ctx, createOrderTrackingLink, arg.OrderID, arg.Token)
+	var i OrderTrackingLink
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Token,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getOrderByTrackingToken = `-- name: GetOrderByTrackingToken :one
+SELECT o.id, o.customer_name, o.phone, o.city, o.street, o.apartment, o.floor, o.doorphone, o.building, o.entrance, o.comment, o.cost, o.status, o.location, o.created_at, o.external_id, o.branch_id, o.out_of_zone, o.organization_id, o.iiko_organization_id, o.guest_count, o.courier_name, o.courier_phone, o.cash_to_collect, o.promised_at, o.lateness, o.source, o.iiko_status, o.iiko_delivery_status, o.location_source, o.customer_id
+FROM order_tracking_links otl
+         JOIN orders o ON o.id = otl.order_id
+WHERE otl.token = $1
+`
+
+type GetOrderByTrackingTokenRow struct {
+	Order Order `json:"order"`
+}
+
+func (q *Queries) GetOrderByTrackingToken(ctx context.Context, token string) (GetOrderByTrackingTokenRow, error) {
+	row := q.db.QueryRow(ctx, getOrderByTrackingToken, token)
+	var i GetOrderByTrackingTokenRow
+	err := row.Scan(
+		&i.Order.ID,
+		&i.Order.CustomerName,
+		&i.Order.Phone,
+		&i.Order.City,
+		&i.Order.Street,
+		&i.Order.Apartment,
+		&i.Order.Floor,
+		&i.Order.Doorphone,
+		&i.Order.Building,
+		&i.Order.Entrance,
+		&i.Order.Comment,
+		&i.Order.Cost,
+		&i.Order.Status,
+		&i.Order.Location,
+		&i.Order.CreatedAt,
+		&i.Order.ExternalID,
+		&i.Order.BranchID,
+		&i.Order.OutOfZone,
+		&i.Order.OrganizationID,
+		&i.Order.IikoOrganizationID,
+		&i.Order.GuestCount,
+		&i.Order.CourierName,
+		&i.Order.CourierPhone,
+		&i.Order.CashToCollect,
+		&i.Order.PromisedAt,
+		&i.Order.Lateness,
+		&i.Order.Source,
+		&i.Order.IikoStatus,
+		&i.Order.IikoDeliveryStatus,
+		&i.Order.LocationSource,
+		&i.Order.CustomerID,
+	)
+	return i, err
+}
+
+const getOrderTrackingStop = `-- name: GetOrderTrackingStop :one
+SELECT rto.ride_id,
+       rto.stop_status,
+       rto.planned_arrival,
+       rto.eta,
+       r.status AS ride_status,
+       r.driver_id,
+       u.name   AS driver_name
+FROM rides_to_orders rto
+         JOIN rides r ON r.id = rto.ride_id
+         LEFT JOIN drivers d ON d.id = r.driver_id
+         LEFT JOIN users u ON u.id = d.user_id
+WHERE rto.order_id = $1
+ORDER BY rto.id DESC
+LIMIT 1
+`
+
+type GetOrderTrackingStopRow struct {
+	RideID         int64            `json:"ride_id"`
+	StopStatus     string           `json:"stop_status"`
+	PlannedArrival pgtype.Timestamp `json:"planned_arrival"`
+	Eta            pgtype.Timestamp `json:"eta"`
+	RideStatus     string           `json:"ride_status"`
+	DriverID       *int64           `json:"driver_id"`
+	DriverName     *string          `json:"driver_name"`
+}
+
+// The latest stop of the order together with the ride and its driver
+func (q *Queries) GetOrderTrackingStop(ctx context.Context, orderID int64) (GetOrderTrackingStopRow, error) {
+	row := q.db.QueryRow(ctx, getOrderTrackingStop, orderID)
+	var i GetOrderTrackingStopRow
+	err := row.Scan(
+		&i.RideID,
+		&i.StopStatus,
+		&i.PlannedArrival,
+		&i.Eta,
+		&i.RideStatus,
+		&i.DriverID,
+		&i.DriverName,
+	)
+	return i, err
+}
@@ -13,11 +13,16 @@ import (
 
 const countOrdersByStatus = `-- name: CountOrdersByStatus :one
 SELECT COUNT(*) FROM orders
-WHERE status = $1
+WHERE organization_id = $1 AND status = $2
 `
 
-func (q *Queries) CountOrdersByStatus(ctx context.Context, status *string) (int64, error) {
-	row := q.db.QueryRow(ctx, countOrdersByStatus, status)
+type CountOrdersByStatusParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	Status         string `json:"status"`
+}
+
+func (q *Queries) CountOrdersByStatus(ctx context.Context, arg CountOrdersByStatusParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countOrdersByStatus, arg.OrganizationID, arg.Status)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -28,19 +33,26 @@ SELECT COUNT(*)
 FROM orders o
          LEFT JOIN rides_to_orders rto ON rto.order_id = o.id
 WHERE rto.ride_id IS NULL
-  AND (o.status = $1)
-  AND (o.created_at >= $2)
-  AND (o.created_at <= $3)
+  AND o.organization_id = $1
+  AND ($2::text IS NULL OR o.status = $2)
+  AND ($3::timestamp IS NULL OR o.created_at >= $3)
+  AND ($4::timestamp IS NULL OR o.created_at <= $4)
 `
 
 type CountUnboundOrdersParams struct {
-	Status      *string          `json:"status"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	CreatedAt_2 pgtype.Timestamp `json:"created_at_2"`
+	OrganizationID int64            `json:"organization_id"`
+	Status         *string          `json:"status"`
+	FromDate       pgtype.Timestamp `json:"from_date"`
+	ToDate         pgtype.Timestamp `json:"to_date"`
 }
 
 func (q *Queries) CountUnboundOrders(ctx context.Context, arg CountUnboundOrdersParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countUnboundOrders, arg.Status, arg.CreatedAt, arg.CreatedAt_2)
+	row := q.db.QueryRow(ctx, countUnboundOrders,
+		arg.OrganizationID,
+		arg.Status,
+		arg.FromDate,
+		arg.ToDate,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -62,30 +74,59 @@ INSERT INTO orders (
     status,
     location,
     created_at,
-    external_id
+    external_id,
+    branch_id,
+    out_of_zone,
+    organization_id,
+    iiko_organization_id,
+    guest_count,
+    courier_name,
+    courier_phone,
+    cash_to_collect,
+    promised_at,
+    source,
+    iiko_status,
+    iiko_delivery_status,
+    location_source,
+    customer_id
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, point($13, $14), $15, $16
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, point($13, $14), $15, $16, $17, $18, $19, $20,
+             $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
          )
-RETURNING id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id
+RETURNING id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id, branch_id, out_of_zone, organization_id, iiko_organization_id, guest_count, courier_name, courier_phone, cash_to_collect, promised_at, lateness, source, iiko_status, iiko_delivery_status, location_source, customer_id
 `
 
 type CreateOrderParams struct {
-	CustomerName string           `json:"customer_name"`
-	Phone        *string          `json:"phone"`
-	City         *string          `json:"city"`
-	Street       *string          `json:"street"`
-	Apartment    *string          `json:"apartment"`
-	Floor        *int32           `json:"floor"`
-	Doorphone    *string          `json:"doorphone"`
-	Building     *string          `json:"building"`
-	Entrance     *int32           `json:"entrance"`
-	Comment      *string          `json:"comment"`
-	Cost         pgtype.Numeric   `json:"cost"`
-	Status       *string          `json:"status"`
-	Point        float64          `json:"point"`
-	Point_2      float64          `json:"point_2"`
-	CreatedAt    pgtype.Timestamp `json:"created_at"`
-	ExternalID   string           `json:"external_id"`
+	CustomerName       string           `json:"customer_name"`
+	Phone              *string          `json:"phone"`
+	City               *string          `json:"city"`
+	Street             *string          `json:"street"`
+	Apartment          *string          `json:"apartment"`
+	Floor              *int32           `json:"floor"`
+	Doorphone          *string          `json:"doorphone"`
+	Building           *string          `json:"building"`
+	Entrance           *int32           `json:"entrance"`
+	Comment            *string          `json:"comment"`
+	Cost               pgtype.Numeric   `json:"cost"`
+	Status             string           `json:"status"`
+	Point              float64          `json:"point"`
+	Point_2            float64          `json:"point_2"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	ExternalID         string           `json:"external_id"`
+	BranchID           *int64           `json:"branch_id"`
+	OutOfZone          bool             `json:"out_of_zone"`
+	OrganizationID     int64            `json:"organization_id"`
+	IikoOrganizationID *string          `json:"iiko_organization_id"`
+	GuestCount         *int32           `json:"guest_count"`
+	CourierName        *string          `json:"courier_name"`
+	CourierPhone       *string          `json:"courier_phone"`
+	CashToCollect      pgtype.Numeric   `json:"cash_to_collect"`
+	PromisedAt         pgtype.Timestamp `json:"promised_at"`
+	Source             string           `json:"source"`
+	IikoStatus         *string          `json:"iiko_status"`
+	IikoDeliveryStatus *string          `json:"iiko_delivery_status"`
+	LocationSource     string           `json:"location_source"`
+	CustomerID         *int64           `json:"customer_id"`
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
@@ -106,6 +147,20 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		arg.Point_2,
 		arg.CreatedAt,
 		arg.ExternalID,
+		arg.BranchID,
+		arg.OutOfZone,
+		arg.OrganizationID,
+		arg.IikoOrganizationID,
+		arg.GuestCount,
+		arg.CourierName,
+		arg.CourierPhone,
+		arg.CashToCollect,
+		arg.PromisedAt,
+		arg.Source,
+		arg.IikoStatus,
+		arg.IikoDeliveryStatus,
+		arg.LocationSource,
+		arg.CustomerID,
 	)
 	var i Order
 	err := row.Scan(
@@ -125,17 +180,168 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.Location,
 		&i.CreatedAt,
 		&i.ExternalID,
+		&i.BranchID,
+		&i.OutOfZone,
+		&i.OrganizationID,
+		&i.IikoOrganizationID,
+		&i.GuestCount,
+		&i.CourierName,
+		&i.CourierPhone,
+		&i.CashToCollect,
+		&i.PromisedAt,
+		&i.Lateness,
+		&i.Source,
+		&i.IikoStatus,
+		&i.IikoDeliveryStatus,
+		&i.LocationSource,
+		&i.CustomerID,
 	)
 	return i, err
 }
 
+const filterOrders = `-- name: FilterOrders :many
+SELECT o.id, o.customer_name, o.phone, o.city, o.street, o.apartment, o.floor, o.doorphone, o.building, o.entrance, o.comment, o.cost, o.status, o.location, o.created_at, o.external_id, o.branch_id, o.out_of_zone, o.organization_id, o.iiko_organization_id, o.guest_count, o.courier_name, o.courier_phone, o.cash_to_collect, o.promised_at, o.lateness, o.source, o.iiko_status, o.iiko_delivery_status, o.location_source, o.customer_id, rto.ride_id, CASE WHEN rto.stop_status IN ('pending', 'arrived') THEN rto.eta END::timestamp AS eta
+FROM orders o
+         LEFT JOIN rides_to_orders rto ON rto.id = (SELECT max(id)
+                                                    FROM rides_to_orders
+                                                    WHERE order_id = o.id)
+WHERE o.organization_id = $1
+  AND ($2::text[] IS NULL OR o.status = ANY ($2::text[]))
+  AND ($3::timestamp IS NULL OR o.created_at >= $3)
+  AND ($4::timestamp IS NULL OR o.created_at <= $4)
+  AND ($5::bigint IS NULL OR o.branch_id = $5)
+  AND ($6::text IS NULL OR o.phone ILIKE '%' || $6 || '%')
+  AND ($7::float8 IS NULL OR o.cost >= $7)
+  AND ($8::float8 IS NULL OR o.cost <= $8)
+  AND ($9::boolean IS NULL OR (rto.ride_id IS NOT NULL) = $9)
+  AND ($10::bigint IS NULL OR rto.ride_id = $10)
+  AND ($11::text IS NULL OR o.source = $11)
+  AND ($12::bigint IS NULL OR CASE $13::text
+           WHEN 'created_at' THEN (o.created_at, o.id) > ($14::timestamp, $12)
+           WHEN 'cost' THEN (o.cost, o.id) > ($15::numeric, $12)
+           WHEN '-cost' THEN (o.cost, o.id) < ($15, $12)
+           ELSE (o.created_at, o.id) < ($14, $12)
+      END)
+ORDER BY CASE WHEN $13 = 'created_at' THEN o.created_at END,
+         CASE WHEN $13 = '-created_at' THEN o.created_at END DESC,
+         CASE WHEN $13 = 'cost' THEN o.cost END,
+         CASE WHEN $13 = '-cost' THEN o.cost END DESC,
+         CASE WHEN $13 IN ('created_at', 'cost') THEN o.id END,
+         o.id DESC
+LIMIT $16
+`
+
+type FilterOrdersParams struct {
+	OrganizationID  int64            `json:"organization_id"`
+	Statuses        []string         `json:"statuses"`
+	FromDate        pgtype.Timestamp `json:"from_date"`
+	ToDate          pgtype.Timestamp `json:"to_date"`
+	BranchID        *int64           `json:"branch_id"`
+	Phone           *string          `json:"phone"`
+	MinCost         *float64         `json:"min_cost"`
+	MaxCost         *float64         `json:"max_cost"`
+	Bound           *bool            `json:"bound"`
+	RideID          *int64           `json:"ride_id"`
+	Source          *string          `json:"source"`
+	CursorID        *int64           `json:"cursor_id"`
+	Sort            string           `json:"sort"`
+	CursorCreatedAt pgtype.Timestamp `json:"cursor_created_at"`
+	CursorCost      pgtype.Numeric   `json:"cursor_cost"`
+	RowLimit        int32            `json:"row_limit"`
+}
+
+type FilterOrdersRow struct {
+	Order  Order            `json:"order"`
+	RideID *int64           `json:"ride_id"`
+	Eta    pgtype.Timestamp `json:"eta"`
+}
+
+// Keyset pagination: the cursor holds the sort key and id of the last row
+// of the previous page. Sort is one of created_at, -created_at, cost, -cost.
+// Only the latest stop of an order counts, like GetOrderRideID.
+func (q *Queries) FilterOrders(ctx context.Context, arg FilterOrdersParams) ([]FilterOrdersRow, error) {
+	rows, err := q.db.Query(ctx, filterOrders,
+		arg.OrganizationID,
+		arg.Statuses,
+		arg.FromDate,
+		arg.ToDate,
+		arg.BranchID,
+		arg.Phone,
+		arg.MinCost,
+		arg.MaxCost,
+		arg.Bound,
+		arg.RideID,
+		arg.Source,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorCreatedAt,
+		arg.CursorCost,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FilterOrdersRow
+	for rows.Next() {
+		var i FilterOrdersRow
+		if err := rows.Scan(
+			&i.Order.ID,
+			&i.Order.CustomerName,
+			&i.Order.Phone,
+			&i.Order.City,
+			&i.Order.Street,
+			&i.Order.Apartment,
+			&i.Order.Floor,
+			&i.Order.Doorphone,
+			&i.Order.Building,
+			&i.Order.Entrance,
+			&i.Order.Comment,
+			&i.Order.Cost,
+			&i.Order.Status,
+			&i.Order.Location,
+			&i.Order.CreatedAt,
+			&i.Order.ExternalID,
+			&i.Order.BranchID,
+			&i.Order.OutOfZone,
+			&i.Order.OrganizationID,
+			&i.Order.IikoOrganizationID,
+			&i.Order.GuestCount,
+			&i.Order.CourierName,
+			&i.Order.CourierPhone,
+			&i.Order.CashToCollect,
+			&i.Order.PromisedAt,
+			&i.Order.Lateness,
+			&i.Order.Source,
+			&i.Order.IikoStatus,
+			&i.Order.IikoDeliveryStatus,
+			&i.Order.LocationSource,
+			&i.Order.CustomerID,
+			&i.RideID,
+			&i.Eta,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getOrder = `-- name: GetOrder :one
-SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id FROM orders
-WHERE id = $1
+SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id, branch_id, out_of_zone, organization_id, iiko_organization_id, guest_count, courier_name, courier_phone, cash_to_collect, promised_at, lateness, source, iiko_status, iiko_delivery_status, location_source, customer_id FROM orders
+WHERE id = $1 AND organization_id = $2
 `
 
-func (q *Queries) GetOrder(ctx context.Context, id int64) (Order, error) {
-	row := q.db.QueryRow(ctx, getOrder, id)
+type GetOrderParams struct {
+	ID             int64 `json:"id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) GetOrder(ctx context.Context, arg GetOrderParams) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrder, arg.ID, arg.OrganizationID)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -154,17 +360,37 @@ func (q *Queries) GetOrder(ctx context.Context, id int64) (Order, error) {
 		&i.Location,
 		&i.CreatedAt,
 		&i.ExternalID,
+		&i.BranchID,
+		&i.OutOfZone,
+		&i.OrganizationID,
+		&i.IikoOrganizationID,
+		&i.GuestCount,
+		&i.CourierName,
+		&i.CourierPhone,
+		&i.CashToCollect,
+		&i.PromisedAt,
+		&i.Lateness,
+		&i.Source,
+		&i.IikoStatus,
+		&i.IikoDeliveryStatus,
+		&i.LocationSource,
+		&i.CustomerID,
 	)
 	return i, err
 }
 
 const getOrderByExternalID = `-- name: GetOrderByExternalID :one
-SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id FROM orders
-WHERE external_id = $1
+SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id, branch_id, out_of_zone, organization_id, iiko_organization_id, guest_count, courier_name, courier_phone, cash_to_collect, promised_at, lateness, source, iiko_status, iiko_delivery_status, location_source, customer_id FROM orders
+WHERE organization_id = $1 AND external_id = $2
 `
 
-func (q *Queries) GetOrderByExternalID(ctx context.Context, externalID string) (Order, error) {
-	row := q.db.QueryRow(ctx, getOrderByExternalID, externalID)
+type GetOrderByExternalIDParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	ExternalID     string `json:"external_id"`
+}
+
+func (q *Queries) GetOrderByExternalID(ctx context.Context, arg GetOrderByExternalIDParams) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrderByExternalID, arg.OrganizationID, arg.ExternalID)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -183,26 +409,57 @@ func (q *Queries) GetOrderByExternalID(ctx context.Context, externalID string) (
 		&i.Location,
 		&i.CreatedAt,
 		&i.ExternalID,
+		&i.BranchID,
+		&i.OutOfZone,
+		&i.OrganizationID,
+		&i.IikoOrganizationID,
+		&i.GuestCount,
+		&i.CourierName,
+		&i.CourierPhone,
+		&i.CashToCollect,
+		&i.PromisedAt,
+		&i.Lateness,
+		&i.Source,
+		&i.IikoStatus,
+		&i.IikoDeliveryStatus,
+		&i.LocationSource,
+		&i.CustomerID,
 	)
 	return i, err
 }
 
+const getOrderRideID = `-- name: GetOrderRideID :one
+SELECT ride_id
+FROM rides_to_orders
+WHERE order_id = $1
+ORDER BY id DESC
+LIMIT 1
+`
+
+// The latest ride of the order, finished stops of earlier rides are kept
+func (q *Queries) GetOrderRideID(ctx context.Context, orderID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, getOrderRideID, orderID)
+	var ride_id int64
+	err := row.Scan(&ride_id)
+	return ride_id, err
+}
+
 const getOrderStatuses = `-- name: GetOrderStatuses :many
 SELECT DISTINCT status
 FROM orders
-WHERE status IS NOT NULL
+WHERE organization_id = $1 AND status IS NOT NULL
 ORDER BY status
 `
 
-func (q *Queries) GetOrderStatuses(ctx context.Context) ([]*string, error) {
-	rows, err := q.db.Query(ctx, getOrderStatuses)
+func (q *Queries) GetOrderStatuses(ctx context.Context, organizationID int64) ([]string, error) {
+	rows, err := q.db.Query(ctx, getOrderStatuses, organizationID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []*string
+	var items []string
 	for rows.Next() {
-		var status *string
+		var status string
 		if err := rows.Scan(&status); err != nil {
 			return nil, err
 		}
@@ -215,13 +472,18 @@ func (q *Queries) GetOrderStatuses(ctx context.Context) ([]*string, error) {
 }
 
 const getOrdersByStatus = `-- name: GetOrdersByStatus :many
-SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id FROM orders
-WHERE status = $1
+SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id, branch_id, out_of_zone, organization_id, iiko_organization_id, guest_count, courier_name, courier_phone, cash_to_collect, promised_at, lateness, source, iiko_status, iiko_delivery_status, location_source, customer_id FROM orders
+WHERE organization_id = $1 AND status = $2
 ORDER BY created_at DESC
 `
 
-func (q *Queries) GetOrdersByStatus(ctx context.Context, status *string) ([]Order, error) {
-	rows, err := q.db.Query(ctx, getOrdersByStatus, status)
+type GetOrdersByStatusParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	Status         string `json:"status"`
+}
+
+func (q *Queries) GetOrdersByStatus(ctx context.Context, arg GetOrdersByStatusParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, getOrdersByStatus, arg.OrganizationID, arg.Status)
 	if err != nil {
 		return nil, err
 	}
@@ -246,6 +508,21 @@ func (q *Queries) GetOrdersByStatus(ctx context.Context, status *string) ([]Orde
 			&i.Location,
 			&i.CreatedAt,
 			&i.ExternalID,
+			&i.BranchID,
+			&i.OutOfZone,
+			&i.OrganizationID,
+			&i.IikoOrganizationID,
+			&i.GuestCount,
+			&i.CourierName,
+			&i.CourierPhone,
+			&i.CashToCollect,
+			&i.PromisedAt,
+			&i.Lateness,
+			&i.Source,
+			&i.IikoStatus,
+			&i.IikoDeliveryStatus,
+			&i.LocationSource,
+			&i.CustomerID,
 		); err != nil {
 			return nil, err
 		}
@@ -258,32 +535,35 @@ func (q *Queries) GetOrdersByStatus(ctx context.Context, status *string) ([]Orde
 }
 
 const getUnboundOrders = `-- name: GetUnboundOrders :many
-SELECT o.id, o.customer_name, o.phone, o.city, o.street, o.apartment, o.floor, o.doorphone, o.building, o.entrance, o.comment, o.cost, o.status, o.location, o.created_at, o.external_id
+SELECT o.id, o.customer_name, o.phone, o.city, o.street, o.apartment, o.floor, o.doorphone, o.building, o.entrance, o.comment, o.cost, o.status, o.location, o.created_at, o.external_id, o.branch_id, o.out_of_zone, o.organization_id, o.iiko_organization_id, o.guest_count, o.courier_name, o.courier_phone, o.cash_to_collect, o.promised_at, o.lateness, o.source, o.iiko_status, o.iiko_delivery_status, o.location_source, o.customer_id
 FROM orders o
          LEFT JOIN rides_to_orders rto ON rto.order_id = o.id
 WHERE rto.ride_id IS NULL
-  AND (o.status = $1)
-  AND (o.created_at >= $2)
-  AND (o.created_at <= $3)
+  AND o.organization_id = $1
+  AND ($2::text IS NULL OR o.status = $2)
+  AND ($3::timestamp IS NULL OR o.created_at >= $3)
+  AND ($4::timestamp IS NULL OR o.created_at <= $4)
 ORDER BY o.created_at DESC
-LIMIT $4 OFFSET $5
+LIMIT $6 OFFSET $5
 `
 
 type GetUnboundOrdersParams struct {
-	Status      *string          `json:"status"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	CreatedAt_2 pgtype.Timestamp `json:"created_at_2"`
-	Limit       int32            `json:"limit"`
-	Offset      int32            `json:"offset"`
+	OrganizationID int64            `json:"organization_id"`
+	Status         *string          `json:"status"`
+	FromDate       pgtype.Timestamp `json:"from_date"`
+	ToDate         pgtype.Timestamp `json:"to_date"`
+	RowOffset      int32            `json:"row_offset"`
+	RowLimit       int32            `json:"row_limit"`
 }
 
 func (q *Queries) GetUnboundOrders(ctx context.Context, arg GetUnboundOrdersParams) ([]Order, error) {
 	rows, err := q.db.Query(ctx, getUnboundOrders,
+		arg.OrganizationID,
 		arg.Status,
-		arg.CreatedAt,
-		arg.CreatedAt_2,
-		arg.Limit,
-		arg.Offset,
+		arg.FromDate,
+		arg.ToDate,
+		arg.RowOffset,
+		arg.RowLimit,
 	)
 	if err != nil {
 		return nil, err
@@ -309,6 +589,81 @@ func (q *Queries) GetUnboundOrders(ctx context.Context, arg GetUnboundOrdersPara
 			&i.Location,
 			&i.CreatedAt,
 			&i.ExternalID,
+			&i.BranchID,
+			&i.OutOfZone,
+			&i.OrganizationID,
+			&i.IikoOrganizationID,
+			&i.GuestCount,
+			&i.CourierName,
+			&i.CourierPhone,
+			&i.CashToCollect,
+			&i.PromisedAt,
+			&i.Lateness,
+			&i.Source,
+			&i.IikoStatus,
+			&i.IikoDeliveryStatus,
+			&i.LocationSource,
+			&i.CustomerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenPromisedOrders = `-- name: ListOpenPromisedOrders :many
+SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id, branch_id, out_of_zone, organization_id, iiko_organization_id, guest_count, courier_name, courier_phone, cash_to_collect, promised_at, lateness, source, iiko_status, iiko_delivery_status, location_source, customer_id FROM orders
+WHERE promised_at IS NOT NULL
+  AND lateness <> 'late'
+  AND status NOT IN ('delivered', 'cancelled', 'failed')
+ORDER BY promised_at
+`
+
+func (q *Queries) ListOpenPromisedOrders(ctx context.Context) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOpenPromisedOrders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerName,
+			&i.Phone,
+			&i.City,
+			&i.Street,
+			&i.Apartment,
+			&i.Floor,
+			&i.Doorphone,
+			&i.Building,
+			&i.Entrance,
+			&i.Comment,
+			&i.Cost,
+			&i.Status,
+			&i.Location,
+			&i.CreatedAt,
+			&i.ExternalID,
+			&i.BranchID,
+			&i.OutOfZone,
+			&i.OrganizationID,
+			&i.IikoOrganizationID,
+			&i.GuestCount,
+			&i.CourierName,
+			&i.CourierPhone,
+			&i.CashToCollect,
+			&i.PromisedAt,
+			&i.Lateness,
+			&i.Source,
+			&i.IikoStatus,
+			&i.IikoDeliveryStatus,
+			&i.LocationSource,
+			&i.CustomerID,
 		); err != nil {
 			return nil, err
 		}
@@ -321,18 +676,20 @@ func (q *Queries) GetUnboundOrders(ctx context.Context, arg GetUnboundOrdersPara
 }
 
 const listOrders = `-- name: ListOrders :many
-SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id FROM orders
+SELECT id, customer_name, phone, city, street, apartment, floor, doorphone, building, entrance, comment, cost, status, location, created_at, external_id, branch_id, out_of_zone, organization_id, iiko_organization_id, guest_count, courier_name, courier_phone, cash_to_collect, promised_at, lateness, source, iiko_status, iiko_delivery_status, location_source, customer_id FROM orders
+WHERE organization_id = $1
 ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+LIMIT $2 OFFSET $3
 `
 
 type ListOrdersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	OrganizationID int64 `json:"organization_id"`
+	Limit          int32 `json:"limit"`
+	Offset         int32 `json:"offset"`
 }
 
 func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error) {
-	rows, err := q.db.Query(ctx, listOrders, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listOrders, arg.OrganizationID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -357,6 +714,21 @@ func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order
 			&i.Location,
 			&i.CreatedAt,
 			&i.ExternalID,
+			&i.BranchID,
+			&i.OutOfZone,
+			&i.OrganizationID,
+			&i.IikoOrganizationID,
+			&i.GuestCount,
+			&i.CourierName,
+			&i.CourierPhone,
+			&i.CashToCollect,
+			&i.PromisedAt,
+			&i.Lateness,
+			&i.Source,
+			&i.IikoStatus,
+			&i.IikoDeliveryStatus,
+			&i.LocationSource,
+			&i.CustomerID,
 		); err != nil {
 			return nil, err
 		}
@@ -382,25 +754,49 @@ SET
     entrance = $10,
     comment = $11,
     cost = $12,
-    location = point($13, $14)
+    location = point($13, $14),
+    branch_id = $15,
+    out_of_zone = $16,
+    guest_count = $17,
+    courier_name = $18,
+    courier_phone = $19,
+    cash_to_collect = $20,
+    -- A new promise gives the order a fresh chance to be on time
+    lateness = CASE WHEN promised_at IS DISTINCT FROM $21 THEN 'on_time' ELSE lateness END,
+    promised_at = $21,
+    iiko_status = $22,
+    iiko_delivery_status = $23,
+    location_source = $24,
+    customer_id = $25
 WHERE id = $1
 `
 
 type UpdateOrderParams struct {
-	ID           int64          `json:"id"`
-	CustomerName string         `json:"customer_name"`
-	Phone        *string        `json:"phone"`
-	City         *string        `json:"city"`
-	Street       *string        `json:"street"`
-	Apartment    *string        `json:"apartment"`
-	Floor        *int32         `json:"floor"`
-	Doorphone    *string        `json:"doorphone"`
-	Building     *string        `json:"building"`
-	Entrance     *int32         `json:"entrance"`
-	Comment      *string        `json:"comment"`
-	Cost         pgtype.Numeric `json:"cost"`
-	Point        float64        `json:"point"`
-	Point_2      float64        `json:"point_2"`
+	ID                 int64            `json:"id"`
+	CustomerName       string           `json:"customer_name"`
+	Phone              *string          `json:"phone"`
+	City               *string          `json:"city"`
+	Street             *string          `json:"street"`
+	Apartment          *string          `json:"apartment"`
+	Floor              *int32           `json:"floor"`
+	Doorphone          *string          `json:"doorphone"`
+	Building           *string          `json:"building"`
+	Entrance           *int32           `json:"entrance"`
+	Comment            *string          `json:"comment"`
+	Cost               pgtype.Numeric   `json:"cost"`
+	Point              float64          `json:"point"`
+	Point_2            float64          `json:"point_2"`
+	BranchID           *int64           `json:"branch_id"`
+	OutOfZone          bool             `json:"out_of_zone"`
+	GuestCount         *int32           `json:"guest_count"`
+	CourierName        *string          `json:"courier_name"`
+	CourierPhone       *string          `json:"courier_phone"`
+	CashToCollect      pgtype.Numeric   `json:"cash_to_collect"`
+	PromisedAt         pgtype.Timestamp `json:"promised_at"`
+	IikoStatus         *string          `json:"iiko_status"`
+	IikoDeliveryStatus *string          `json:"iiko_delivery_status"`
+	LocationSource     string           `json:"location_source"`
+	CustomerID         *int64           `json:"customer_id"`
 }
 
 func (q *Queries) UpdateOrder(ctx context.Context, arg UpdateOrderParams) error {
@@ -419,10 +815,37 @@ func (q *Queries) UpdateOrder(ctx context.Context, arg UpdateOrderParams) error
 		arg.Cost,
 		arg.Point,
 		arg.Point_2,
+		arg.BranchID,
+		arg.OutOfZone,
+		arg.GuestCount,
+		arg.CourierName,
+		arg.CourierPhone,
+		arg.CashToCollect,
+		arg.PromisedAt,
+		arg.IikoStatus,
+		arg.IikoDeliveryStatus,
+		arg.LocationSource,
+		arg.CustomerID,
 	)
 	return err
 }
 
+const updateOrderLateness = `-- name: UpdateOrderLateness :exec
+UPDATE orders
+SET lateness = $2
+WHERE id = $1
+`
+
+type UpdateOrderLatenessParams struct {
+	ID       int64  `json:"id"`
+	Lateness string `json:"lateness"`
+}
+
+func (q *Queries) UpdateOrderLateness(ctx context.Context, arg UpdateOrderLatenessParams) error {
+	_, err := q.db.Exec(ctx, updateOrderLateness, arg.ID, arg.Lateness)
+	return err
+}
+
 const updateOrderStatus = `-- name: UpdateOrderStatus :exec
 UPDATE orders
 SET status = $2
@@ -430,8 +853,8 @@ WHERE id = $1
 `
 
 type UpdateOrderStatusParams struct {
-	ID     int64   `json:"id"`
-	Status *string `json:"status"`
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
 }
 
 func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) error {
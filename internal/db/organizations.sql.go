@@ -27,7 +27,7 @@ func (q *Queries) CheckOrganizationExists(ctx context.Context, id int64) (bool,
 
 const createOrganization = `-- name: CreateOrganization :one
 INSERT INTO organizations (name, iiko_api_token)
-    VALUES ($1, $2) RETURNING id, name, balance, iiko_api_token
+    VALUES ($1, $2) RETURNING id, name, balance, iiko_api_token, timezone, auto_dispatch, ride_capacity, dispatch_radius, dispatch_window_minutes, stop_radius, branch_radius
 `
 
 type CreateOrganizationParams struct {
@@ -43,6 +43,13 @@ func (q *Queries) CreateOrganization(ctx context.Context, arg CreateOrganization
 		&i.Name,
 		&i.Balance,
 		&i.IikoApiToken,
+		&i.Timezone,
+		&i.AutoDispatch,
+		&i.RideCapacity,
+		&i.DispatchRadius,
+		&i.DispatchWindowMinutes,
+		&i.StopRadius,
+		&i.BranchRadius,
 	)
 	return i, err
 }
@@ -78,7 +85,7 @@ func (q *Queries) DeleteOrganizationUsers(ctx context.Context, organizationID in
 }
 
 const getOrganization = `-- name: GetOrganization :one
-SELECT id, name, balance, iiko_api_token FROM organizations WHERE id = $1
+SELECT id, name, balance, iiko_api_token, timezone, auto_dispatch, ride_capacity, dispatch_radius, dispatch_window_minutes, stop_radius, branch_radius FROM organizations WHERE id = $1
 `
 
 func (q *Queries) GetOrganization(ctx context.Context, id int64) (Organization, error) {
@@ -89,6 +96,13 @@ func (q *Queries) GetOrganization(ctx context.Context, id int64) (Organization,
 		&i.Name,
 		&i.Balance,
 		&i.IikoApiToken,
+		&i.Timezone,
+		&i.AutoDispatch,
+		&i.RideCapacity,
+		&i.DispatchRadius,
+		&i.DispatchWindowMinutes,
+		&i.StopRadius,
+		&i.BranchRadius,
 	)
 	return i, err
 }
@@ -118,7 +132,7 @@ func (q *Queries) GetOrganizationsApiTokens(ctx context.Context) ([]string, erro
 }
 
 const listOrganizations = `-- name: ListOrganizations :many
-SELECT id, name, balance, iiko_api_token FROM organizations
+SELECT id, name, balance, iiko_api_token, timezone, auto_dispatch, ride_capacity, dispatch_radius, dispatch_window_minutes, stop_radius, branch_radius FROM organizations
 `
 
 func (q *Queries) ListOrganizations(ctx context.Context) ([]Organization, error) {
@@ -135,6 +149,13 @@ func (q *Queries) ListOrganizations(ctx context.Context) ([]Organization, error)
 			&i.Name,
 			&i.Balance,
 			&i.IikoApiToken,
+			&i.Timezone,
+			&i.AutoDispatch,
+			&i.RideCapacity,
+			&i.DispatchRadius,
+			&i.DispatchWindowMinutes,
+			&i.StopRadius,
+			&i.BranchRadius,
 		); err != nil {
 			return nil, err
 		}
@@ -150,7 +171,7 @@ const updateOrganization = `-- name: UpdateOrganization :one
 UPDATE organizations
 SET name = $2, balance = $3, iiko_api_token = $4
 WHERE id = $1
-RETURNING id, name, balance, iiko_api_token
+RETURNING id, name, balance, iiko_api_token, timezone, auto_dispatch, ride_capacity, dispatch_radius, dispatch_window_minutes, stop_radius, branch_radius
 `
 
 type UpdateOrganizationParams struct {
@@ -173,6 +194,13 @@ func (q *Queries) UpdateOrganization(ctx context.Context, arg UpdateOrganization
 		&i.Name,
 		&i.Balance,
 		&i.IikoApiToken,
+		&i.Timezone,
+		&i.AutoDispatch,
+		&i.RideCapacity,
+		&i.DispatchRadius,
+		&i.DispatchWindowMinutes,
+		&i.StopRadius,
+		&i.BranchRadius,
 	)
 	return i, err
 }
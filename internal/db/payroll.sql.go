@@ -0,0 +1,585 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: payroll.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countDeliveredRideStops = `-- name: CountDeliveredRideStops :one
+SELECT COUNT(*)
+FROM rides_to_orders
+WHERE ride_id = $1 AND stop_status = 'delivered'
+`
+
+func (q *Queries) CountDeliveredRideStops(ctx context.Context, rideID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeliveredRideStops, rideID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createEarningAdjustment = `-- name: CreateEarningAdjustment :one
+INSERT INTO earning_adjustments (driver_id, kind, amount, ride_id, order_id, comment, occurred_at, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7,
+        $8)
+RETURNING id, driver_id, kind, amount, ride_id, order_id, comment, occurred_at, created_by, created_at
+`
+
+type CreateEarningAdjustmentParams struct {
+	DriverID   int64            `json:"driver_id"`
+	Kind       string           `json:"kind"`
+	Amount     pgtype.Numeric   `json:"amount"`
+	RideID     *int64           `json:"ride_id"`
+	OrderID    *int64           `json:"order_id"`
+	Comment    string           `json:"comment"`
+	OccurredAt pgtype.Timestamp `json:"occurred_at"`
+	CreatedBy  *int64           `json:"created_by"`
+}
+
+func (q *Queries) CreateEarningAdjustment(ctx context.Context, arg CreateEarningAdjustmentParams) (EarningAdjustment, error) {
+	row := q.db.QueryRow(ctx, createEarningAdjustment,
+		arg.DriverID,
+		arg.Kind,
+		arg.Amount,
+		arg.RideID,
+		arg.OrderID,
+		arg.Comment,
+		arg.OccurredAt,
+		arg.CreatedBy,
+	)
+	var i EarningAdjustment
+	err := row.Scan(
+		&i.ID,
+		&i.DriverID,
+		&i.Kind,
+		&i.Amount,
+		&i.RideID,
+		&i.OrderID,
+		&i.Comment,
+		&i.OccurredAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteEarningAdjustment = `-- name: DeleteEarningAdjustment :execrows
+DELETE FROM earning_adjustments ea
+    USING drivers d
+WHERE ea.id = $1
+  AND ea.driver_id = $2
+  AND d.id = ea.driver_id
+  AND d.organization_id = $3
+`
+
+type DeleteEarningAdjustmentParams struct {
+	ID             int64 `json:"id"`
+	DriverID       int64 `json:"driver_id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) DeleteEarningAdjustment(ctx context.Context, arg DeleteEarningAdjustmentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteEarningAdjustment, arg.ID, arg.DriverID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePayRule = `-- name: DeletePayRule :execrows
+DELETE FROM pay_rules
+WHERE id = $1 AND organization_id = $2
+`
+
+type DeletePayRuleParams struct {
+	ID             int64 `json:"id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) DeletePayRule(ctx context.Context, arg DeletePayRuleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePayRule, arg.ID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getBranchPayRule = `-- name: GetBranchPayRule :one
+SELECT id, organization_id, branch_id, per_order, per_km, per_hour, minimum_per_ride, updated_at
+FROM pay_rules
+WHERE organization_id = $1
+  AND (branch_id = $2 OR branch_id IS NULL)
+ORDER BY branch_id NULLS LAST
+LIMIT 1
+`
+
+type GetBranchPayRuleParams struct {
+	OrganizationID int64  `json:"organization_id"`
+	BranchID       *int64 `json:"branch_id"`
+}
+
+// The rule of the branch, or the organization rule when the branch has none
+func (q *Queries) GetBranchPayRule(ctx context.Context, arg GetBranchPayRuleParams) (PayRule, error) {
+	row := q.db.QueryRow(ctx, getBranchPayRule, arg.OrganizationID, arg.BranchID)
+	var i PayRule
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.BranchID,
+		&i.PerOrder,
+		&i.PerKm,
+		&i.PerHour,
+		&i.MinimumPerRide,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRideEarnings = `-- name: GetRideEarnings :one
+SELECT ride_id, driver_id, pay_rule_id, orders, distance, duration, order_pay, distance_pay, time_pay, guarantee_pay, total, calculated_at
+FROM ride_earnings
+WHERE ride_id = $1
+`
+
+func (q *Queries) GetRideEarnings(ctx context.Context, rideID int64) (RideEarning, error) {
+	row := q.db.QueryRow(ctx, getRideEarnings, rideID)
+	var i RideEarning
+	err := row.Scan(
+		&i.RideID,
+		&i.DriverID,
+		&i.PayRuleID,
+		&i.Orders,
+		&i.Distance,
+		&i.Duration,
+		&i.OrderPay,
+		&i.DistancePay,
+		&i.TimePay,
+		&i.GuaranteePay,
+		&i.Total,
+		&i.CalculatedAt,
+	)
+	return i, err
+}
+
+const listDriverEarningAdjustments = `-- name: ListDriverEarningAdjustments :many
+SELECT ea.id, ea.driver_id, ea.kind, ea.amount, ea.ride_id, ea.order_id, ea.comment, ea.occurred_at, ea.created_by, ea.created_at
+FROM earning_adjustments ea
+         JOIN drivers d ON d.id = ea.driver_id
+         JOIN organizations org ON org.id = d.organization_id
+WHERE ea.driver_id = $1
+  AND d.organization_id = $2
+  AND (ea.occurred_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date BETWEEN $3::date AND $4::date
+ORDER BY ea.occurred_at, ea.id
+`
+
+type ListDriverEarningAdjustmentsParams struct {
+	DriverID       int64       `json:"driver_id"`
+	OrganizationID int64       `json:"organization_id"`
+	DateFrom       pgtype.Date `json:"date_from"`
+	DateTo         pgtype.Date `json:"date_to"`
+}
+
+func (q *Queries) ListDriverEarningAdjustments(ctx context.Context, arg ListDriverEarningAdjustmentsParams) ([]EarningAdjustment, error) {
+	rows, err := q.db.Query(ctx, listDriverEarningAdjustments,
+		arg.DriverID,
+		arg.OrganizationID,
+		arg.DateFrom,
+		arg.DateTo,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EarningAdjustment
+	for rows.Next() {
+		var i EarningAdjustment
+		if err := rows.Scan(
+			&i.ID,
+			&i.DriverID,
+			&i.Kind,
+			&i.Amount,
+			&i.RideID,
+			&i.OrderID,
+			&i.Comment,
+			&i.OccurredAt,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPayRules = `-- name: ListPayRules :many
+SELECT id, organization_id, branch_id, per_order, per_km, per_hour, minimum_per_ride, updated_at
+FROM pay_rules
+WHERE organization_id = $1
+ORDER BY branch_id NULLS FIRST
+`
+
+func (q *Queries) ListPayRules(ctx context.Context, organizationID int64) ([]PayRule, error) {
+	rows, err := q.db.Query(ctx, listPayRules, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PayRule
+	for rows.Next() {
+		var i PayRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.BranchID,
+			&i.PerOrder,
+			&i.PerKm,
+			&i.PerHour,
+			&i.MinimumPerRide,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPayrollAdjustments = `-- name: ListPayrollAdjustments :many
+SELECT ea.driver_id,
+       COALESCE(SUM(ea.amount) FILTER (WHERE ea.kind = 'bonus'), 0)::numeric AS bonuses,
+       COALESCE(SUM(ea.amount) FILTER (WHERE ea.kind = 'tip'), 0)::numeric   AS tips
+FROM earning_adjustments ea
+         JOIN drivers d ON d.id = ea.driver_id
+         JOIN organizations org ON org.id = d.organization_id
+         LEFT JOIN rides r ON r.id = ea.ride_id
+WHERE d.organization_id = $1
+  AND (ea.occurred_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date BETWEEN $2::date AND $3::date
+  AND ($4::bigint IS NULL OR ea.driver_id = $4)
+  AND ($5::bigint IS NULL OR COALESCE(r.branch_id, d.home_branch_id) = $5)
+GROUP BY ea.driver_id
+`
+
+type ListPayrollAdjustmentsParams struct {
+	OrganizationID int64       `json:"organization_id"`
+	DateFrom       pgtype.Date `json:"date_from"`
+	DateTo         pgtype.Date `json:"date_to"`
+	DriverID       *int64      `json:"driver_id"`
+	BranchID       *int64      `json:"branch_id"`
+}
+
+type ListPayrollAdjustmentsRow struct {
+	DriverID int64          `json:"driver_id"`
+	Bonuses  pgtype.Numeric `json:"bonuses"`
+	Tips     pgtype.Numeric `json:"tips"`
+}
+
+// Sums bonuses and tips per driver. Adjustments of rides of other branches
+// are left out when filtering by branch.
+func (q *Queries) ListPayrollAdjustments(ctx context.Context, arg ListPayrollAdjustmentsParams) ([]ListPayrollAdjustmentsRow, error) {
+	rows, err := q.db.Query(ctx, listPayrollAdjustments,
+		arg.OrganizationID,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.DriverID,
+		arg.BranchID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPayrollAdjustmentsRow
+	for rows.Next() {
+		var i ListPayrollAdjustmentsRow
+		if err := rows.Scan(&i.DriverID, &i.Bonuses, &i.Tips); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPayrollDrivers = `-- name: ListPayrollDrivers :many
+SELECT d.id,
+       COALESCE(u.name, '')::text    AS name,
+       COALESCE(u.surname, '')::text AS surname
+FROM drivers d
+         JOIN users u ON u.id = d.user_id
+WHERE d.organization_id = $1
+  AND d.id = ANY ($2::bigint[])
+`
+
+type ListPayrollDriversParams struct {
+	OrganizationID int64   `json:"organization_id"`
+	DriverIds      []int64 `json:"driver_ids"`
+}
+
+type ListPayrollDriversRow struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Surname string `json:"surname"`
+}
+
+func (q *Queries) ListPayrollDrivers(ctx context.Context, arg ListPayrollDriversParams) ([]ListPayrollDriversRow, error) {
+	rows, err := q.db.Query(ctx, listPayrollDrivers, arg.OrganizationID, arg.DriverIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPayrollDriversRow
+	for rows.Next() {
+		var i ListPayrollDriversRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Surname); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPayrollRides = `-- name: ListPayrollRides :many
+SELECT re.driver_id,
+       COUNT(*)                                AS ride_count,
+       SUM(re.orders)::bigint                  AS orders,
+       SUM(re.distance)::float8                AS distance,
+       SUM(re.duration)::bigint                AS duration,
+       SUM(re.order_pay)::numeric              AS order_pay,
+       SUM(re.distance_pay)::numeric           AS distance_pay,
+       SUM(re.time_pay)::numeric               AS time_pay,
+       SUM(re.guarantee_pay)::numeric          AS guarantee_pay,
+       SUM(re.total)::numeric                  AS total
+FROM ride_earnings re
+         JOIN rides r ON r.id = re.ride_id
+         JOIN branches b ON b.id = r.branch_id
+         JOIN organizations org ON org.id = b.organization_id
+WHERE b.organization_id = $1
+  AND (r.ended_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date BETWEEN $2::date AND $3::date
+  AND ($4::bigint IS NULL OR re.driver_id = $4)
+  AND ($5::bigint IS NULL OR r.branch_id = $5)
+GROUP BY re.driver_id
+`
+
+type ListPayrollRidesParams struct {
+	OrganizationID int64       `json:"organization_id"`
+	DateFrom       pgtype.Date `json:"date_from"`
+	DateTo         pgtype.Date `json:"date_to"`
+	DriverID       *int64      `json:"driver_id"`
+	BranchID       *int64      `json:"branch_id"`
+}
+
+type ListPayrollRidesRow struct {
+	DriverID     int64          `json:"driver_id"`
+	RideCount    int64          `json:"ride_count"`
+	Orders       int64          `json:"orders"`
+	Distance     float64        `json:"distance"`
+	Duration     int64          `json:"duration"`
+	OrderPay     pgtype.Numeric `json:"order_pay"`
+	DistancePay  pgtype.Numeric `json:"distance_pay"`
+	TimePay      pgtype.Numeric `json:"time_pay"`
+	GuaranteePay pgtype.Numeric `json:"guarantee_pay"`
+	Total        pgtype.Numeric `json:"total"`
+}
+
+// Sums the earnings of rides per driver and local day of the ride return
+func (q *Queries) ListPayrollRides(ctx context.Context, arg ListPayrollRidesParams) ([]ListPayrollRidesRow, error) {
+	rows, err := q.db.Query(ctx, listPayrollRides,
+		arg.OrganizationID,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.DriverID,
+		arg.BranchID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPayrollRidesRow
+	for rows.Next() {
+		var i ListPayrollRidesRow
+		if err := rows.Scan(
+			&i.DriverID,
+			&i.RideCount,
+			&i.Orders,
+			&i.Distance,
+			&i.Duration,
+			&i.OrderPay,
+			&i.DistancePay,
+			&i.TimePay,
+			&i.GuaranteePay,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRideEarningAdjustments = `-- name: ListRideEarningAdjustments :many
+SELECT id, driver_id, kind, amount, ride_id, order_id, comment, occurred_at, created_by, created_at
+FROM earning_adjustments
+WHERE ride_id = $1
+ORDER BY occurred_at, id
+`
+
+func (q *Queries) ListRideEarningAdjustments(ctx context.Context, rideID *int64) ([]EarningAdjustment, error) {
+	rows, err := q.db.Query(ctx, listRideEarningAdjustments, rideID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EarningAdjustment
+	for rows.Next() {
+		var i EarningAdjustment
+		if err := rows.Scan(
+			&i.ID,
+			&i.DriverID,
+			&i.Kind,
+			&i.Amount,
+			&i.RideID,
+			&i.OrderID,
+			&i.Comment,
+			&i.OccurredAt,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertPayRule = `-- name: UpsertPayRule :one
+INSERT INTO pay_rules (organization_id, branch_id, per_order, per_km, per_hour, minimum_per_ride)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (organization_id, coalesce(branch_id, 0)) DO UPDATE
+    SET per_order        = excluded.per_order,
+        per_km           = excluded.per_km,
+        per_hour         = excluded.per_hour,
+        minimum_per_ride = excluded.minimum_per_ride,
+        updated_at       = CURRENT_TIMESTAMP
+RETURNING id, organization_id, branch_id, per_order, per_km, per_hour, minimum_per_ride, updated_at
+`
+
+type UpsertPayRuleParams struct {
+	OrganizationID int64          `json:"organization_id"`
+	BranchID       *int64         `json:"branch_id"`
+	PerOrder       pgtype.Numeric `json:"per_order"`
+	PerKm          pgtype.Numeric `json:"per_km"`
+	PerHour        pgtype.Numeric `json:"per_hour"`
+	MinimumPerRide pgtype.Numeric `json:"minimum_per_ride"`
+}
+
+func (q *Queries) UpsertPayRule(ctx context.Context, arg UpsertPayRuleParams) (PayRule, error) {
+	row := q.db.QueryRow(ctx, upsertPayRule,
+		arg.OrganizationID,
+		arg.BranchID,
+		arg.PerOrder,
+		arg.PerKm,
+		arg.PerHour,
+		arg.MinimumPerRide,
+	)
+	var i PayRule
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.BranchID,
+		&i.PerOrder,
+		&i.PerKm,
+		&i.PerHour,
+		&i.MinimumPerRide,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertRideEarnings = `-- name: UpsertRideEarnings :one
+INSERT INTO ride_earnings (ride_id, driver_id, pay_rule_id, orders, distance, duration,
+                           order_pay, distance_pay, time_pay, guarantee_pay, total)
+VALUES ($1, $2, $3, $4, $5, $6,
+        $7, $8, $9, $10, $11)
+ON CONFLICT (ride_id) DO UPDATE
+    SET driver_id     = excluded.driver_id,
+        pay_rule_id   = excluded.pay_rule_id,
+        orders        = excluded.orders,
+        distance      = excluded.distance,
+        duration      = excluded.duration,
+        order_pay     = excluded.order_pay,
+        distance_pay  = excluded.distance_pay,
+        time_pay      = excluded.time_pay,
+        guarantee_pay = excluded.guarantee_pay,
+        total         = excluded.total,
+        calculated_at = CURRENT_TIMESTAMP
+RETURNING ride_id, driver_id, pay_rule_id, orders, distance, duration, order_pay, distance_pay, time_pay, guarantee_pay, total, calculated_at
+`
+
+type UpsertRideEarningsParams struct {
+	RideID       int64          `json:"ride_id"`
+	DriverID     int64          `json:"driver_id"`
+	PayRuleID    *int64         `json:"pay_rule_id"`
+	Orders       int32          `json:"orders"`
+	Distance     float64        `json:"distance"`
+	Duration     int32          `json:"duration"`
+	OrderPay     pgtype.Numeric `json:"order_pay"`
+	DistancePay  pgtype.Numeric `json:"distance_pay"`
+	TimePay      pgtype.Numeric `json:"time_pay"`
+	GuaranteePay pgtype.Numeric `json:"guarantee_pay"`
+	Total        pgtype.Numeric `json:"total"`
+}
+
+func (q *Queries) UpsertRideEarnings(ctx context.Context, arg UpsertRideEarningsParams) (RideEarning, error) {
+	row := q.db.QueryRow(ctx, upsertRideEarnings,
+		arg.RideID,
+		arg.DriverID,
+		arg.PayRuleID,
+		arg.Orders,
+		arg.Distance,
+		arg.Duration,
+		arg.OrderPay,
+		arg.DistancePay,
+		arg.TimePay,
+		arg.GuaranteePay,
+		arg.Total,
+	)
+	var i RideEarning
+	err := row.Scan(
+		&i.RideID,
+		&i.DriverID,
+		&i.PayRuleID,
+		&i.Orders,
+		&i.Distance,
+		&i.Duration,
+		&i.OrderPay,
+		&i.DistancePay,
+		&i.TimePay,
+		&i.GuaranteePay,
+		&i.Total,
+		&i.CalculatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,154 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: ride_order_detachments.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createRideOrderDetachment = `-- name: CreateRideOrderDetachment :one
+INSERT INTO ride_order_detachments (ride_id, order_id, reason, source)
+    VALUES ($1, $2, $3, $4)
+RETURNING id, ride_id, order_id, reason, source, detached_at
+`
+
+type CreateRideOrderDetachmentParams struct {
+	RideID  int64  `json:"ride_id"`
+	OrderID int64  `json:"order_id"`
+	Reason  string `json:"reason"`
+	Source  string `json:"source"`
+}
+
+func (q *Queries) CreateRideOrderDetachment(ctx context.Context, arg CreateRideOrderDetachmentParams) (RideOrderDetachment, error) {
+	row := q.db.QueryRow(ctx, createRideOrderDetachment,
+		arg.RideID,
+		arg.OrderID,
+		arg.Reason,
+		arg.Source,
+	)
+	var i RideOrderDetachment
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.OrderID,
+		&i.Reason,
+		&i.Source,
+		&i.DetachedAt,
+	)
+	return i, err
+}
+
+const detachOrderFromActiveRides = `-- name: DetachOrderFromActiveRides :many
+DELETE FROM rides_to_orders rto
+USING rides r
+WHERE rto.order_id = $1
+  AND r.id = rto.ride_id
+  AND r.ended_at IS NULL
+  AND rto.stop_status IN ('pending', 'arrived')
+RETURNING rto.ride_id
+`
+
+// Stops the driver already finished stay on the ride as its history
+func (q *Queries) DetachOrderFromActiveRides(ctx context.Context, orderID int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, detachOrderFromActiveRides, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var ride_id int64
+		if err := rows.Scan(&ride_id); err != nil {
+			return nil, err
+		}
+		items = append(items, ride_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const finishOrderStopsOnRoad = `-- name: FinishOrderStopsOnRoad :many
+UPDATE rides_to_orders rto
+SET stop_status  = $1,
+    delivered_at = $2,
+    failed_at    = $3
+FROM rides r
+WHERE rto.order_id = $4
+  AND r.id = rto.ride_id
+  AND r.status IN ('departed', 'in_progress')
+  AND rto.stop_status IN ('pending', 'arrived')
+RETURNING rto.ride_id
+`
+
+type FinishOrderStopsOnRoadParams struct {
+	StopStatus  string           `json:"stop_status"`
+	DeliveredAt pgtype.Timestamp `json:"delivered_at"`
+	FailedAt    pgtype.Timestamp `json:"failed_at"`
+	OrderID     int64            `json:"order_id"`
+}
+
+// Orders finished while their ride is on the road stay on it as finished stops
+func (q *Queries) FinishOrderStopsOnRoad(ctx context.Context, arg FinishOrderStopsOnRoadParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, finishOrderStopsOnRoad,
+		arg.StopStatus,
+		arg.DeliveredAt,
+		arg.FailedAt,
+		arg.OrderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var ride_id int64
+		if err := rows.Scan(&ride_id); err != nil {
+			return nil, err
+		}
+		items = append(items, ride_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRideOrderDetachments = `-- name: ListRideOrderDetachments :many
+SELECT id, ride_id, order_id, reason, source, detached_at FROM ride_order_detachments
+WHERE ride_id = $1
+ORDER BY detached_at, id
+`
+
+func (q *Queries) ListRideOrderDetachments(ctx context.Context, rideID int64) ([]RideOrderDetachment, error) {
+	rows, err := q.db.Query(ctx, listRideOrderDetachments, rideID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RideOrderDetachment
+	for rows.Next() {
+		var i RideOrderDetachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.RideID,
+			&i.OrderID,
+			&i.Reason,
+			&i.Source,
+			&i.DetachedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
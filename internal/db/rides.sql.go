@@ -11,102 +11,216 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const attachOrderToRide = `-- name: AttachOrderToRide :exec
+const attachOrderToRide = `-- name: AttachOrderToRide :execrows
 INSERT INTO rides_to_orders (
     ride_id,
     order_id
-) VALUES (
-             $1, $2
-         )
+)
+SELECT r.id, o.id
+FROM rides r
+         JOIN branches b ON b.id = r.branch_id
+         JOIN orders o ON o.organization_id = b.organization_id
+WHERE r.id = $1
+  AND o.id = $2
+  AND b.organization_id = $3
 `
 
 type AttachOrderToRideParams struct {
-	RideID  int64 `json:"ride_id"`
-	OrderID int64 `json:"order_id"`
+	RideID         int64 `json:"ride_id"`
+	OrderID        int64 `json:"order_id"`
+	OrganizationID int64 `json:"organization_id"`
 }
 
-func (q *Queries) AttachOrderToRide(ctx context.Context, arg AttachOrderToRideParams) error {
-	_, err := q.db.Exec(ctx, attachOrderToRide, arg.RideID, arg.OrderID)
-	return err
+func (q *Queries) AttachOrderToRide(ctx context.Context, arg AttachOrderToRideParams) (int64, error) {
+	result, err := q.db.Exec(ctx, attachOrderToRide, arg.RideID, arg.OrderID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const completeRide = `-- name: CompleteRide :exec
+const bumpRideVersion = `-- name: BumpRideVersion :one
 UPDATE rides
-SET ended_at = CURRENT_TIMESTAMP
+SET version = version + 1
 WHERE id = $1
+RETURNING id, branch_id, created_at, ended_at, driver_id, status, departed_at, started_at, version
 `
 
-func (q *Queries) CompleteRide(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, completeRide, id)
-	return err
+// Locks the ride until the end of the transaction, so concurrent changes of
+// a ride are applied one after another
+func (q *Queries) BumpRideVersion(ctx context.Context, id int64) (Ride, error) {
+	row := q.db.QueryRow(ctx, bumpRideVersion, id)
+	var i Ride
+	err := row.Scan(
+		&i.ID,
+		&i.BranchID,
+		&i.CreatedAt,
+		&i.EndedAt,
+		&i.DriverID,
+		&i.Status,
+		&i.DepartedAt,
+		&i.StartedAt,
+		&i.Version,
+	)
+	return i, err
 }
 
 const createRide = `-- name: CreateRide :one
 INSERT INTO rides (
     branch_id,
     created_at
-) VALUES (
-             $1,
-             CURRENT_TIMESTAMP
-         )
-RETURNING id, branch_id, created_at, ended_at
+)
+SELECT b.id,
+       CURRENT_TIMESTAMP
+FROM branches b
+WHERE b.id = $1 AND b.organization_id = $2
+RETURNING id, branch_id, created_at, ended_at, driver_id, status, departed_at, started_at, version
 `
 
-func (q *Queries) CreateRide(ctx context.Context, branchID int64) (Ride, error) {
-	row := q.db.QueryRow(ctx, createRide, branchID)
+type CreateRideParams struct {
+	BranchID       int64 `json:"branch_id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) CreateRide(ctx context.Context, arg CreateRideParams) (Ride, error) {
+	row := q.db.QueryRow(ctx, createRide, arg.BranchID, arg.OrganizationID)
 	var i Ride
 	err := row.Scan(
 		&i.ID,
 		&i.BranchID,
 		&i.CreatedAt,
 		&i.EndedAt,
+		&i.DriverID,
+		&i.Status,
+		&i.DepartedAt,
+		&i.StartedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const detachAllOrdersFromRide = `-- name: DetachAllOrdersFromRide :exec
-DELETE FROM rides_to_orders
-WHERE ride_id = $1
+DELETE FROM rides_to_orders rto
+USING rides r, branches b
+WHERE rto.ride_id = $1
+  AND r.id = rto.ride_id
+  AND b.id = r.branch_id
+  AND b.organization_id = $2
 `
 
-func (q *Queries) DetachAllOrdersFromRide(ctx context.Context, rideID int64) error {
-	_, err := q.db.Exec(ctx, detachAllOrdersFromRide, rideID)
+type DetachAllOrdersFromRideParams struct {
+	RideID         int64 `json:"ride_id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) DetachAllOrdersFromRide(ctx context.Context, arg DetachAllOrdersFromRideParams) error {
+	_, err := q.db.Exec(ctx, detachAllOrdersFromRide, arg.RideID, arg.OrganizationID)
 	return err
 }
 
-const getActiveRides = `-- name: GetActiveRides :many
-SELECT r.id, r.branch_id, r.created_at, r.ended_at,
-       COUNT(rto.order_id) as order_count
+const detachOrderFromRide = `-- name: DetachOrderFromRide :execrows
+DELETE FROM rides_to_orders
+WHERE ride_id = $1 AND order_id = $2
+`
+
+type DetachOrderFromRideParams struct {
+	RideID  int64 `json:"ride_id"`
+	OrderID int64 `json:"order_id"`
+}
+
+func (q *Queries) DetachOrderFromRide(ctx context.Context, arg DetachOrderFromRideParams) (int64, error) {
+	result, err := q.db.Exec(ctx, detachOrderFromRide, arg.RideID, arg.OrderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const filterRides = `-- name: FilterRides :many
+SELECT r.id, r.branch_id, r.created_at, r.ended_at, r.driver_id, r.status, r.departed_at, r.started_at, r.version,
+       COALESCE(u.name, '')::text                                             AS driver_name,
+       COALESCE(u.surname, '')::text                                          AS driver_surname,
+       COUNT(rto.order_id)                                                    AS order_count,
+       COUNT(rto.order_id) FILTER (WHERE rto.stop_status = 'delivered')       AS delivered_count,
+       COUNT(rto.order_id) FILTER (WHERE rto.stop_status = 'failed')          AS failed_count,
+       (SELECT COUNT(*) FROM ride_order_detachments rod WHERE rod.ride_id = r.id) AS detached_count
 FROM rides r
-         LEFT JOIN rides_to_orders rto ON r.id = rto.ride_id
-WHERE r.ended_at IS NULL
-GROUP BY r.id
-ORDER BY r.created_at DESC
+         JOIN branches b ON b.id = r.branch_id
+         LEFT JOIN drivers d ON d.id = r.driver_id
+         LEFT JOIN users u ON u.id = d.user_id
+         LEFT JOIN rides_to_orders rto ON rto.ride_id = r.id
+WHERE b.organization_id = $1
+  AND ($2::bigint IS NULL OR r.branch_id = $2)
+  AND ($3::bigint IS NULL OR r.driver_id = $3)
+  AND ($4::text[] IS NULL OR r.status = ANY ($4::text[]))
+  AND ($5::timestamp IS NULL OR r.created_at >= $5)
+  AND ($6::timestamp IS NULL OR r.created_at <= $6)
+  AND ($7::bigint IS NULL OR
+       (r.created_at, r.id) < ($8::timestamp, $7))
+GROUP BY r.id, u.id
+ORDER BY r.created_at DESC, r.id DESC
+LIMIT $9
 `
 
-type GetActiveRidesRow struct {
-	ID         int64            `json:"id"`
-	BranchID   int64            `json:"branch_id"`
-	CreatedAt  pgtype.Timestamp `json:"created_at"`
-	EndedAt    pgtype.Timestamp `json:"ended_at"`
-	OrderCount int64            `json:"order_count"`
+type FilterRidesParams struct {
+	OrganizationID  int64            `json:"organization_id"`
+	BranchID        *int64           `json:"branch_id"`
+	DriverID        *int64           `json:"driver_id"`
+	Statuses        []string         `json:"statuses"`
+	FromDate        pgtype.Timestamp `json:"from_date"`
+	ToDate          pgtype.Timestamp `json:"to_date"`
+	CursorID        *int64           `json:"cursor_id"`
+	CursorCreatedAt pgtype.Timestamp `json:"cursor_created_at"`
+	RowLimit        int32            `json:"row_limit"`
 }
 
-func (q *Queries) GetActiveRides(ctx context.Context) ([]GetActiveRidesRow, error) {
-	rows, err := q.db.Query(ctx, getActiveRides)
+type FilterRidesRow struct {
+	Ride           Ride   `json:"ride"`
+	DriverName     string `json:"driver_name"`
+	DriverSurname  string `json:"driver_surname"`
+	OrderCount     int64  `json:"order_count"`
+	DeliveredCount int64  `json:"delivered_count"`
+	FailedCount    int64  `json:"failed_count"`
+	DetachedCount  int64  `json:"detached_count"`
+}
+
+// Keyset pagination by creation time, newest first. Stops stay on returned
+// rides, so finished rides report what they delivered.
+func (q *Queries) FilterRides(ctx context.Context, arg FilterRidesParams) ([]FilterRidesRow, error) {
+	rows, err := q.db.Query(ctx, filterRides,
+		arg.OrganizationID,
+		arg.BranchID,
+		arg.DriverID,
+		arg.Statuses,
+		arg.FromDate,
+		arg.ToDate,
+		arg.CursorID,
+		arg.CursorCreatedAt,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetActiveRidesRow
+	var items []FilterRidesRow
 	for rows.Next() {
-		var i GetActiveRidesRow
+		var i FilterRidesRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.BranchID,
-			&i.CreatedAt,
-			&i.EndedAt,
+			&i.Ride.ID,
+			&i.Ride.BranchID,
+			&i.Ride.CreatedAt,
+			&i.Ride.EndedAt,
+			&i.Ride.DriverID,
+			&i.Ride.Status,
+			&i.Ride.DepartedAt,
+			&i.Ride.StartedAt,
+			&i.Ride.Version,
+			&i.DriverName,
+			&i.DriverSurname,
 			&i.OrderCount,
+			&i.DeliveredCount,
+			&i.FailedCount,
+			&i.DetachedCount,
 		); err != nil {
 			return nil, err
 		}
@@ -118,16 +232,34 @@ func (q *Queries) GetActiveRides(ctx context.Context) ([]GetActiveRidesRow, erro
 	return items, nil
 }
 
+const getOrderActiveRideID = `-- name: GetOrderActiveRideID :one
+SELECT ride_id
+FROM rides_to_orders
+WHERE order_id = $1 AND stop_status IN ('pending', 'arrived')
+`
+
+func (q *Queries) GetOrderActiveRideID(ctx context.Context, orderID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, getOrderActiveRideID, orderID)
+	var ride_id int64
+	err := row.Scan(&ride_id)
+	return ride_id, err
+}
+
 const getOrdersByRideID = `-- name: GetOrdersByRideID :many
-SELECT o.id, o.customer_name, o.phone, o.city, o.street, o.apartment, o.floor, o.doorphone, o.building, o.entrance, o.comment, o.cost, o.status, o.location, o.created_at, o.external_id
+SELECT o.id, o.customer_name, o.phone, o.city, o.street, o.apartment, o.floor, o.doorphone, o.building, o.entrance, o.comment, o.cost, o.status, o.location, o.created_at, o.external_id, o.branch_id, o.out_of_zone, o.organization_id, o.iiko_organization_id, o.guest_count, o.courier_name, o.courier_phone, o.cash_to_collect, o.promised_at, o.lateness, o.source, o.iiko_status, o.iiko_delivery_status, o.location_source, o.customer_id
 FROM orders o
          JOIN rides_to_orders rto ON rto.order_id = o.id
-WHERE rto.ride_id = $1
-ORDER BY o.created_at
+WHERE rto.ride_id = $1 AND o.organization_id = $2
+ORDER BY rto.sequence NULLS LAST, o.created_at
 `
 
-func (q *Queries) GetOrdersByRideID(ctx context.Context, rideID int64) ([]Order, error) {
-	rows, err := q.db.Query(ctx, getOrdersByRideID, rideID)
+type GetOrdersByRideIDParams struct {
+	RideID         int64 `json:"ride_id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) GetOrdersByRideID(ctx context.Context, arg GetOrdersByRideIDParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, getOrdersByRideID, arg.RideID, arg.OrganizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -152,6 +284,21 @@ func (q *Queries) GetOrdersByRideID(ctx context.Context, rideID int64) ([]Order,
 			&i.Location,
 			&i.CreatedAt,
 			&i.ExternalID,
+			&i.BranchID,
+			&i.OutOfZone,
+			&i.OrganizationID,
+			&i.IikoOrganizationID,
+			&i.GuestCount,
+			&i.CourierName,
+			&i.CourierPhone,
+			&i.CashToCollect,
+			&i.PromisedAt,
+			&i.Lateness,
+			&i.Source,
+			&i.IikoStatus,
+			&i.IikoDeliveryStatus,
+			&i.LocationSource,
+			&i.CustomerID,
 		); err != nil {
 			return nil, err
 		}
@@ -164,18 +311,307 @@ func (q *Queries) GetOrdersByRideID(ctx context.Context, rideID int64) ([]Order,
 }
 
 const getRide = `-- name: GetRide :one
-SELECT id, branch_id, created_at, ended_at FROM rides
-WHERE id = $1
+SELECT r.id, r.branch_id, r.created_at, r.ended_at, r.driver_id, r.status, r.departed_at, r.started_at, r.version
+FROM rides r
+         JOIN branches b ON b.id = r.branch_id
+WHERE r.id = $1 AND b.organization_id = $2
+`
+
+type GetRideParams struct {
+	ID             int64 `json:"id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+func (q *Queries) GetRide(ctx context.Context, arg GetRideParams) (Ride, error) {
+	row := q.db.QueryRow(ctx, getRide, arg.ID, arg.OrganizationID)
+	var i Ride
+	err := row.Scan(
+		&i.ID,
+		&i.BranchID,
+		&i.CreatedAt,
+		&i.EndedAt,
+		&i.DriverID,
+		&i.Status,
+		&i.DepartedAt,
+		&i.StartedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getRideStop = `-- name: GetRideStop :one
+SELECT id, ride_id, order_id, sequence, leg_distance, planned_arrival, stop_status, arrived_at, delivered_at, failed_at, failure_reason, eta FROM rides_to_orders
+WHERE ride_id = $1 AND order_id = $2
+`
+
+type GetRideStopParams struct {
+	RideID  int64 `json:"ride_id"`
+	OrderID int64 `json:"order_id"`
+}
+
+func (q *Queries) GetRideStop(ctx context.Context, arg GetRideStopParams) (RidesToOrder, error) {
+	row := q.db.QueryRow(ctx, getRideStop, arg.RideID, arg.OrderID)
+	var i RidesToOrder
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.OrderID,
+		&i.Sequence,
+		&i.LegDistance,
+		&i.PlannedArrival,
+		&i.StopStatus,
+		&i.ArrivedAt,
+		&i.DeliveredAt,
+		&i.FailedAt,
+		&i.FailureReason,
+		&i.Eta,
+	)
+	return i, err
+}
+
+const listRideStops = `-- name: ListRideStops :many
+SELECT o.id, o.customer_name, o.phone, o.city, o.street, o.apartment, o.floor, o.doorphone, o.building, o.entrance, o.comment, o.cost, o.status, o.location, o.created_at, o.external_id, o.branch_id, o.out_of_zone, o.organization_id, o.iiko_organization_id, o.guest_count, o.courier_name, o.courier_phone, o.cash_to_collect, o.promised_at, o.lateness, o.source, o.iiko_status, o.iiko_delivery_status, o.location_source, o.customer_id,
+       rto.sequence,
+       rto.leg_distance,
+       rto.planned_arrival,
+       rto.eta,
+       rto.stop_status,
+       rto.arrived_at,
+       rto.delivered_at,
+       rto.failed_at,
+       rto.failure_reason
+FROM orders o
+         JOIN rides_to_orders rto ON rto.order_id = o.id
+WHERE rto.ride_id = $1 AND o.organization_id = $2
+ORDER BY rto.sequence NULLS LAST, o.created_at
+`
+
+type ListRideStopsParams struct {
+	RideID         int64 `json:"ride_id"`
+	OrganizationID int64 `json:"organization_id"`
+}
+
+type ListRideStopsRow struct {
+	Order          Order            `json:"order"`
+	Sequence       *int32           `json:"sequence"`
+	LegDistance    *float64         `json:"leg_distance"`
+	PlannedArrival pgtype.Timestamp `json:"planned_arrival"`
+	Eta            pgtype.Timestamp `json:"eta"`
+	StopStatus     string           `json:"stop_status"`
+	ArrivedAt      pgtype.Timestamp `json:"arrived_at"`
+	DeliveredAt    pgtype.Timestamp `json:"delivered_at"`
+	FailedAt       pgtype.Timestamp `json:"failed_at"`
+	FailureReason  string           `json:"failure_reason"`
+}
+
+func (q *Queries) ListRideStops(ctx context.Context, arg ListRideStopsParams) ([]ListRideStopsRow, error) {
+	rows, err := q.db.Query(ctx, listRideStops, arg.RideID, arg.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRideStopsRow
+	for rows.Next() {
+		var i ListRideStopsRow
+		if err := rows.Scan(
+			&i.Order.ID,
+			&i.Order.CustomerName,
+			&i.Order.Phone,
+			&i.Order.City,
+			&i.Order.Street,
+			&i.Order.Apartment,
+			&i.Order.Floor,
+			&i.Order.Doorphone,
+			&i.Order.Building,
+			&i.Order.Entrance,
+			&i.Order.Comment,
+			&i.Order.Cost,
+			&i.Order.Status,
+			&i.Order.Location,
+			&i.Order.CreatedAt,
+			&i.Order.ExternalID,
+			&i.Order.BranchID,
+			&i.Order.OutOfZone,
+			&i.Order.OrganizationID,
+			&i.Order.IikoOrganizationID,
+			&i.Order.GuestCount,
+			&i.Order.CourierName,
+			&i.Order.CourierPhone,
+			&i.Order.CashToCollect,
+			&i.Order.PromisedAt,
+			&i.Order.Lateness,
+			&i.Order.Source,
+			&i.Order.IikoStatus,
+			&i.Order.IikoDeliveryStatus,
+			&i.Order.LocationSource,
+			&i.Order.CustomerID,
+			&i.Sequence,
+			&i.LegDistance,
+			&i.PlannedArrival,
+			&i.Eta,
+			&i.StopStatus,
+			&i.ArrivedAt,
+			&i.DeliveredAt,
+			&i.FailedAt,
+			&i.FailureReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnfinishedRideStops = `-- name: ListUnfinishedRideStops :many
+SELECT order_id
+FROM rides_to_orders
+WHERE ride_id = $1 AND stop_status IN ('pending', 'arrived')
+ORDER BY sequence NULLS LAST, order_id
+`
+
+func (q *Queries) ListUnfinishedRideStops(ctx context.Context, rideID int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listUnfinishedRideStops, rideID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var order_id int64
+		if err := rows.Scan(&order_id); err != nil {
+			return nil, err
+		}
+		items = append(items, order_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateRideStatus = `-- name: UpdateRideStatus :one
+UPDATE rides
+SET status      = $1,
+    departed_at = COALESCE(departed_at, $2),
+    started_at  = COALESCE(started_at, $3),
+    ended_at    = COALESCE(ended_at, $4)
+WHERE id = $5 AND status = $6
+RETURNING id, branch_id, created_at, ended_at, driver_id, status, departed_at, started_at, version
 `
 
-func (q *Queries) GetRide(ctx context.Context, id int64) (Ride, error) {
-	row := q.db.QueryRow(ctx, getRide, id)
+type UpdateRideStatusParams struct {
+	Status     string           `json:"status"`
+	DepartedAt pgtype.Timestamp `json:"departed_at"`
+	StartedAt  pgtype.Timestamp `json:"started_at"`
+	EndedAt    pgtype.Timestamp `json:"ended_at"`
+	ID         int64            `json:"id"`
+	FromStatus string           `json:"from_status"`
+}
+
+// Moves the ride from from_status only, so concurrent transitions fail.
+// Transition timestamps are kept once set.
+func (q *Queries) UpdateRideStatus(ctx context.Context, arg UpdateRideStatusParams) (Ride, error) {
+	row := q.db.QueryRow(ctx, updateRideStatus,
+		arg.Status,
+		arg.DepartedAt,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.ID,
+		arg.FromStatus,
+	)
 	var i Ride
 	err := row.Scan(
 		&i.ID,
 		&i.BranchID,
 		&i.CreatedAt,
 		&i.EndedAt,
+		&i.DriverID,
+		&i.Status,
+		&i.DepartedAt,
+		&i.StartedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateRideStop = `-- name: UpdateRideStop :exec
+UPDATE rides_to_orders
+SET sequence        = $1,
+    leg_distance    = $2,
+    planned_arrival = $3
+WHERE ride_id = $4 AND order_id = $5
+`
+
+type UpdateRideStopParams struct {
+	Sequence       *int32           `json:"sequence"`
+	LegDistance    *float64         `json:"leg_distance"`
+	PlannedArrival pgtype.Timestamp `json:"planned_arrival"`
+	RideID         int64            `json:"ride_id"`
+	OrderID        int64            `json:"order_id"`
+}
+
+func (q *Queries) UpdateRideStop(ctx context.Context, arg UpdateRideStopParams) error {
+	_, err := q.db.Exec(ctx, updateRideStop,
+		arg.Sequence,
+		arg.LegDistance,
+		arg.PlannedArrival,
+		arg.RideID,
+		arg.OrderID,
+	)
+	return err
+}
+
+const updateRideStopStatus = `-- name: UpdateRideStopStatus :one
+UPDATE rides_to_orders
+SET stop_status    = $1,
+    arrived_at     = COALESCE(arrived_at, $2),
+    delivered_at   = $3,
+    failed_at      = $4,
+    failure_reason = $5
+WHERE ride_id = $6 AND order_id = $7 AND stop_status = $8
+RETURNING id, ride_id, order_id, sequence, leg_distance, planned_arrival, stop_status, arrived_at, delivered_at, failed_at, failure_reason, eta
+`
+
+type UpdateRideStopStatusParams struct {
+	StopStatus    string           `json:"stop_status"`
+	ArrivedAt     pgtype.Timestamp `json:"arrived_at"`
+	DeliveredAt   pgtype.Timestamp `json:"delivered_at"`
+	FailedAt      pgtype.Timestamp `json:"failed_at"`
+	FailureReason string           `json:"failure_reason"`
+	RideID        int64            `json:"ride_id"`
+	OrderID       int64            `json:"order_id"`
+	FromStatus    string           `json:"from_status"`
+}
+
+// Moves the stop from from_status only, so concurrent transitions fail
+func (q *Queries) UpdateRideStopStatus(ctx context.Context, arg UpdateRideStopStatusParams) (RidesToOrder, error) {
+	row := q.db.QueryRow(ctx, updateRideStopStatus,
+		arg.StopStatus,
+		arg.ArrivedAt,
+		arg.DeliveredAt,
+		arg.FailedAt,
+		arg.FailureReason,
+		arg.RideID,
+		arg.OrderID,
+		arg.FromStatus,
+	)
+	var i RidesToOrder
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.OrderID,
+		&i.Sequence,
+		&i.LegDistance,
+		&i.PlannedArrival,
+		&i.StopStatus,
+		&i.ArrivedAt,
+		&i.DeliveredAt,
+		&i.FailedAt,
+		&i.FailureReason,
+		&i.Eta,
 	)
 	return i, err
 }
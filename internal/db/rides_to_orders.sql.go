@@ -11,7 +11,7 @@ import (
 
 const createRideToOrder = `-- name: CreateRideToOrder :one
 INSERT INTO rides_to_orders (ride_id, order_id)
-    VALUES ($1, $2) RETURNING id, ride_id, order_id
+    VALUES ($1, $2) RETURNING id, ride_id, order_id, sequence, leg_distance, planned_arrival, stop_status, arrived_at, delivered_at, failed_at, failure_reason, eta
 `
 
 type CreateRideToOrderParams struct {
@@ -22,7 +22,20 @@ type CreateRideToOrderParams struct {
 func (q *Queries) CreateRideToOrder(ctx context.Context, arg CreateRideToOrderParams) (RidesToOrder, error) {
 	row := q.db.QueryRow(ctx, createRideToOrder, arg.RideID, arg.OrderID)
 	var i RidesToOrder
-	err := row.Scan(&i.ID, &i.RideID, &i.OrderID)
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.OrderID,
+		&i.Sequence,
+		&i.LegDistance,
+		&i.PlannedArrival,
+		&i.StopStatus,
+		&i.ArrivedAt,
+		&i.DeliveredAt,
+		&i.FailedAt,
+		&i.FailureReason,
+		&i.Eta,
+	)
 	return i, err
 }
 
@@ -37,18 +50,31 @@ func (q *Queries) DeleteRideToOrder(ctx context.Context, id int64) error {
 }
 
 const getRideToOrder = `-- name: GetRideToOrder :one
-SELECT id, ride_id, order_id FROM rides_to_orders WHERE id = $1
+SELECT id, ride_id, order_id, sequence, leg_distance, planned_arrival, stop_status, arrived_at, delivered_at, failed_at, failure_reason, eta FROM rides_to_orders WHERE id = $1
 `
 
 func (q *Queries) GetRideToOrder(ctx context.Context, id int64) (RidesToOrder, error) {
 	row := q.db.QueryRow(ctx, getRideToOrder, id)
 	var i RidesToOrder
-	err := row.Scan(&i.ID, &i.RideID, &i.OrderID)
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.OrderID,
+		&i.Sequence,
+		&i.LegDistance,
+		&i.PlannedArrival,
+		&i.StopStatus,
+		&i.ArrivedAt,
+		&i.DeliveredAt,
+		&i.FailedAt,
+		&i.FailureReason,
+		&i.Eta,
+	)
 	return i, err
 }
 
 const listRidesToOrders = `-- name: ListRidesToOrders :many
-SELECT id, ride_id, order_id FROM rides_to_orders
+SELECT id, ride_id, order_id, sequence, leg_distance, planned_arrival, stop_status, arrived_at, delivered_at, failed_at, failure_reason, eta FROM rides_to_orders
 `
 
 func (q *Queries) ListRidesToOrders(ctx context.Context) ([]RidesToOrder, error) {
@@ -60,7 +86,20 @@ func (q *Queries) ListRidesToOrders(ctx context.Context) ([]RidesToOrder, error)
 	var items []RidesToOrder
 	for rows.Next() {
 		var i RidesToOrder
-		if err := rows.Scan(&i.ID, &i.RideID, &i.OrderID); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.RideID,
+			&i.OrderID,
+			&i.Sequence,
+			&i.LegDistance,
+			&i.PlannedArrival,
+			&i.StopStatus,
+			&i.ArrivedAt,
+			&i.DeliveredAt,
+			&i.FailedAt,
+			&i.FailureReason,
+			&i.Eta,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -75,7 +114,7 @@ const updateRideToOrder = `-- name: UpdateRideToOrder :one
 UPDATE rides_to_orders
 SET ride_id = $2, order_id = $3
 WHERE id = $1
-RETURNING id, ride_id, order_id
+RETURNING id, ride_id, order_id, sequence, leg_distance, planned_arrival, stop_status, arrived_at, delivered_at, failed_at, failure_reason, eta
 `
 
 type UpdateRideToOrderParams struct {
@@ -87,6 +126,19 @@ type UpdateRideToOrderParams struct {
 func (q *Queries) UpdateRideToOrder(ctx context.Context, arg UpdateRideToOrderParams) (RidesToOrder, error) {
 	row := q.db.QueryRow(ctx, updateRideToOrder, arg.ID, arg.RideID, arg.OrderID)
 	var i RidesToOrder
-	err := row.Scan(&i.ID, &i.RideID, &i.OrderID)
+	err := row.Scan(
+		&i.ID,
+		&i.RideID,
+		&i.OrderID,
+		&i.Sequence,
+		&i.LegDistance,
+		&i.PlannedArrival,
+		&i.StopStatus,
+		&i.ArrivedAt,
+		&i.DeliveredAt,
+		&i.FailedAt,
+		&i.FailureReason,
+		&i.Eta,
+	)
 	return i, err
 }
@@ -0,0 +1,101 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: tracking.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createDriverPosition = `-- name: CreateDriverPosition :exec
+INSERT INTO driver_positions (driver_id, ride_id, location, accuracy, speed, recorded_at)
+VALUES ($1, $2, point($3::float8, $4::float8), $5,
+        $6, $7)
+`
+
+type CreateDriverPositionParams struct {
+	DriverID   int64            `json:"driver_id"`
+	RideID     *int64           `json:"ride_id"`
+	Lng        float64          `json:"lng"`
+	Lat        float64          `json:"lat"`
+	Accuracy   *float64         `json:"accuracy"`
+	Speed      *float64         `json:"speed"`
+	RecordedAt pgtype.Timestamp `json:"recorded_at"`
+}
+
+func (q *Queries) CreateDriverPosition(ctx context.Context, arg CreateDriverPositionParams) error {
+	_, err := q.db.Exec(ctx, createDriverPosition,
+		arg.DriverID,
+		arg.RideID,
+		arg.Lng,
+		arg.Lat,
+		arg.Accuracy,
+		arg.Speed,
+		arg.RecordedAt,
+	)
+	return err
+}
+
+const getLatestDriverPosition = `-- name: GetLatestDriverPosition :one
+SELECT id, driver_id, ride_id, location, accuracy, speed, recorded_at, received_at
+FROM driver_positions
+WHERE driver_id = $1
+ORDER BY recorded_at DESC, id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestDriverPosition(ctx context.Context, driverID int64) (DriverPosition, error) {
+	row := q.db.QueryRow(ctx, getLatestDriverPosition, driverID)
+	var i DriverPosition
+	err := row.Scan(
+		&i.ID,
+		&i.DriverID,
+		&i.RideID,
+		&i.Location,
+		&i.Accuracy,
+		&i.Speed,
+		&i.RecordedAt,
+		&i.ReceivedAt,
+	)
+	return i, err
+}
+
+const listRideTrack = `-- name: ListRideTrack :many
+SELECT id, driver_id, ride_id, location, accuracy, speed, recorded_at, received_at
+FROM driver_positions
+WHERE ride_id = $1
+ORDER BY recorded_at, id
+`
+
+func (q *Queries) ListRideTrack(ctx context.Context, rideID *int64) ([]DriverPosition, error) {
+	rows, err := q.db.Query(ctx, listRideTrack, rideID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DriverPosition
+	for rows.Next() {
+		var i DriverPosition
+		if err := rows.Scan(
+			&i.ID,
+			&i.DriverID,
+			&i.RideID,
+			&i.Location,
+			&i.Accuracy,
+			&i.Speed,
+			&i.RecordedAt,
+			&i.ReceivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
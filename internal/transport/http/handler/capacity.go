@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/capacity"
+	"smartDriver/pkg/eta"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/rides"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type vehicleLimitsBody struct {
+	MaxOrders   *int32 `json:"max_orders" minimum:"1" maximum:"100" doc:"Maximum number of orders in a ride, null is not limited"`
+	MaxWeight   *int32 `json:"max_weight" minimum:"1" doc:"Maximum total weight of the orders in grams, null is not limited"`
+	MaxVolume   *int32 `json:"max_volume" minimum:"1" doc:"Maximum total volume of the orders in milliliters, null is not limited"`
+	MaxDistance *int32 `json:"max_distance" minimum:"1" doc:"Maximum route length from the branch to the last stop in meters, null is not limited"`
+}
+
+type vehicleLimits struct {
+	VehicleType string `json:"vehicle_type" enum:"foot,bicycle,scooter,car" doc:"Vehicle type"`
+	vehicleLimitsBody
+	Custom bool `json:"custom" doc:"The organization configured the limits, otherwise defaults apply"`
+}
+
+type vehicleLimitsListOut struct {
+	Body struct {
+		Vehicles []vehicleLimits `json:"vehicles" doc:"Limits of every vehicle type"`
+	}
+}
+
+type vehicleLimitsOut struct {
+	Body vehicleLimits
+}
+
+type updateVehicleLimitsIn struct {
+	VehicleType string `path:"vehicle_type" enum:"foot,bicycle,scooter,car" doc:"Vehicle type"`
+	Body        vehicleLimitsBody
+}
+
+type productSizeBody struct {
+	Weight int32 `json:"weight" minimum:"0" doc:"Weight of one unit in grams"`
+	Volume int32 `json:"volume" minimum:"0" doc:"Volume of one unit in milliliters"`
+}
+
+type productSize struct {
+	ProductID string `json:"product_id" doc:"iiko product ID"`
+	productSizeBody
+}
+
+type productSizesOut struct {
+	Body struct {
+		Products      []productSize `json:"products" doc:"Products with a known size"`
+		DefaultWeight int32         `json:"default_weight" doc:"Weight in grams of a unit of other products"`
+		DefaultVolume int32         `json:"default_volume" doc:"Volume in milliliters of a unit of other products"`
+	}
+}
+
+type productSizeOut struct {
+	Body productSize
+}
+
+type productIDPathIn struct {
+	ProductID string `path:"product_id" maxLength:"100" doc:"iiko product ID"`
+}
+
+type updateProductSizeIn struct {
+	productIDPathIn
+	Body productSizeBody
+}
+
+// orderSize is how much room an order takes in a vehicle
+type orderSize struct {
+	Weight float64 `json:"weight" doc:"Total weight of the items in grams"`
+	Volume float64 `json:"volume" doc:"Total volume of the items in milliliters"`
+}
+
+// ListVehicleLimits lists what each vehicle type can carry in a ride
+func ListVehicleLimits(ctx context.Context, _ *struct{}) (*vehicleLimitsListOut, error) {
+	custom, err := db.Repository.ListVehicleLimits(ctx, organizationID(ctx))
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list vehicle limits: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get vehicle limits", err)
+	}
+
+	configured := make(map[string]db.VehicleLimit, len(custom))
+	for _, limits := range custom {
+		configured[limits.VehicleType] = limits
+	}
+
+	var resp vehicleLimitsListOut
+	resp.Body.Vehicles = make([]vehicleLimits, 0, len(eta.VehicleTypes))
+	for _, vehicleType := range eta.VehicleTypes {
+		if limits, ok := configured[vehicleType]; ok {
+			resp.Body.Vehicles = append(resp.Body.Vehicles, newVehicleLimits(limits))
+			continue
+		}
+		defaults := capacity.DefaultLimits(vehicleType)
+		resp.Body.Vehicles = append(resp.Body.Vehicles, vehicleLimits{
+			VehicleType: vehicleType,
+			vehicleLimitsBody: vehicleLimitsBody{
+				MaxOrders:   limitValue(float64(defaults.MaxOrders)),
+				MaxWeight:   limitValue(defaults.MaxWeight),
+				MaxVolume:   limitValue(defaults.MaxVolume),
+				MaxDistance: limitValue(defaults.MaxDistance),
+			},
+		})
+	}
+
+	return &resp, nil
+}
+
+// UpdateVehicleLimits sets what a vehicle type can carry in a ride. Rides
+// already planned are not checked again.
+func UpdateVehicleLimits(ctx context.Context, in *updateVehicleLimitsIn) (*vehicleLimitsOut, error) {
+	limits, err := db.Repository.UpsertVehicleLimits(ctx, db.UpsertVehicleLimitsParams{
+		OrganizationID: organizationID(ctx),
+		VehicleType:    in.VehicleType,
+		MaxOrders:      in.Body.MaxOrders,
+		MaxWeight:      in.Body.MaxWeight,
+		MaxVolume:      in.Body.MaxVolume,
+		MaxDistance:    in.Body.MaxDistance,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to update vehicle limits: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update vehicle limits", err)
+	}
+
+	return &vehicleLimitsOut{Body: newVehicleLimits(limits)}, nil
+}
+
+// ListProductSizes lists the products of the organization with a known size
+func ListProductSizes(ctx context.Context, _ *struct{}) (*productSizesOut, error) {
+	sizes, err := db.Repository.ListProductSizes(ctx, organizationID(ctx))
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list product sizes: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get product sizes", err)
+	}
+
+	var resp productSizesOut
+	resp.Body.DefaultWeight = capacity.DefaultItemWeight
+	resp.Body.DefaultVolume = capacity.DefaultItemVolume
+	resp.Body.Products = make([]productSize, 0, len(sizes))
+	for _, size := range sizes {
+		resp.Body.Products = append(resp.Body.Products, newProductSize(size))
+	}
+
+	return &resp, nil
+}
+
+// UpdateProductSize sets the size of one unit of a product
+func UpdateProductSize(ctx context.Context, in *updateProductSizeIn) (*productSizeOut, error) {
+	size, err := db.Repository.UpsertProductSize(ctx, db.UpsertProductSizeParams{
+		OrganizationID: organizationID(ctx),
+		ProductID:      in.ProductID,
+		Weight:         in.Body.Weight,
+		Volume:         in.Body.Volume,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to update product size: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update product size", err)
+	}
+
+	return &productSizeOut{Body: newProductSize(size)}, nil
+}
+
+// DeleteProductSize removes the size of a product, its units count with the
+// default size again
+func DeleteProductSize(ctx context.Context, in *productIDPathIn) (*successOut, error) {
+	deleted, err := db.Repository.DeleteProductSize(ctx, db.DeleteProductSizeParams{
+		OrganizationID: organizationID(ctx),
+		ProductID:      in.ProductID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to delete product size: %v", err)
+		return nil, huma.Error500InternalServerError("failed to delete product size", err)
+	}
+	if deleted == 0 {
+		return nil, huma.Error404NotFound("product size not found")
+	}
+
+	var resp successOut
+	resp.Body.Success = true
+	return &resp, nil
+}
+
+// Helper function to check the unfinished orders of a ride fit the vehicle of
+// its driver. The route of the ride must be sequenced.
+func checkRideCapacity(ctx context.Context, q *db.Queries, orgID int64, ride db.Ride) error {
+	if ride.DriverID == nil {
+		return nil
+	}
+
+	driver, err := q.GetDriver(ctx, db.GetDriverParams{
+		ID:             *ride.DriverID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get ride driver: %v", err)
+		return huma.Error500InternalServerError("failed to check ride capacity", err)
+	}
+
+	limits, err := getVehicleLimits(ctx, q, orgID, driver.VehicleType)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get vehicle limits: %v", err)
+		return huma.Error500InternalServerError("failed to check ride capacity", err)
+	}
+
+	stops, err := q.ListRideStops(ctx, db.ListRideStopsParams{
+		RideID:         ride.ID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list ride stops: %v", err)
+		return huma.Error500InternalServerError("failed to check ride capacity", err)
+	}
+
+	var (
+		orderIDs []int64
+		distance float64
+	)
+	for _, stop := range stops {
+		if rides.StopStatus(stop.StopStatus).IsFinished() {
+			continue
+		}
+		orderIDs = append(orderIDs, stop.Order.ID)
+		if stop.LegDistance != nil {
+			distance += *stop.LegDistance
+		}
+	}
+
+	sizes, err := orderSizes(ctx, q, orgID, orderIDs)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get order sizes: %v", err)
+		return huma.Error500InternalServerError("failed to check ride capacity", err)
+	}
+
+	var total capacity.Size
+	for _, size := range sizes {
+		total = total.Add(size)
+	}
+
+	if err := limits.Check(len(orderIDs), total, distance); err != nil {
+		return huma.Error422UnprocessableEntity(fmt.Sprintf("%s of driver %d: %v", driver.VehicleType, driver.ID, err))
+	}
+
+	return nil
+}
+
+// Helper function to get the limits of a vehicle type, falling back to the
+// defaults
+func getVehicleLimits(ctx context.Context, q *db.Queries, orgID int64, vehicleType string) (capacity.Limits, error) {
+	limits, err := q.GetVehicleLimits(ctx, db.GetVehicleLimitsParams{
+		OrganizationID: orgID,
+		VehicleType:    vehicleType,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return capacity.DefaultLimits(vehicleType), nil
+		}
+		return capacity.Limits{}, fmt.Errorf("failed to get vehicle limits: %w", err)
+	}
+
+	var result capacity.Limits
+	if limits.MaxOrders != nil {
+		result.MaxOrders = int(*limits.MaxOrders)
+	}
+	if limits.MaxWeight != nil {
+		result.MaxWeight = float64(*limits.MaxWeight)
+	}
+	if limits.MaxVolume != nil {
+		result.MaxVolume = float64(*limits.MaxVolume)
+	}
+	if limits.MaxDistance != nil {
+		result.MaxDistance = float64(*limits.MaxDistance)
+	}
+	return result, nil
+}
+
+// Helper function to derive the size of orders from their items. Orders
+// without items take no room.
+func orderSizes(ctx context.Context, q *db.Queries, orgID int64, orderIDs []int64) (map[int64]capacity.Size, error) {
+	sizes := make(map[int64]capacity.Size, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return sizes, nil
+	}
+
+	rows, err := q.ListOrderSizes(ctx, db.ListOrderSizesParams{
+		DefaultWeight:  capacity.DefaultItemWeight,
+		DefaultVolume:  capacity.DefaultItemVolume,
+		OrganizationID: orgID,
+		OrderIds:       orderIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order sizes: %w", err)
+	}
+
+	for _, row := range rows {
+		sizes[row.OrderID] = capacity.Size{Weight: row.Weight, Volume: row.Volume}
+	}
+	return sizes, nil
+}
+
+// Helper function to convert stored vehicle limits to their response
+// representation
+func newVehicleLimits(limits db.VehicleLimit) vehicleLimits {
+	return vehicleLimits{
+		VehicleType: limits.VehicleType,
+		vehicleLimitsBody: vehicleLimitsBody{
+			MaxOrders:   limits.MaxOrders,
+			MaxWeight:   limits.MaxWeight,
+			MaxVolume:   limits.MaxVolume,
+			MaxDistance: limits.MaxDistance,
+		},
+		Custom: true,
+	}
+}
+
+// Helper function to convert a stored product size to its response
+// representation
+func newProductSize(size db.ProductSize) productSize {
+	return productSize{
+		ProductID: size.ProductID,
+		productSizeBody: productSizeBody{
+			Weight: size.Weight,
+			Volume: size.Volume,
+		},
+	}
+}
+
+// Helper function to convert a default limit to its response representation,
+// zero is not limited
+func limitValue(limit float64) *int32 {
+	if limit <= 0 {
+		return nil
+	}
+	value := int32(limit)
+	return &value
+}
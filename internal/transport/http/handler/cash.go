@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/payment"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type reportRideCashIn struct {
+	ID   int64 `path:"id" doc:"Ride ID"`
+	Body struct {
+		Collected float64 `json:"collected" minimum:"0" doc:"Cash the driver collected during the ride"`
+		Comment   string  `json:"comment,omitempty" maxLength:"1000" doc:"Driver explanation of a difference"`
+	}
+}
+
+type confirmRideCashIn struct {
+	ID   int64 `path:"id" doc:"Ride ID"`
+	Body struct {
+		Received float64 `json:"received" minimum:"0" doc:"Cash the branch received from the driver"`
+		Comment  string  `json:"comment,omitempty" maxLength:"1000" doc:"Branch comment on the handover"`
+	}
+}
+
+type rideCashOut struct {
+	Body struct {
+		RideID        int64       `json:"ride_id" doc:"Ride ID"`
+		Expected      float64     `json:"expected" doc:"Cash to collect for the orders handed over during the ride"`
+		Orders        []orderInfo `json:"orders" doc:"Orders the expected cash is made of"`
+		Status        string      `json:"status" enum:"pending,reported,confirmed" doc:"Reconciliation progress"`
+		Collected     *float64    `json:"collected,omitempty" doc:"Cash reported by the driver"`
+		ReportedBy    *int64      `json:"reported_by,omitempty" doc:"User who reported the cash"`
+		ReportedAt    *time.Time  `json:"reported_at,omitempty" doc:"When the cash was reported"`
+		DriverComment string      `json:"driver_comment,omitempty" doc:"Driver comment"`
+		Received      *float64    `json:"received,omitempty" doc:"Cash received by the branch"`
+		ConfirmedBy   *int64      `json:"confirmed_by,omitempty" doc:"User who confirmed the handover"`
+		ConfirmedAt   *time.Time  `json:"confirmed_at,omitempty" doc:"When the handover was confirmed"`
+		BranchComment string      `json:"branch_comment,omitempty" doc:"Branch comment"`
+		Discrepancy   float64     `json:"discrepancy" doc:"Received, or reported while unconfirmed, cash minus expected cash. Negative when cash is missing."`
+	}
+}
+
+type cashDiscrepanciesIn struct {
+	From              string `query:"from" format:"date" doc:"First day of the report, defaults to 30 days ago"`
+	To                string `query:"to" format:"date" doc:"Last day of the report, defaults to today"`
+	DriverID          int64  `query:"driver_id" doc:"Only rides of this driver"`
+	OnlyDiscrepancies bool   `query:"only_discrepancies" doc:"Skip days without a difference"`
+}
+
+type cashDiscrepancy struct {
+	DriverID       *int64  `json:"driver_id" doc:"Driver of the rides"`
+	DriverName     string  `json:"driver_name" doc:"Driver name"`
+	Day            string  `json:"day" format:"date" doc:"Day the rides started, in the organization's timezone"`
+	RideCount      int64   `json:"ride_count" doc:"Rides with a cash report"`
+	ConfirmedCount int64   `json:"confirmed_count" doc:"Rides with a confirmed handover"`
+	Expected       float64 `json:"expected" doc:"Cash to collect"`
+	Collected      float64 `json:"collected" doc:"Cash reported by the driver"`
+	Received       float64 `json:"received" doc:"Cash received by the branch"`
+	Discrepancy    float64 `json:"discrepancy" doc:"Handed over cash minus expected cash. Negative when cash is missing."`
+}
+
+type cashDiscrepanciesOut struct {
+	Body struct {
+		From   string            `json:"from" format:"date"`
+		To     string            `json:"to" format:"date"`
+		Shifts []cashDiscrepancy `json:"shifts" doc:"Cash per driver and day, newest first"`
+	}
+}
+
+// GetRideCash retrieves the expected cash of a ride and its reconciliation
+func GetRideCash(ctx context.Context, in *idPathIn) (*rideCashOut, error) {
+	ride, err := getOrganizationRide(ctx, db.Repository, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, expected, err := rideExpectedCash(ctx, db.Repository, ride.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := db.Repository.GetRideCashReport(ctx, ride.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.SugaredLogger.Errorf("failed to get ride cash report: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get ride cash", err)
+	}
+	if err != nil {
+		report = db.RideCashReport{}
+	}
+
+	return buildRideCashResponse(ride.ID, orders, expected, report), nil
+}
+
+// ReportRideCash records the cash a driver collected during a ride. The
+// report may be corrected until the branch confirms the handover.
+func ReportRideCash(ctx context.Context, in *reportRideCashIn) (*rideCashOut, error) {
+	ride, err := getOrganizationRide(ctx, db.Repository, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, expected, err := rideExpectedCash(ctx, db.Repository, ride.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := ctx.Value("user_id").(int64)
+	report, err := db.Repository.UpsertRideCashReport(ctx, db.UpsertRideCashReportParams{
+		RideID:        ride.ID,
+		Expected:      db.NumericFromFloat(expected),
+		Collected:     db.NumericFromFloat(payment.Round(in.Body.Collected)),
+		ReportedBy:    &userID,
+		DriverComment: in.Body.Comment,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error409Conflict("cash handover is already confirmed")
+		}
+		log.SugaredLogger.Errorf("failed to save ride cash report: %v", err)
+		return nil, huma.Error500InternalServerError("failed to report ride cash", err)
+	}
+
+	return buildRideCashResponse(ride.ID, orders, expected, report), nil
+}
+
+// ConfirmRideCash confirms the branch received the cash of a ride from the
+// driver. The expected cash is fixed at this moment.
+func ConfirmRideCash(ctx context.Context, in *confirmRideCashIn) (*rideCashOut, error) {
+	ride, err := getOrganizationRide(ctx, db.Repository, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, expected, err := rideExpectedCash(ctx, db.Repository, ride.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := ctx.Value("user_id").(int64)
+	report, err := db.Repository.ConfirmRideCashHandover(ctx, db.ConfirmRideCashHandoverParams{
+		RideID:        ride.ID,
+		Expected:      db.NumericFromFloat(expected),
+		Received:      db.NumericFromFloat(payment.Round(in.Body.Received)),
+		ConfirmedBy:   &userID,
+		BranchComment: in.Body.Comment,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error409Conflict("cash is not reported yet or the handover is already confirmed")
+		}
+		log.SugaredLogger.Errorf("failed to confirm ride cash handover: %v", err)
+		return nil, huma.Error500InternalServerError("failed to confirm ride cash", err)
+	}
+
+	return buildRideCashResponse(ride.ID, orders, expected, report), nil
+}
+
+// GetCashDiscrepancies reports cash per driver and day with the difference
+// between expected and handed over cash
+func GetCashDiscrepancies(ctx context.Context, in *cashDiscrepanciesIn) (*cashDiscrepanciesOut, error) {
+	from, to, err := reportPeriod(in.From, in.To)
+	if err != nil {
+		return nil, err
+	}
+
+	params := db.ListCashDiscrepanciesParams{
+		OrganizationID:    organizationID(ctx),
+		DateFrom:          pgtype.Date{Time: from, Valid: true},
+		DateTo:            pgtype.Date{Time: to, Valid: true},
+		OnlyDiscrepancies: in.OnlyDiscrepancies,
+	}
+	if in.DriverID != 0 {
+		params.DriverID = &in.DriverID
+	}
+
+	rows, err := db.Repository.ListCashDiscrepancies(ctx, params)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list cash discrepancies: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get cash discrepancies", err)
+	}
+
+	var resp cashDiscrepanciesOut
+	resp.Body.From = from.Format(time.DateOnly)
+	resp.Body.To = to.Format(time.DateOnly)
+	resp.Body.Shifts = make([]cashDiscrepancy, 0, len(rows))
+	for _, row := range rows {
+		resp.Body.Shifts = append(resp.Body.Shifts, cashDiscrepancy{
+			DriverID:       row.DriverID,
+			DriverName:     formatPersonName(row.DriverName, row.DriverSurname),
+			Day:            row.Day.Time.Format(time.DateOnly),
+			RideCount:      row.RideCount,
+			ConfirmedCount: row.ConfirmedCount,
+			Expected:       payment.Round(numericValue(row.Expected)),
+			Collected:      payment.Round(numericValue(row.Collected)),
+			Received:       payment.Round(numericValue(row.Received)),
+			Discrepancy:    payment.Round(numericValue(row.Discrepancy)),
+		})
+	}
+
+	return &resp, nil
+}
+
+// Helper function to parse the days of a report. The report covers the last
+// 30 days by default.
+func reportPeriod(fromDay, toDay string) (time.Time, time.Time, error) {
+	to := time.Now()
+	if toDay != "" {
+		t, err := time.Parse(time.DateOnly, toDay)
+		if err != nil {
+			return time.Time{}, time.Time{}, huma.Error422UnprocessableEntity("to must be a date")
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -30)
+	if fromDay != "" {
+		t, err := time.Parse(time.DateOnly, fromDay)
+		if err != nil {
+			return time.Time{}, time.Time{}, huma.Error422UnprocessableEntity("from must be a date")
+		}
+		from = t
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, huma.Error422UnprocessableEntity("from is after to")
+	}
+	return from, to, nil
+}
+
+// Helper function to load a ride of the caller's organization
+func getOrganizationRide(ctx context.Context, q *db.Queries, id int64) (db.Ride, error) {
+	ride, err := q.GetRide(ctx, db.GetRideParams{
+		ID:             id,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Ride{}, huma.Error404NotFound("ride not found")
+		}
+		log.SugaredLogger.Errorf("failed to get ride: %v", err)
+		return db.Ride{}, huma.Error500InternalServerError("failed to get ride", err)
+	}
+	return ride, nil
+}
+
+// Helper function to list the orders handed over during a ride and sum the
+// cash they were to be paid with
+func rideExpectedCash(ctx context.Context, q *db.Queries, rideID int64) ([]db.Order, float64, error) {
+	orders, err := q.ListRideCashOrders(ctx, rideID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list ride cash orders: %v", err)
+		return nil, 0, huma.Error500InternalServerError("failed to get ride cash", err)
+	}
+
+	var expected float64
+	for _, order := range orders {
+		expected += numericValue(order.CashToCollect)
+	}
+	return orders, payment.Round(expected), nil
+}
+
+// Helper function to build ride cash response
+func buildRideCashResponse(rideID int64, orders []db.Order, expected float64, report db.RideCashReport) *rideCashOut {
+	var resp rideCashOut
+	resp.Body.RideID = rideID
+	resp.Body.Expected = expected
+	resp.Body.Orders = make([]orderInfo, 0, len(orders))
+	for _, order := range orders {
+		resp.Body.Orders = append(resp.Body.Orders, newOrderInfo(order))
+	}
+
+	resp.Body.Status = "pending"
+	if report.ID == 0 {
+		return &resp
+	}
+
+	// Confirmed reports keep the expected cash fixed at the handover
+	if report.ConfirmedAt.Valid {
+		resp.Body.Status = "confirmed"
+		resp.Body.Expected = payment.Round(numericValue(report.Expected))
+	} else {
+		resp.Body.Status = "reported"
+	}
+
+	collected := payment.Round(numericValue(report.Collected))
+	resp.Body.Collected = &collected
+	resp.Body.ReportedBy = report.ReportedBy
+	resp.Body.ReportedAt = &report.ReportedAt.Time
+	resp.Body.DriverComment = report.DriverComment
+	resp.Body.Discrepancy = payment.Round(collected - resp.Body.Expected)
+
+	if report.ConfirmedAt.Valid {
+		received := payment.Round(numericValue(report.Received))
+		resp.Body.Received = &received
+		resp.Body.ConfirmedBy = report.ConfirmedBy
+		resp.Body.ConfirmedAt = &report.ConfirmedAt.Time
+		resp.Body.BranchComment = report.BranchComment
+		resp.Body.Discrepancy = payment.Round(received - resp.Body.Expected)
+	}
+
+	return &resp
+}
+
+// Helper function to join name parts of a person
+func formatPersonName(name, surname string) string {
+	if name == "" || surname == "" {
+		return name + surname
+	}
+	return name + " " + surname
+}
@@ -5,6 +5,6 @@ type idPathIn struct {
 }
 
 type listIn struct {
-	Cursor string `query:"cursor"`
-	Limit  int    `query:"limit"`
+	Cursor string `query:"cursor" doc:"Opaque cursor returned as next_cursor by the previous page"`
+	Limit  int    `query:"limit" default:"50" minimum:"1" maximum:"500" doc:"Maximum number of items to return"`
 }
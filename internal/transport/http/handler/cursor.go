@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// encodeCursor serializes the position of the last returned item into an
+// opaque string clients pass back to fetch the next page
+func encodeCursor(position any) (string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor restores a position encoded by encodeCursor
+func decodeCursor(cursor string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, position)
+}
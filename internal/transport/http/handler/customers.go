@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/log"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type listCustomersIn struct {
+	Query struct {
+		Search  string `query:"search" doc:"Search in phone and name"`
+		Flagged string `query:"flagged" enum:"all,flagged,unflagged" default:"all" doc:"Filter by problematic customer flags"`
+		Limit   int32  `query:"limit" default:"50" minimum:"1" maximum:"500" doc:"Maximum number of customers to return"`
+		Offset  int32  `query:"offset" default:"0" minimum:"0" doc:"Number of customers to skip"`
+	}
+}
+
+type customerInfo struct {
+	ID         int64     `json:"id" doc:"Customer ID"`
+	Phone      string    `json:"phone" doc:"Normalized phone"`
+	Name       string    `json:"name" doc:"Name from the latest order"`
+	Notes      string    `json:"notes" doc:"Delivery notes for drivers"`
+	OrderCount int64     `json:"order_count,omitempty" doc:"Number of orders"`
+	CreatedAt  time.Time `json:"created_at" doc:"First order time"`
+	UpdatedAt  time.Time `json:"updated_at" doc:"Last update time"`
+}
+
+type listCustomersOut struct {
+	Body struct {
+		Customers []customerInfo `json:"customers" doc:"List of customers"`
+		Limit     int32          `json:"limit" doc:"Current page limit"`
+		Offset    int32          `json:"offset" doc:"Current page offset"`
+	}
+}
+
+type customerAddress struct {
+	ID         int64     `json:"id" doc:"Address ID"`
+	City       string    `json:"city"`
+	Street     string    `json:"street"`
+	Building   string    `json:"building"`
+	Apartment  string    `json:"apartment"`
+	Entrance   *int32    `json:"entrance,omitempty"`
+	Floor      *int32    `json:"floor,omitempty"`
+	Doorphone  string    `json:"doorphone,omitempty"`
+	Location   point     `json:"location"`
+	UseCount   int32     `json:"use_count" doc:"Number of orders delivered to the address"`
+	LastUsedAt time.Time `json:"last_used_at" doc:"Latest order to the address"`
+}
+
+type customerFlag struct {
+	ID        int64     `json:"id" doc:"Flag ID"`
+	Flag      string    `json:"flag" doc:"Flag kind"`
+	Comment   string    `json:"comment" doc:"Why the customer was flagged"`
+	CreatedBy *int64    `json:"created_by" doc:"User who flagged the customer"`
+	CreatedAt time.Time `json:"created_at" doc:"When the customer was flagged"`
+}
+
+type customerOut struct {
+	Body struct {
+		customerInfo
+		Addresses []customerAddress `json:"addresses" doc:"Saved delivery addresses, most recent first"`
+		Flags     []customerFlag    `json:"flags" doc:"Flags of a problematic customer"`
+	}
+}
+
+type updateCustomerIn struct {
+	ID   int64 `path:"id" doc:"Customer ID"`
+	Body struct {
+		Name  string `json:"name" maxLength:"255" doc:"Customer name"`
+		Notes string `json:"notes" maxLength:"2000" doc:"Delivery notes for drivers, e.g. gate code or a dog in the yard"`
+	}
+}
+
+type listCustomerOrdersIn struct {
+	ID     int64 `path:"id" doc:"Customer ID"`
+	Limit  int32 `query:"limit" default:"50" minimum:"1" maximum:"500" doc:"Maximum number of orders to return"`
+	Offset int32 `query:"offset" default:"0" minimum:"0" doc:"Number of orders to skip"`
+}
+
+type customerOrdersOut struct {
+	Body struct {
+		Orders []orderInfo `json:"orders" doc:"Delivery history, newest first"`
+	}
+}
+
+type addCustomerFlagIn struct {
+	ID   int64 `path:"id" doc:"Customer ID"`
+	Body struct {
+		Flag    string `json:"flag" enum:"no_show,refused_payment,aggressive,fraud,other" doc:"Flag kind"`
+		Comment string `json:"comment,omitempty" maxLength:"1000" doc:"Why the customer is flagged"`
+	}
+}
+
+type deleteCustomerFlagIn struct {
+	ID     int64 `path:"id" doc:"Customer ID"`
+	FlagID int64 `path:"flag_id" doc:"Flag ID"`
+}
+
+// ListCustomers lists customers of the organization
+func ListCustomers(ctx context.Context, in *listCustomersIn) (*listCustomersOut, error) {
+	params := db.ListCustomersParams{
+		OrganizationID: organizationID(ctx),
+		RowLimit:       in.Query.Limit,
+		RowOffset:      in.Query.Offset,
+	}
+	if in.Query.Search != "" {
+		params.Search = &in.Query.Search
+	}
+	switch in.Query.Flagged {
+	case "flagged":
+		flagged := true
+		params.Flagged = &flagged
+	case "unflagged":
+		flagged := false
+		params.Flagged = &flagged
+	}
+
+	rows, err := db.Repository.ListCustomers(ctx, params)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list customers: %v", err)
+		return nil, huma.Error500InternalServerError("failed to list customers", err)
+	}
+
+	var resp listCustomersOut
+	resp.Body.Limit = in.Query.Limit
+	resp.Body.Offset = in.Query.Offset
+	resp.Body.Customers = make([]customerInfo, 0, len(rows))
+	for _, row := range rows {
+		info := newCustomerInfo(db.Customer{
+			ID:             row.ID,
+			OrganizationID: row.OrganizationID,
+			Phone:          row.Phone,
+			Name:           row.Name,
+			Notes:          row.Notes,
+			CreatedAt:      row.CreatedAt,
+			UpdatedAt:      row.UpdatedAt,
+		})
+		info.OrderCount = row.OrderCount
+		resp.Body.Customers = append(resp.Body.Customers, info)
+	}
+
+	return &resp, nil
+}
+
+// GetCustomer retrieves a customer profile with saved addresses and flags
+func GetCustomer(ctx context.Context, in *idPathIn) (*customerOut, error) {
+	customer, err := getCustomer(ctx, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCustomerResponse(ctx, customer)
+}
+
+// UpdateCustomer updates the name and delivery notes of a customer
+func UpdateCustomer(ctx context.Context, in *updateCustomerIn) (*customerOut, error) {
+	customer, err := db.Repository.UpdateCustomer(ctx, db.UpdateCustomerParams{
+		ID:             in.ID,
+		OrganizationID: organizationID(ctx),
+		Name:           in.Body.Name,
+		Notes:          in.Body.Notes,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("customer not found")
+		}
+		log.SugaredLogger.Errorf("failed to update customer: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update customer", err)
+	}
+
+	return buildCustomerResponse(ctx, customer)
+}
+
+// ListCustomerOrders retrieves the delivery history of a customer
+func ListCustomerOrders(ctx context.Context, in *listCustomerOrdersIn) (*customerOrdersOut, error) {
+	customer, err := getCustomer(ctx, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := db.Repository.ListCustomerOrders(ctx, db.ListCustomerOrdersParams{
+		CustomerID:     &customer.ID,
+		OrganizationID: customer.OrganizationID,
+		Limit:          in.Limit,
+		Offset:         in.Offset,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list customer orders: %v", err)
+		return nil, huma.Error500InternalServerError("failed to list customer orders", err)
+	}
+
+	var resp customerOrdersOut
+	resp.Body.Orders = make([]orderInfo, 0, len(orders))
+	for _, order := range orders {
+		resp.Body.Orders = append(resp.Body.Orders, newOrderInfo(order))
+	}
+
+	return &resp, nil
+}
+
+// AddCustomerFlag marks a customer as problematic. Adding a flag the
+// customer already has replaces its comment.
+func AddCustomerFlag(ctx context.Context, in *addCustomerFlagIn) (*customerOut, error) {
+	customer, err := getCustomer(ctx, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := ctx.Value("user_id").(int64)
+	if _, err := db.Repository.CreateCustomerFlag(ctx, db.CreateCustomerFlagParams{
+		CustomerID: customer.ID,
+		Flag:       in.Body.Flag,
+		Comment:    in.Body.Comment,
+		CreatedBy:  &userID,
+	}); err != nil {
+		log.SugaredLogger.Errorf("failed to create customer flag: %v", err)
+		return nil, huma.Error500InternalServerError("failed to flag customer", err)
+	}
+
+	return buildCustomerResponse(ctx, customer)
+}
+
+// DeleteCustomerFlag removes a flag from a customer
+func DeleteCustomerFlag(ctx context.Context, in *deleteCustomerFlagIn) (*successOut, error) {
+	customer, err := getCustomer(ctx, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := db.Repository.DeleteCustomerFlag(ctx, db.DeleteCustomerFlagParams{
+		ID:         in.FlagID,
+		CustomerID: customer.ID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to delete customer flag: %v", err)
+		return nil, huma.Error500InternalServerError("failed to delete customer flag", err)
+	}
+	if deleted == 0 {
+		return nil, huma.Error404NotFound("flag not found")
+	}
+
+	resp := &successOut{}
+	resp.Body.Success = true
+	return resp, nil
+}
+
+// Helper function to load a customer of the caller's organization
+func getCustomer(ctx context.Context, id int64) (db.Customer, error) {
+	customer, err := db.Repository.GetCustomer(ctx, db.GetCustomerParams{
+		ID:             id,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Customer{}, huma.Error404NotFound("customer not found")
+		}
+		log.SugaredLogger.Errorf("failed to get customer: %v", err)
+		return db.Customer{}, huma.Error500InternalServerError("failed to get customer", err)
+	}
+	return customer, nil
+}
+
+// Helper function to build customer profile response
+func buildCustomerResponse(ctx context.Context, customer db.Customer) (*customerOut, error) {
+	addresses, err := db.Repository.ListCustomerAddresses(ctx, customer.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list customer addresses: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get customer", err)
+	}
+
+	flags, err := db.Repository.ListCustomerFlags(ctx, customer.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list customer flags: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get customer", err)
+	}
+
+	var resp customerOut
+	resp.Body.customerInfo = newCustomerInfo(customer)
+	resp.Body.Addresses = make([]customerAddress, 0, len(addresses))
+	for _, a := range addresses {
+		resp.Body.Addresses = append(resp.Body.Addresses, customerAddress{
+			ID:         a.ID,
+			City:       a.City,
+			Street:     a.Street,
+			Building:   a.Building,
+			Apartment:  a.Apartment,
+			Entrance:   a.Entrance,
+			Floor:      a.Floor,
+			Doorphone:  stringValue(a.Doorphone),
+			Location:   point{Lat: a.Location.P.Y, Lng: a.Location.P.X},
+			UseCount:   a.UseCount,
+			LastUsedAt: a.LastUsedAt.Time,
+		})
+	}
+	resp.Body.Flags = newCustomerFlags(flags)
+
+	return &resp, nil
+}
+
+// Helper function to convert a customer to its response representation
+func newCustomerInfo(customer db.Customer) customerInfo {
+	return customerInfo{
+		ID:        customer.ID,
+		Phone:     customer.Phone,
+		Name:      customer.Name,
+		Notes:     customer.Notes,
+		CreatedAt: customer.CreatedAt.Time,
+		UpdatedAt: customer.UpdatedAt.Time,
+	}
+}
+
+// Helper function to convert customer flags to their response representation
+func newCustomerFlags(flags []db.CustomerFlag) []customerFlag {
+	result := make([]customerFlag, 0, len(flags))
+	for _, f := range flags {
+		result = append(result, customerFlag{
+			ID:        f.ID,
+			Flag:      f.Flag,
+			Comment:   f.Comment,
+			CreatedBy: f.CreatedBy,
+			CreatedAt: f.CreatedAt.Time,
+		})
+	}
+	return result
+}
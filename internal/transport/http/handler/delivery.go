@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/blob"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/delivery"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/orderstatus"
+	"smartDriver/pkg/rides"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// deliveryFiles are the files of a proof of delivery upload
+type deliveryFiles struct {
+	Photos    []huma.FormFile `form:"photos" contentType:"image/jpeg,image/png,image/webp,image/heic" doc:"Photos of the handed over order"`
+	Signature huma.FormFile   `form:"signature" contentType:"image/png,image/jpeg" doc:"Signature of the recipient"`
+}
+
+type confirmDeliveryIn struct {
+	ID      int64 `path:"id" doc:"Order ID"`
+	RawBody huma.MultipartFormFiles[deliveryFiles]
+}
+
+type deliveryAttachment struct {
+	ID          int64     `json:"id" doc:"Attachment ID"`
+	Kind        string    `json:"kind" enum:"photo,signature" doc:"Attachment kind"`
+	ContentType string    `json:"content_type" doc:"Media type of the file"`
+	Size        int64     `json:"size" doc:"File size in bytes"`
+	URL         string    `json:"url" doc:"Path to download the file"`
+	CreatedAt   time.Time `json:"created_at" doc:"Upload time"`
+}
+
+type deliveryConfirmationOut struct {
+	Body struct {
+		ID            int64                `json:"id" doc:"Confirmation ID"`
+		OrderID       int64                `json:"order_id" doc:"Order ID"`
+		RideID        *int64               `json:"ride_id" doc:"Ride the order was delivered by"`
+		ConfirmedBy   *int64               `json:"confirmed_by" doc:"User who confirmed the delivery"`
+		DeliveredAt   time.Time            `json:"delivered_at" doc:"Hand over time reported by the driver"`
+		Location      point                `json:"location" doc:"Driver coordinates at the hand over"`
+		Accuracy      *float64             `json:"accuracy,omitempty" doc:"Location accuracy in meters"`
+		RecipientName string               `json:"recipient_name" doc:"Who received the order"`
+		Comment       string               `json:"comment" doc:"Driver comment"`
+		Attachments   []deliveryAttachment `json:"attachments" doc:"Photos and signature"`
+		CreatedAt     time.Time            `json:"created_at" doc:"When the confirmation was received"`
+	}
+}
+
+type deliveryAttachmentIn struct {
+	ID           int64 `path:"id" doc:"Order ID"`
+	AttachmentID int64 `path:"attachment_id" doc:"Attachment ID"`
+}
+
+type deliveryAttachmentOut struct {
+	ContentType        string `header:"Content-Type"`
+	ContentLength      string `header:"Content-Length"`
+	ContentDisposition string `header:"Content-Disposition"`
+	ContentTypeOptions string `header:"X-Content-Type-Options"`
+	CacheControl       string `header:"Cache-Control"`
+	Body               []byte
+}
+
+// deliveryForm holds the text fields of a proof of delivery upload
+type deliveryForm struct {
+	lat, lng      float64
+	accuracy      *float64
+	deliveredAt   time.Time
+	recipientName string
+	comment       string
+}
+
+// ConfirmDelivery records that an order was handed over, with photos or a
+// signature, the hand over time and the driver's coordinates. Manual orders
+// become delivered, iiko orders keep following iiko.
+func ConfirmDelivery(ctx context.Context, in *confirmDeliveryIn) (*deliveryConfirmationOut, error) {
+	form, err := parseDeliveryForm(in.RawBody.Form.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	files := in.RawBody.Data()
+	if len(files.Photos) == 0 && !files.Signature.IsSet {
+		return nil, huma.Error400BadRequest("a photo or a signature is required")
+	}
+	uploads := make([]deliveryUpload, 0, len(files.Photos)+1)
+	for _, photo := range files.Photos {
+		uploads = append(uploads, deliveryUpload{kind: delivery.KindPhoto, file: photo})
+	}
+	if files.Signature.IsSet {
+		uploads = append(uploads, deliveryUpload{kind: delivery.KindSignature, file: files.Signature})
+	}
+	for _, upload := range uploads {
+		if upload.file.Size > delivery.MaxUploadSize {
+			return nil, huma.NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("%s %q exceeds %d bytes", upload.kind, upload.file.Filename, delivery.MaxUploadSize))
+		}
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to confirm delivery", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
+
+	order, err := qtx.GetOrder(ctx, db.GetOrderParams{
+		ID:             in.ID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("order not found")
+		}
+		log.SugaredLogger.Errorf("failed to get order: %v", err)
+		return nil, huma.Error500InternalServerError("failed to confirm delivery", err)
+	}
+
+	status := orderstatus.Status(order.Status)
+	if status == orderstatus.Cancelled || status == orderstatus.Failed {
+		return nil, huma.Error409Conflict(fmt.Sprintf("order is %s", status))
+	}
+
+	var rideID *int64
+	id, err := qtx.GetOrderRideID(ctx, order.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.SugaredLogger.Errorf("failed to get order ride: %v", err)
+		return nil, huma.Error500InternalServerError("failed to confirm delivery", err)
+	}
+	if err == nil {
+		rideID = &id
+	}
+
+	userID, _ := ctx.Value("user_id").(int64)
+	confirmation, err := qtx.CreateDeliveryConfirmation(ctx, db.CreateDeliveryConfirmationParams{
+		OrderID:       order.ID,
+		RideID:        rideID,
+		ConfirmedBy:   &userID,
+		DeliveredAt:   pgtype.Timestamp{Time: form.deliveredAt, Valid: true},
+		Lng:           form.lng,
+		Lat:           form.lat,
+		Accuracy:      form.accuracy,
+		RecipientName: form.recipientName,
+		Comment:       form.comment,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error409Conflict("delivery is already confirmed")
+		}
+		log.SugaredLogger.Errorf("failed to create delivery confirmation: %v", err)
+		return nil, huma.Error500InternalServerError("failed to confirm delivery", err)
+	}
+
+	// Stored files are removed again unless the confirmation is committed
+	var storedKeys []string
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		for _, key := range storedKeys {
+			if err := blob.Default.Delete(context.WithoutCancel(ctx), key); err != nil {
+				log.SugaredLogger.Errorf("failed to delete orphaned delivery attachment %s: %v", key, err)
+			}
+		}
+	}()
+
+	for _, upload := range uploads {
+		key := delivery.AttachmentKey(orgID, order.ID, upload.kind, upload.file.ContentType)
+		err := blob.Default.Put(ctx, key, upload.file.ContentType, upload.file, upload.file.Size)
+		upload.file.Close()
+		if err != nil {
+			log.SugaredLogger.Errorf("failed to store delivery attachment: %v", err)
+			return nil, huma.Error500InternalServerError("failed to store delivery attachment", err)
+		}
+		storedKeys = append(storedKeys, key)
+
+		if _, err := qtx.CreateDeliveryAttachment(ctx, db.CreateDeliveryAttachmentParams{
+			ConfirmationID: confirmation.ID,
+			Kind:           upload.kind,
+			StorageKey:     key,
+			ContentType:    upload.file.ContentType,
+			Size:           upload.file.Size,
+		}); err != nil {
+			log.SugaredLogger.Errorf("failed to create delivery attachment: %v", err)
+			return nil, huma.Error500InternalServerError("failed to confirm delivery", err)
+		}
+	}
+
+	var detachments []rides.Detachment
+	if order.Source == orderSourceManual && status != orderstatus.Delivered {
+		actor, err := requestActor(ctx, qtx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		detachments, err = changeOrderStatus(ctx, qtx, order, orderstatus.Delivered, actor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to confirm delivery", err)
+	}
+	committed = true
+
+	if err := rides.PublishDetachments(ctx, centrifugo.Default, orgID, detachments); err != nil {
+		log.SugaredLogger.Errorf("failed to publish ride detachments: %v", err)
+	}
+
+	return GetDeliveryConfirmation(ctx, &idPathIn{ID: order.ID})
+}
+
+// GetDeliveryConfirmation retrieves the proof of delivery of an order
+func GetDeliveryConfirmation(ctx context.Context, in *idPathIn) (*deliveryConfirmationOut, error) {
+	confirmation, err := db.Repository.GetDeliveryConfirmation(ctx, db.GetDeliveryConfirmationParams{
+		OrderID:        in.ID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("delivery is not confirmed")
+		}
+		log.SugaredLogger.Errorf("failed to get delivery confirmation: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get delivery confirmation", err)
+	}
+
+	attachments, err := db.Repository.ListDeliveryAttachments(ctx, confirmation.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list delivery attachments: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get delivery confirmation", err)
+	}
+
+	var resp deliveryConfirmationOut
+	resp.Body.ID = confirmation.ID
+	resp.Body.OrderID = confirmation.OrderID
+	resp.Body.RideID = confirmation.RideID
+	resp.Body.ConfirmedBy = confirmation.ConfirmedBy
+	resp.Body.DeliveredAt = confirmation.DeliveredAt.Time
+	resp.Body.Location = point{Lat: confirmation.Location.P.Y, Lng: confirmation.Location.P.X}
+	resp.Body.Accuracy = confirmation.Accuracy
+	resp.Body.RecipientName = confirmation.RecipientName
+	resp.Body.Comment = confirmation.Comment
+	resp.Body.CreatedAt = confirmation.CreatedAt.Time
+	resp.Body.Attachments = make([]deliveryAttachment, 0, len(attachments))
+	for _, a := range attachments {
+		resp.Body.Attachments = append(resp.Body.Attachments, deliveryAttachment{
+			ID:          a.ID,
+			Kind:        a.Kind,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+			URL:         fmt.Sprintf("/orders/%d/delivery-confirmation/attachments/%d", confirmation.OrderID, a.ID),
+			CreatedAt:   a.CreatedAt.Time,
+		})
+	}
+
+	return &resp, nil
+}
+
+// GetDeliveryAttachment downloads a photo or signature of a proof of delivery.
+// Files are uploaded by clients, so browsers must save them rather than
+// render them on the dispatcher origin.
+func GetDeliveryAttachment(ctx context.Context, in *deliveryAttachmentIn) (*deliveryAttachmentOut, error) {
+	attachment, err := db.Repository.GetDeliveryAttachment(ctx, db.GetDeliveryAttachmentParams{
+		ID:             in.AttachmentID,
+		OrderID:        in.ID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("attachment not found")
+		}
+		log.SugaredLogger.Errorf("failed to get delivery attachment: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get delivery attachment", err)
+	}
+
+	object, err := blob.Default.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, huma.Error404NotFound("attachment file is missing")
+		}
+		log.SugaredLogger.Errorf("failed to read delivery attachment: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get delivery attachment", err)
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to read delivery attachment: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get delivery attachment", err)
+	}
+
+	return &deliveryAttachmentOut{
+		ContentType:        attachment.ContentType,
+		ContentLength:      strconv.Itoa(len(data)),
+		ContentDisposition: fmt.Sprintf(`attachment; filename="%s"`, path.Base(attachment.StorageKey)),
+		ContentTypeOptions: "nosniff",
+		CacheControl:       "private, max-age=86400",
+		Body:               data,
+	}, nil
+}
+
+// deliveryUpload is a file waiting to be stored
+type deliveryUpload struct {
+	kind string
+	file huma.FormFile
+}
+
+// Helper function to parse the text fields of a proof of delivery upload
+func parseDeliveryForm(values map[string][]string) (deliveryForm, error) {
+	value := func(key string) string {
+		if v := values[key]; len(v) > 0 {
+			return strings.TrimSpace(v[0])
+		}
+		return ""
+	}
+
+	var form deliveryForm
+	var err error
+
+	form.lat, err = strconv.ParseFloat(value("lat"), 64)
+	if err != nil || form.lat < -90 || form.lat > 90 {
+		return form, huma.Error422UnprocessableEntity("lat must be a latitude between -90 and 90")
+	}
+	form.lng, err = strconv.ParseFloat(value("lng"), 64)
+	if err != nil || form.lng < -180 || form.lng > 180 {
+		return form, huma.Error422UnprocessableEntity("lng must be a longitude between -180 and 180")
+	}
+
+	if raw := value("accuracy"); raw != "" {
+		accuracy, err := strconv.ParseFloat(raw, 64)
+		if err != nil || accuracy < 0 {
+			return form, huma.Error422UnprocessableEntity("accuracy must be a non-negative number of meters")
+		}
+		form.accuracy = &accuracy
+	}
+
+	form.deliveredAt = time.Now().UTC()
+	if raw := value("delivered_at"); raw != "" {
+		deliveredAt, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return form, huma.Error422UnprocessableEntity("delivered_at must be an RFC 3339 time")
+		}
+		if deliveredAt.After(time.Now().Add(5 * time.Minute)) {
+			return form, huma.Error422UnprocessableEntity("delivered_at is in the future")
+		}
+		form.deliveredAt = deliveredAt.UTC()
+	}
+
+	form.recipientName = value("recipient_name")
+	form.comment = value("comment")
+	if len(form.recipientName) > 255 || len(form.comment) > 2000 {
+		return form, huma.Error422UnprocessableEntity("recipient_name or comment is too long")
+	}
+
+	return form, nil
+}
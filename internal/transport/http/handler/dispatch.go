@@ -0,0 +1,378 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/capacity"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/dispatch"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/route"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type dispatchSettingsBody struct {
+	AutoDispatch  bool  `json:"auto_dispatch" doc:"Create due rides automatically for idle drivers on duty"`
+	RideCapacity  int32 `json:"ride_capacity" minimum:"1" maximum:"50" doc:"Maximum number of orders in a ride"`
+	Radius        int32 `json:"radius" minimum:"100" maximum:"100000" doc:"Maximum distance in meters between an order and the closest order of the same ride"`
+	WindowMinutes int32 `json:"window_minutes" minimum:"0" maximum:"1440" doc:"Maximum difference in minutes between promised times within a ride, 0 disables the limit"`
+}
+
+type dispatchSettingsOut struct {
+	Body dispatchSettingsBody
+}
+
+type updateDispatchSettingsIn struct {
+	Body dispatchSettingsBody
+}
+
+type rideProposal struct {
+	OrderIDs          []int64     `json:"order_ids" doc:"Orders of the ride in visiting order, ready to be passed to ride creation"`
+	Orders            []orderInfo `json:"orders" doc:"Orders of the ride in visiting order"`
+	Stops             []rideStop  `json:"stops" doc:"Visiting sequence with distance and arrival estimates"`
+	Distance          float64     `json:"distance" doc:"Estimated road distance of the route, meters"`
+	Slack             int64       `json:"slack" doc:"Seconds the ride may wait before an order would be late"`
+	Full              bool        `json:"full" doc:"The ride reached the capacity"`
+	Due               bool        `json:"due" doc:"The ride should leave now"`
+	SuggestedDriverID *int64      `json:"suggested_driver_id" doc:"Idle driver on duty whose vehicle can carry the ride, preferring drivers of the branch"`
+}
+
+type rideProposalsOut struct {
+	Body struct {
+		Proposals []rideProposal `json:"proposals" doc:"Proposed rides, most urgent first"`
+	}
+}
+
+type acceptRideProposalIn struct {
+	ID   int64 `path:"id" doc:"Branch ID"`
+	Body struct {
+		OrderIDs []int64 `json:"order_ids" minItems:"1" doc:"Orders of the proposal"`
+		DriverID *int64  `json:"driver_id,omitempty" doc:"Driver of the ride"`
+	}
+}
+
+// GetDispatchSettings retrieves the automatic dispatch settings of the organization
+func GetDispatchSettings(ctx context.Context, _ *struct{}) (*dispatchSettingsOut, error) {
+	settings, err := db.Repository.GetDispatchSettings(ctx, organizationID(ctx))
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get dispatch settings: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get dispatch settings", err)
+	}
+
+	return &dispatchSettingsOut{Body: newDispatchSettingsBody(db.UpdateDispatchSettingsRow(settings))}, nil
+}
+
+// UpdateDispatchSettings updates the automatic dispatch settings of the organization
+func UpdateDispatchSettings(ctx context.Context, in *updateDispatchSettingsIn) (*dispatchSettingsOut, error) {
+	settings, err := db.Repository.UpdateDispatchSettings(ctx, db.UpdateDispatchSettingsParams{
+		ID:                    organizationID(ctx),
+		AutoDispatch:          in.Body.AutoDispatch,
+		RideCapacity:          in.Body.RideCapacity,
+		DispatchRadius:        in.Body.Radius,
+		DispatchWindowMinutes: in.Body.WindowMinutes,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to update dispatch settings: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update dispatch settings", err)
+	}
+
+	return &dispatchSettingsOut{Body: newDispatchSettingsBody(settings)}, nil
+}
+
+// GetRideProposals groups ready orders of a branch waiting for a ride into
+// proposed rides
+func GetRideProposals(ctx context.Context, in *idPathIn) (*rideProposalsOut, error) {
+	orgID := organizationID(ctx)
+
+	branch, err := db.Repository.GetBranch(ctx, db.GetBranchParams{
+		ID:             in.ID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("branch not found")
+		}
+		log.SugaredLogger.Errorf("failed to get branch: %v", err)
+		return nil, huma.Error500InternalServerError("failed to propose rides", err)
+	}
+
+	proposals, orders, err := proposeRides(ctx, db.Repository, branch, capacity.Limits{}, route.DefaultOptions)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to propose rides: %v", err)
+		return nil, huma.Error500InternalServerError("failed to propose rides", err)
+	}
+
+	idle, err := db.Repository.ListIdleDrivers(ctx, db.ListIdleDriversParams{
+		OrganizationID: orgID,
+		BranchID:       &branch.ID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list idle drivers: %v", err)
+		return nil, huma.Error500InternalServerError("failed to propose rides", err)
+	}
+
+	limits := make(map[string]capacity.Limits)
+	for _, driver := range idle {
+		if _, ok := limits[driver.VehicleType]; ok {
+			continue
+		}
+		limits[driver.VehicleType], err = getVehicleLimits(ctx, db.Repository, orgID, driver.VehicleType)
+		if err != nil {
+			log.SugaredLogger.Errorf("failed to get vehicle limits: %v", err)
+			return nil, huma.Error500InternalServerError("failed to propose rides", err)
+		}
+	}
+
+	suggested := make(map[int64]bool, len(idle))
+	var resp rideProposalsOut
+	resp.Body.Proposals = make([]rideProposal, 0, len(proposals))
+	for _, proposal := range proposals {
+		item := rideProposal{
+			OrderIDs: proposal.OrderIDs,
+			Distance: proposal.Plan.Distance,
+			Slack:    int64(proposal.Slack.Seconds()),
+			Full:     proposal.Full,
+			Due:      proposal.Due(0),
+		}
+		// Drivers are suggested to the most urgent rides first, if the vehicle
+		// can carry the ride
+		for _, driver := range idle {
+			if suggested[driver.ID] || limits[driver.VehicleType].Check(len(proposal.OrderIDs), proposal.Size, proposal.Plan.Distance) != nil {
+				continue
+			}
+			suggested[driver.ID] = true
+			item.SuggestedDriverID = &driver.ID
+			break
+		}
+		for n, leg := range proposal.Plan.Legs {
+			order := orders[leg.StopID]
+			arrival := leg.Arrival
+			item.Orders = append(item.Orders, newOrderInfo(order))
+			item.Stops = append(item.Stops, rideStop{
+				Sequence:       int32(n + 1),
+				OrderID:        order.ID,
+				Distance:       leg.Distance,
+				PlannedArrival: &arrival,
+				Late:           leg.Late > 0,
+			})
+		}
+		resp.Body.Proposals = append(resp.Body.Proposals, item)
+	}
+
+	return &resp, nil
+}
+
+// AcceptRideProposal creates a ride from a proposal of the branch
+func AcceptRideProposal(ctx context.Context, in *acceptRideProposalIn) (*rideOut, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to accept ride proposal", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
+
+	// Another dispatcher may have taken the orders since the proposal
+	bound, err := qtx.CountOrdersOnActiveRides(ctx, in.Body.OrderIDs)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to count bound orders: %v", err)
+		return nil, huma.Error500InternalServerError("failed to accept ride proposal", err)
+	}
+	if bound > 0 {
+		return nil, huma.Error409Conflict("some orders of the proposal are already on a ride")
+	}
+
+	ride, err := createRide(ctx, qtx, orgID, in.ID, in.Body.DriverID, in.Body.OrderIDs, dispatcherActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to accept ride proposal", err)
+	}
+
+	return buildRideResponse(ctx, *db.Repository, orgID, ride)
+}
+
+// AutoDispatch creates the due rides of organizations with automatic
+// dispatch enabled. Each ride gets an idle driver on duty whose vehicle can
+// carry it, rides wait while no such driver is available.
+func AutoDispatch(ctx context.Context, lead time.Duration) error {
+	branches, err := db.Repository.ListAutoDispatchBranches(ctx)
+	if err != nil {
+		return fmt.Errorf("list branches: %w", err)
+	}
+
+	for _, branch := range branches {
+		if err := autoDispatchBranch(ctx, branch, lead); err != nil {
+			log.SugaredLogger.Errorf("failed to dispatch rides of branch %d: %v", branch.ID, err)
+		}
+	}
+	return nil
+}
+
+// Helper function to create the due rides of a branch. Rides are proposed
+// for the vehicle of each idle driver in turn and the driver gets the most
+// urgent due one, until no idle driver has a due ride.
+func autoDispatchBranch(ctx context.Context, branch db.Branch, lead time.Duration) error {
+	for {
+		idle, err := db.Repository.ListIdleDrivers(ctx, db.ListIdleDriversParams{
+			OrganizationID: branch.OrganizationID,
+			BranchID:       &branch.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("list idle drivers: %w", err)
+		}
+
+		dispatched := false
+		for _, driver := range idle {
+			ok, err := autoDispatchDriver(ctx, branch, driver, lead)
+			if err != nil {
+				return err
+			}
+			if ok {
+				dispatched = true
+				break
+			}
+		}
+		if !dispatched {
+			return nil
+		}
+	}
+}
+
+// Helper function to create the most urgent due ride of a branch the vehicle
+// of a driver can carry. It reports whether a ride was created.
+func autoDispatchDriver(ctx context.Context, branch db.Branch, driver db.Driver, lead time.Duration) (bool, error) {
+	limits, err := getVehicleLimits(ctx, db.Repository, branch.OrganizationID, driver.VehicleType)
+	if err != nil {
+		return false, err
+	}
+	profile, err := getVehicleProfile(ctx, db.Repository, branch.OrganizationID, driver.VehicleType)
+	if err != nil {
+		return false, err
+	}
+
+	proposals, _, err := proposeRides(ctx, db.Repository, branch, limits, profile.Options())
+	if err != nil {
+		return false, err
+	}
+
+	for _, proposal := range proposals {
+		if !proposal.Due(lead) {
+			continue
+		}
+
+		ride, err := createAutoRide(ctx, branch, driver.ID, proposal.OrderIDs)
+		if err != nil {
+			return false, err
+		}
+
+		event := dispatch.Event{
+			Type:     dispatch.EventRideAutoCreated,
+			RideID:   ride.ID,
+			BranchID: branch.ID,
+			DriverID: ride.DriverID,
+			OrderIDs: proposal.OrderIDs,
+		}
+		for _, channel := range []string{centrifugo.OrganizationChannel(branch.OrganizationID), centrifugo.RideChannel(ride.ID)} {
+			if err := centrifugo.Default.Publish(ctx, channel, event); err != nil {
+				log.SugaredLogger.Errorf("failed to publish %s for ride %d: %v", event.Type, ride.ID, err)
+			}
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// Helper function to create a ride for a proposal in its own transaction
+func createAutoRide(ctx context.Context, branch db.Branch, driverID int64, orderIDs []int64) (db.Ride, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return db.Ride{}, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+
+	ride, err := createRide(ctx, qtx, branch.OrganizationID, branch.ID, &driverID, orderIDs, systemActor)
+	if err != nil {
+		return db.Ride{}, fmt.Errorf("create ride: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.Ride{}, fmt.Errorf("commit transaction: %w", err)
+	}
+	return ride, nil
+}
+
+// Helper function to propose rides for the orders of a branch waiting for a
+// ride that fit the vehicle limits. The orders are returned by ID.
+func proposeRides(ctx context.Context, q *db.Queries, branch db.Branch, vehicle capacity.Limits, opts route.Options) ([]dispatch.Proposal, map[int64]db.Order, error) {
+	settings, err := q.GetDispatchSettings(ctx, branch.OrganizationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get dispatch settings: %w", err)
+	}
+
+	orders, err := q.ListDispatchableOrders(ctx, db.ListDispatchableOrdersParams{
+		OrganizationID: branch.OrganizationID,
+		BranchID:       &branch.ID,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("list dispatchable orders: %w", err)
+	}
+
+	orderIDs := make([]int64, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	sizes, err := orderSizes(ctx, q, branch.OrganizationID, orderIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[int64]db.Order, len(orders))
+	candidates := make([]dispatch.Order, 0, len(orders))
+	for _, order := range orders {
+		byID[order.ID] = order
+		candidate := dispatch.Order{
+			ID:        order.ID,
+			Location:  geo.PointFromPg(order.Location),
+			CreatedAt: order.CreatedAt.Time,
+			Size:      sizes[order.ID],
+		}
+		if order.PromisedAt.Valid {
+			candidate.PromisedAt = order.PromisedAt.Time
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	proposals := dispatch.Propose(geo.PointFromPg(branch.Location), candidates, time.Now().UTC(), dispatch.Settings{
+		Capacity:   int(settings.RideCapacity),
+		Radius:     float64(settings.DispatchRadius),
+		TimeWindow: time.Duration(settings.DispatchWindowMinutes) * time.Minute,
+		Vehicle:    vehicle,
+	}, opts)
+
+	return proposals, byID, nil
+}
+
+// Helper function to convert dispatch settings to their response representation
+func newDispatchSettingsBody(settings db.UpdateDispatchSettingsRow) dispatchSettingsBody {
+	return dispatchSettingsBody{
+		AutoDispatch:  settings.AutoDispatch,
+		RideCapacity:  settings.RideCapacity,
+		Radius:        settings.DispatchRadius,
+		WindowMinutes: settings.DispatchWindowMinutes,
+	}
+}
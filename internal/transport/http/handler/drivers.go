@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/drivers"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/phone"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+// driverBody holds the editable fields of a driver profile
+type driverBody struct {
+	Phone        string `json:"phone" maxLength:"50" doc:"Driver phone"`
+	VehicleType  string `json:"vehicle_type" enum:"foot,bicycle,scooter,car" default:"car" doc:"How the driver delivers orders"`
+	HomeBranchID *int64 `json:"home_branch_id,omitempty" doc:"Branch the driver usually works for"`
+	Status       string `json:"status" enum:"on_duty,off_duty,inactive" default:"off_duty" doc:"Only drivers on duty may be assigned rides"`
+}
+
+type createDriverIn struct {
+	Body struct {
+		UserID int64 `json:"user_id" doc:"User the driver signs in as"`
+		driverBody
+	}
+}
+
+type updateDriverIn struct {
+	ID   int64 `path:"id" doc:"Driver ID"`
+	Body driverBody
+}
+
+type listDriversIn struct {
+	Status   string `query:"status" enum:"on_duty,off_duty,inactive" doc:"Filter by driver status"`
+	BranchID int64  `query:"branch_id" doc:"Filter by home branch"`
+}
+
+type driverInfo struct {
+	ID           int64     `json:"id" doc:"Driver ID"`
+	UserID       int64     `json:"user_id" doc:"User the driver signs in as"`
+	Name         string    `json:"name" doc:"Driver name"`
+	Phone        string    `json:"phone" doc:"Normalized driver phone"`
+	VehicleType  string    `json:"vehicle_type" enum:"foot,bicycle,scooter,car" doc:"How the driver delivers orders"`
+	HomeBranchID *int64    `json:"home_branch_id" doc:"Branch the driver usually works for"`
+	Status       string    `json:"status" enum:"on_duty,off_duty,inactive" doc:"Driver status"`
+	ActiveRideID *int64    `json:"active_ride_id" doc:"Ride the driver is driving now"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type driverOut struct {
+	Body driverInfo
+}
+
+type listDriversOut struct {
+	Body struct {
+		Drivers []driverInfo `json:"drivers" doc:"List of drivers"`
+	}
+}
+
+// CreateDriver creates a driver profile for a user of the organization
+func CreateDriver(ctx context.Context, in *createDriverIn) (*driverOut, error) {
+	orgID := organizationID(ctx)
+
+	if err := checkDriverBranch(ctx, orgID, in.Body.HomeBranchID); err != nil {
+		return nil, err
+	}
+
+	driver, err := db.Repository.CreateDriver(ctx, db.CreateDriverParams{
+		Phone:          phone.Normalize(in.Body.Phone),
+		VehicleType:    in.Body.VehicleType,
+		HomeBranchID:   in.Body.HomeBranchID,
+		Status:         in.Body.Status,
+		UserID:         in.Body.UserID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error400BadRequest("user not found")
+		}
+		if isUniqueViolation(err) {
+			return nil, huma.Error409Conflict("user is already a driver")
+		}
+		log.SugaredLogger.Errorf("failed to create driver: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create driver", err)
+	}
+
+	return GetDriver(ctx, &idPathIn{ID: driver.ID})
+}
+
+// ListDrivers lists drivers of the organization
+func ListDrivers(ctx context.Context, in *listDriversIn) (*listDriversOut, error) {
+	params := db.ListDriversParams{OrganizationID: organizationID(ctx)}
+	if in.Status != "" {
+		params.Status = &in.Status
+	}
+	if in.BranchID != 0 {
+		params.BranchID = &in.BranchID
+	}
+
+	rows, err := db.Repository.ListDrivers(ctx, params)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list drivers: %v", err)
+		return nil, huma.Error500InternalServerError("failed to list drivers", err)
+	}
+
+	var resp listDriversOut
+	resp.Body.Drivers = make([]driverInfo, 0, len(rows))
+	for _, row := range rows {
+		resp.Body.Drivers = append(resp.Body.Drivers, newDriverInfo(db.GetDriverRow(row)))
+	}
+
+	return &resp, nil
+}
+
+// GetDriver retrieves a driver profile
+func GetDriver(ctx context.Context, in *idPathIn) (*driverOut, error) {
+	driver, err := db.Repository.GetDriver(ctx, db.GetDriverParams{
+		ID:             in.ID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("driver not found")
+		}
+		log.SugaredLogger.Errorf("failed to get driver: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get driver", err)
+	}
+
+	return &driverOut{Body: newDriverInfo(driver)}, nil
+}
+
+// UpdateDriver updates a driver profile. Drivers with an active ride stay on
+// duty until the ride is completed.
+func UpdateDriver(ctx context.Context, in *updateDriverIn) (*driverOut, error) {
+	orgID := organizationID(ctx)
+
+	if err := checkDriverBranch(ctx, orgID, in.Body.HomeBranchID); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update driver", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+
+	driver, err := qtx.GetDriver(ctx, db.GetDriverParams{
+		ID:             in.ID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("driver not found")
+		}
+		log.SugaredLogger.Errorf("failed to get driver: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update driver", err)
+	}
+
+	if driver.ActiveRideID != nil && !drivers.CanDrive(in.Body.Status) {
+		return nil, huma.Error409Conflict(fmt.Sprintf("driver has active ride %d", *driver.ActiveRideID))
+	}
+
+	if _, err := qtx.UpdateDriver(ctx, db.UpdateDriverParams{
+		ID:             driver.ID,
+		OrganizationID: orgID,
+		Phone:          phone.Normalize(in.Body.Phone),
+		VehicleType:    in.Body.VehicleType,
+		HomeBranchID:   in.Body.HomeBranchID,
+		Status:         in.Body.Status,
+	}); err != nil {
+		log.SugaredLogger.Errorf("failed to update driver: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update driver", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update driver", err)
+	}
+
+	return GetDriver(ctx, &idPathIn{ID: driver.ID})
+}
+
+// Helper function to assign a driver to a ride. The driver must be on duty
+// and may not drive another active ride.
+func assignRideDriver(ctx context.Context, q *db.Queries, orgID, rideID int64, driverID *int64) error {
+	if driverID != nil {
+		driver, err := q.GetDriver(ctx, db.GetDriverParams{
+			ID:             *driverID,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return huma.Error400BadRequest("driver not found")
+			}
+			log.SugaredLogger.Errorf("failed to get driver: %v", err)
+			return huma.Error500InternalServerError("failed to assign driver", err)
+		}
+		if !drivers.CanDrive(driver.Status) {
+			return huma.Error409Conflict(fmt.Sprintf("driver is %s", driver.Status))
+		}
+		if driver.ActiveRideID != nil && *driver.ActiveRideID != rideID {
+			return huma.Error409Conflict(fmt.Sprintf("driver already has active ride %d", *driver.ActiveRideID))
+		}
+	}
+
+	if err := q.SetRideDriver(ctx, db.SetRideDriverParams{
+		ID:       rideID,
+		DriverID: driverID,
+	}); err != nil {
+		// Another request assigned the driver concurrently
+		if isUniqueViolation(err) {
+			return huma.Error409Conflict("driver already has an active ride")
+		}
+		log.SugaredLogger.Errorf("failed to set ride driver: %v", err)
+		return huma.Error500InternalServerError("failed to assign driver", err)
+	}
+
+	return nil
+}
+
+// Helper function to check the home branch of a driver belongs to the organization
+func checkDriverBranch(ctx context.Context, orgID int64, branchID *int64) error {
+	if branchID == nil {
+		return nil
+	}
+	if _, err := db.Repository.GetBranch(ctx, db.GetBranchParams{
+		ID:             *branchID,
+		OrganizationID: orgID,
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return huma.Error400BadRequest("branch not found")
+		}
+		log.SugaredLogger.Errorf("failed to get branch: %v", err)
+		return huma.Error500InternalServerError("failed to get branch", err)
+	}
+	return nil
+}
+
+// Helper function to convert a driver to its response representation
+func newDriverInfo(driver db.GetDriverRow) driverInfo {
+	return driverInfo{
+		ID:           driver.ID,
+		UserID:       driver.UserID,
+		Name:         formatPersonName(driver.Name, driver.Surname),
+		Phone:        driver.Phone,
+		VehicleType:  driver.VehicleType,
+		HomeBranchID: driver.HomeBranchID,
+		Status:       driver.Status,
+		ActiveRideID: driver.ActiveRideID,
+		CreatedAt:    driver.CreatedAt.Time,
+		UpdatedAt:    driver.UpdatedAt.Time,
+	}
+}
+
+// Helper function to detect unique constraint violations
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/drivers"
+	"smartDriver/pkg/eta"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/rides"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type vehicleProfileBody struct {
+	Speed       float64 `json:"speed" minimum:"1" maximum:"150" doc:"Average speed in kilometers per hour"`
+	ServiceTime int32   `json:"service_time" minimum:"0" maximum:"3600" doc:"Seconds spent at each stop handing over the order"`
+}
+
+type vehicleProfile struct {
+	VehicleType string `json:"vehicle_type" enum:"foot,bicycle,scooter,car" doc:"Vehicle type"`
+	vehicleProfileBody
+	Custom bool `json:"custom" doc:"The organization configured the profile, otherwise defaults apply"`
+}
+
+type vehicleProfilesOut struct {
+	Body struct {
+		Profiles []vehicleProfile `json:"profiles" doc:"Profiles of every vehicle type"`
+	}
+}
+
+type vehicleProfileOut struct {
+	Body vehicleProfile
+}
+
+type updateVehicleProfileIn struct {
+	VehicleType string `path:"vehicle_type" enum:"foot,bicycle,scooter,car" doc:"Vehicle type"`
+	Body        vehicleProfileBody
+}
+
+// ListVehicleProfiles lists the speed and handover time used to estimate
+// arrivals for each vehicle type
+func ListVehicleProfiles(ctx context.Context, _ *struct{}) (*vehicleProfilesOut, error) {
+	custom, err := db.Repository.ListVehicleProfiles(ctx, organizationID(ctx))
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list vehicle profiles: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get vehicle profiles", err)
+	}
+
+	configured := make(map[string]db.VehicleProfile, len(custom))
+	for _, profile := range custom {
+		configured[profile.VehicleType] = profile
+	}
+
+	var resp vehicleProfilesOut
+	resp.Body.Profiles = make([]vehicleProfile, 0, len(eta.VehicleTypes))
+	for _, vehicleType := range eta.VehicleTypes {
+		if profile, ok := configured[vehicleType]; ok {
+			resp.Body.Profiles = append(resp.Body.Profiles, newVehicleProfile(profile))
+			continue
+		}
+		defaults := eta.DefaultProfile(vehicleType)
+		resp.Body.Profiles = append(resp.Body.Profiles, vehicleProfile{
+			VehicleType: vehicleType,
+			vehicleProfileBody: vehicleProfileBody{
+				Speed:       defaults.Speed,
+				ServiceTime: int32(defaults.ServiceTime / time.Second),
+			},
+		})
+	}
+
+	return &resp, nil
+}
+
+// UpdateVehicleProfile sets the speed and handover time used to estimate
+// arrivals for a vehicle type
+func UpdateVehicleProfile(ctx context.Context, in *updateVehicleProfileIn) (*vehicleProfileOut, error) {
+	profile, err := db.Repository.UpsertVehicleProfile(ctx, db.UpsertVehicleProfileParams{
+		OrganizationID: organizationID(ctx),
+		VehicleType:    in.VehicleType,
+		Speed:          in.Body.Speed,
+		ServiceTime:    in.Body.ServiceTime,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to update vehicle profile: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update vehicle profile", err)
+	}
+
+	return &vehicleProfileOut{Body: newVehicleProfile(profile)}, nil
+}
+
+// Helper function to recalculate the arrival estimates of the active ride of
+// a driver after a position update and publish them
+func updateDriverETA(ctx context.Context, orgID, driverID int64) error {
+	ride, found, err := driverActiveRide(ctx, orgID, driverID)
+	if err != nil {
+		return err
+	}
+	if !found || !rides.Status(ride.Status).IsOnRoad() {
+		return nil
+	}
+
+	arrivals, err := refreshRideETA(ctx, db.Repository, orgID, ride)
+	if err != nil {
+		return err
+	}
+
+	event := eta.Event{Type: eta.EventETAUpdated, RideID: ride.ID, Arrivals: arrivals}
+	for _, channel := range []string{centrifugo.OrganizationChannel(orgID), centrifugo.RideChannel(ride.ID)} {
+		if err := centrifugo.Default.Publish(ctx, channel, event); err != nil {
+			log.SugaredLogger.Errorf("failed to publish ride eta: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Helper function to estimate when the driver reaches the unfinished stops of
+// a ride and store the estimates. A ride on the road is estimated from where
+// the driver is, other rides from the branch.
+func refreshRideETA(ctx context.Context, q *db.Queries, orgID int64, ride db.Ride) ([]eta.Arrival, error) {
+	profile, err := rideProfile(ctx, q, orgID, ride)
+	if err != nil {
+		return nil, err
+	}
+
+	branch, err := q.GetBranch(ctx, db.GetBranchParams{
+		ID:             ride.BranchID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ride branch: %w", err)
+	}
+
+	rows, err := q.ListRideStops(ctx, db.ListRideStopsParams{
+		RideID:         ride.ID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ride stops: %w", err)
+	}
+
+	stops := make([]eta.Stop, 0, len(rows))
+	for _, row := range rows {
+		if rides.StopStatus(row.StopStatus).IsFinished() {
+			continue
+		}
+		stop := eta.Stop{OrderID: row.Order.ID, Location: geo.PointFromPg(row.Order.Location)}
+		if row.ArrivedAt.Valid {
+			stop.ArrivedAt = row.ArrivedAt.Time
+		}
+		stops = append(stops, stop)
+	}
+
+	origin, err := rideOrigin(ctx, ride, geo.PointFromPg(branch.Location), rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest driver position: %w", err)
+	}
+
+	arrivals := eta.Estimate(origin, time.Now().UTC(), stops, profile.Options())
+	for _, arrival := range arrivals {
+		if err := q.UpdateRideStopETA(ctx, db.UpdateRideStopETAParams{
+			Eta:     pgtype.Timestamp{Time: arrival.ETA, Valid: true},
+			RideID:  ride.ID,
+			OrderID: arrival.OrderID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update ride stop eta: %w", err)
+		}
+	}
+
+	return arrivals, nil
+}
+
+// Helper function to get the profile of the vehicle of the ride driver. Rides
+// without a driver are planned as if driven by car.
+func rideProfile(ctx context.Context, q *db.Queries, orgID int64, ride db.Ride) (eta.Profile, error) {
+	vehicleType := drivers.VehicleCar
+	if ride.DriverID != nil {
+		driver, err := q.GetDriver(ctx, db.GetDriverParams{
+			ID:             *ride.DriverID,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			return eta.Profile{}, fmt.Errorf("failed to get ride driver: %w", err)
+		}
+		vehicleType = driver.VehicleType
+	}
+
+	return getVehicleProfile(ctx, q, orgID, vehicleType)
+}
+
+// Helper function to get the profile of a vehicle type, falling back to the
+// defaults
+func getVehicleProfile(ctx context.Context, q *db.Queries, orgID int64, vehicleType string) (eta.Profile, error) {
+	profile, err := q.GetVehicleProfile(ctx, db.GetVehicleProfileParams{
+		OrganizationID: orgID,
+		VehicleType:    vehicleType,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return eta.DefaultProfile(vehicleType), nil
+		}
+		return eta.Profile{}, fmt.Errorf("failed to get vehicle profile: %w", err)
+	}
+
+	return eta.Profile{
+		Speed:       profile.Speed,
+		ServiceTime: time.Duration(profile.ServiceTime) * time.Second,
+	}, nil
+}
+
+// Helper function to convert a stored vehicle profile to its response
+// representation
+func newVehicleProfile(profile db.VehicleProfile) vehicleProfile {
+	return vehicleProfile{
+		VehicleType: profile.VehicleType,
+		vehicleProfileBody: vehicleProfileBody{
+			Speed:       profile.Speed,
+			ServiceTime: profile.ServiceTime,
+		},
+		Custom: true,
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"smartDriver/pkg/geocode"
+	"smartDriver/pkg/log"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type suggestAddressesIn struct {
+	Query string `query:"q" minLength:"3" doc:"Part of the address"`
+	Limit int    `query:"limit" default:"5" minimum:"1" maximum:"10" doc:"Maximum number of suggestions"`
+}
+
+type addressSuggestion struct {
+	Address  string `json:"address" doc:"Full address"`
+	Location point  `json:"location" doc:"Coordinates of the address"`
+}
+
+type suggestAddressesOut struct {
+	Body struct {
+		Suggestions []addressSuggestion `json:"suggestions" doc:"Matching addresses, best match first"`
+	}
+}
+
+// SuggestAddresses autocompletes addresses for manual orders
+func SuggestAddresses(ctx context.Context, in *suggestAddressesIn) (*suggestAddressesOut, error) {
+	suggestions, err := geocode.Default.Suggest(ctx, in.Query, in.Limit)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to suggest addresses: %v", err)
+		return nil, huma.Error502BadGateway("failed to suggest addresses", err)
+	}
+
+	var resp suggestAddressesOut
+	resp.Body.Suggestions = make([]addressSuggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		resp.Body.Suggestions = append(resp.Body.Suggestions, addressSuggestion{
+			Address:  s.Address,
+			Location: point{Lat: s.Location.Lat, Lng: s.Location.Lng},
+		})
+	}
+	return &resp, nil
+}
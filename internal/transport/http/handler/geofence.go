@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/geofence"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/rides"
+	"smartDriver/pkg/tracking"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type geofenceSettingsBody struct {
+	StopRadius   int32 `json:"stop_radius" minimum:"0" maximum:"1000" doc:"Radius in meters around an order location that marks the driver arrived, 0 disables arrival detection"`
+	BranchRadius int32 `json:"branch_radius" minimum:"0" maximum:"5000" doc:"Radius in meters around the branch that departs and returns rides, 0 disables the detection"`
+}
+
+type geofenceSettingsOut struct {
+	Body geofenceSettingsBody
+}
+
+type updateGeofenceSettingsIn struct {
+	Body geofenceSettingsBody
+}
+
+type geofenceEvent struct {
+	Kind       string    `json:"kind" enum:"branch,stop" doc:"What the fence surrounds"`
+	OrderID    *int64    `json:"order_id,omitempty" doc:"Order of the stop"`
+	Event      string    `json:"event" enum:"enter,exit" doc:"Whether the driver entered or left the fence"`
+	Location   point     `json:"location" doc:"Driver coordinates"`
+	OccurredAt time.Time `json:"occurred_at" doc:"When the position was recorded"`
+}
+
+type rideGeofenceEventsOut struct {
+	Body struct {
+		Events []geofenceEvent `json:"events" doc:"Fence crossings in time order"`
+	}
+}
+
+// fenceCrossing is a transition together with the position that caused it
+type fenceCrossing struct {
+	geofence.Transition
+	position tracking.Position
+}
+
+// GetGeofenceSettings retrieves the arrival and return detection radii of the organization
+func GetGeofenceSettings(ctx context.Context, _ *struct{}) (*geofenceSettingsOut, error) {
+	settings, err := db.Repository.GetGeofenceSettings(ctx, organizationID(ctx))
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get geofence settings: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get geofence settings", err)
+	}
+
+	return &geofenceSettingsOut{Body: geofenceSettingsBody{
+		StopRadius:   settings.StopRadius,
+		BranchRadius: settings.BranchRadius,
+	}}, nil
+}
+
+// UpdateGeofenceSettings updates the arrival and return detection radii of the organization
+func UpdateGeofenceSettings(ctx context.Context, in *updateGeofenceSettingsIn) (*geofenceSettingsOut, error) {
+	if !isDispatcher(ctx) {
+		return nil, huma.Error403Forbidden("only admins and dispatchers can change geofence settings")
+	}
+
+	settings, err := db.Repository.UpdateGeofenceSettings(ctx, db.UpdateGeofenceSettingsParams{
+		ID:           organizationID(ctx),
+		StopRadius:   in.Body.StopRadius,
+		BranchRadius: in.Body.BranchRadius,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to update geofence settings: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update geofence settings", err)
+	}
+
+	return &geofenceSettingsOut{Body: geofenceSettingsBody{
+		StopRadius:   settings.StopRadius,
+		BranchRadius: settings.BranchRadius,
+	}}, nil
+}
+
+// GetRideGeofenceEvents lists the branch and stop fences the driver of a ride
+// entered or left
+func GetRideGeofenceEvents(ctx context.Context, in *idPathIn) (*rideGeofenceEventsOut, error) {
+	ride, err := getOrganizationRide(ctx, db.Repository, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := db.Repository.ListRideGeofenceEvents(ctx, ride.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list ride geofence events: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get ride geofence events", err)
+	}
+
+	var resp rideGeofenceEventsOut
+	resp.Body.Events = make([]geofenceEvent, 0, len(events))
+	for _, e := range events {
+		resp.Body.Events = append(resp.Body.Events, geofenceEvent{
+			Kind:       e.Kind,
+			OrderID:    e.OrderID,
+			Event:      e.Event,
+			Location:   point{Lat: e.Location.P.Y, Lng: e.Location.P.X},
+			OccurredAt: e.OccurredAt.Time,
+		})
+	}
+
+	return &resp, nil
+}
+
+// Helper function to detect the driver entering and leaving the branch and
+// the stops of the active ride. Leaving the branch departs a planned ride,
+// entering a stop marks the driver arrived and entering the branch returns a
+// ride whose stops are all finished. Positions must be in recording order.
+func applyGeofences(ctx context.Context, orgID, driverID int64, positions []tracking.Position) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	ride, found, err := driverActiveRide(ctx, orgID, driverID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		// Forget the fences of the previous ride
+		for _, p := range positions {
+			geofence.Default.Update(driverID, nil, p.Location, p.Accuracy)
+		}
+		return nil
+	}
+
+	fences, err := rideFences(ctx, orgID, ride)
+	if err != nil {
+		return err
+	}
+
+	var crossings []fenceCrossing
+	for _, p := range positions {
+		for _, t := range geofence.Default.Update(driverID, fences, p.Location, p.Accuracy) {
+			crossings = append(crossings, fenceCrossing{Transition: t, position: p})
+		}
+	}
+	if len(crossings) == 0 {
+		return nil
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+
+	ride, err = lockRide(ctx, qtx, ride.ID, "")
+	if err != nil {
+		return err
+	}
+
+	var changes []rides.StatusChange
+	for _, c := range crossings {
+		params := db.CreateRideGeofenceEventParams{
+			RideID:     ride.ID,
+			Kind:       string(c.Fence.Kind),
+			Event:      string(c.Event),
+			Lng:        c.position.Location.Lng,
+			Lat:        c.position.Location.Lat,
+			OccurredAt: pgtype.Timestamp{Time: c.position.RecordedAt, Valid: true},
+		}
+		if c.Fence.Kind == geofence.KindStop {
+			params.OrderID = &c.Fence.OrderID
+		}
+		if _, err := qtx.CreateRideGeofenceEvent(ctx, params); err != nil {
+			return fmt.Errorf("failed to create geofence event: %w", err)
+		}
+
+		at := c.position.RecordedAt
+		status := rides.Status(ride.Status)
+		var (
+			moved  db.Ride
+			change []rides.StatusChange
+		)
+		switch {
+		case c.Fence.Kind == geofence.KindBranch && c.Event == geofence.EventExit && status == rides.StatusPlanned:
+			var departure rides.StatusChange
+			moved, departure, err = departRide(ctx, qtx, orgID, ride, at, systemActor)
+			change = []rides.StatusChange{departure}
+		case c.Fence.Kind == geofence.KindStop && c.Event == geofence.EventEnter && status.IsOnRoad():
+			moved, change, err = advanceRideStop(ctx, qtx, orgID, ride, c.Fence.OrderID, rides.StopArrived, "", at, systemActor)
+		case c.Fence.Kind == geofence.KindBranch && c.Event == geofence.EventEnter && status.IsOnRoad():
+			var arrival rides.StatusChange
+			moved, arrival, err = returnRide(ctx, qtx, ride, at)
+			change = []rides.StatusChange{arrival}
+		default:
+			continue
+		}
+		// e.g. the driver already reported the arrival or stops are left
+		if isConflict(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		ride = moved
+		changes = append(changes, change...)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if rides.Status(ride.Status) == rides.StatusReturned {
+		recordRideEarnings(ctx, orgID, ride)
+	}
+
+	return rides.PublishStatusChanges(ctx, centrifugo.Default, orgID, changes)
+}
+
+// Helper function to build the fences of the branch and the unfinished stops
+// of a ride
+func rideFences(ctx context.Context, orgID int64, ride db.Ride) ([]geofence.Fence, error) {
+	settings, err := db.Repository.GetGeofenceSettings(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofence settings: %w", err)
+	}
+
+	branch, err := db.Repository.GetBranch(ctx, db.GetBranchParams{
+		ID:             ride.BranchID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ride branch: %w", err)
+	}
+
+	stops, err := db.Repository.ListRideStops(ctx, db.ListRideStopsParams{
+		RideID:         ride.ID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ride stops: %w", err)
+	}
+
+	fences := []geofence.Fence{{
+		Kind:   geofence.KindBranch,
+		Center: geo.PointFromPg(branch.Location),
+		Radius: float64(settings.BranchRadius),
+	}}
+	for _, stop := range stops {
+		if rides.StopStatus(stop.StopStatus).IsFinished() {
+			continue
+		}
+		fences = append(fences, geofence.Fence{
+			Kind:    geofence.KindStop,
+			OrderID: stop.Order.ID,
+			Center:  geo.PointFromPg(stop.Order.Location),
+			Radius:  float64(settings.StopRadius),
+		})
+	}
+
+	return fences, nil
+}
+
+// Helper function to detect conflict errors of the ride lifecycle
+func isConflict(err error) bool {
+	var statusErr huma.StatusError
+	return errors.As(err, &statusErr) && statusErr.GetStatus() == http.StatusConflict
+}
@@ -0,0 +1,397 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/customers"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/geocode"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/orderstatus"
+	"smartDriver/pkg/payment"
+	"smartDriver/pkg/rides"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// orderSourceManual marks orders entered by dispatchers
+	orderSourceManual = "manual"
+
+	// eventSourceDispatcher marks order events caused by dispatchers
+	eventSourceDispatcher = "dispatcher"
+	// eventSourceDriver marks order events caused by the driver of the ride
+	eventSourceDriver = "driver"
+	// eventSourceSystem marks order events caused by auto-dispatch and
+	// geofences
+	eventSourceSystem = "system"
+)
+
+// manualOrderBody holds the editable fields of a manual order
+type manualOrderBody struct {
+	CustomerName string     `json:"customer_name" minLength:"1" maxLength:"255" doc:"Customer name"`
+	Phone        string     `json:"phone" maxLength:"50" doc:"Customer phone"`
+	City         string     `json:"city,omitempty" doc:"City"`
+	Street       string     `json:"street,omitempty" doc:"Street"`
+	Building     string     `json:"building,omitempty" doc:"Building"`
+	Apartment    string     `json:"apartment,omitempty" doc:"Apartment"`
+	Entrance     *int32     `json:"entrance,omitempty" doc:"Entrance"`
+	Floor        *int32     `json:"floor,omitempty" doc:"Floor"`
+	Doorphone    string     `json:"doorphone,omitempty" doc:"Doorphone code"`
+	Comment      string     `json:"comment,omitempty" doc:"Comment for the courier"`
+	Location     *point     `json:"location,omitempty" doc:"Delivery point. Geocoded from the address when omitted."`
+	Cost         float64    `json:"cost" minimum:"0" doc:"Order cost"`
+	PaymentKind  string     `json:"payment_kind,omitempty" enum:"cash,card,prepaid" default:"cash" doc:"How the customer pays"`
+	PromisedAt   *time.Time `json:"promised_at,omitempty" doc:"Delivery time promised to the customer"`
+	BranchID     *int64     `json:"branch_id,omitempty" doc:"Branch that delivers the order. Located by delivery zones when omitted."`
+}
+
+type createManualOrderIn struct {
+	Body manualOrderBody
+}
+
+type updateManualOrderIn struct {
+	ID   int64 `path:"id" doc:"Order ID"`
+	Body manualOrderBody
+}
+
+// CreateManualOrder creates an order taken by a dispatcher outside of iiko
+func CreateManualOrder(ctx context.Context, in *createManualOrderIn) (*orderOut, error) {
+	orgID := organizationID(ctx)
+
+	externalID, err := newManualExternalID()
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to generate external id: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create order", err)
+	}
+
+	location, locationSource, err := resolveOrderLocation(ctx, in.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create order", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+
+	branchID, inZone, err := resolveOrderBranch(ctx, qtx, orgID, location, in.Body.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	customerID, err := customers.Link(ctx, qtx, orgID, in.Body.CustomerName, in.Body.Phone)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to link customer: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create order", err)
+	}
+
+	order, err := qtx.CreateOrder(ctx, db.CreateOrderParams{
+		ExternalID:     externalID,
+		CustomerName:   in.Body.CustomerName,
+		Phone:          &in.Body.Phone,
+		City:           &in.Body.City,
+		Street:         &in.Body.Street,
+		Apartment:      &in.Body.Apartment,
+		Doorphone:      &in.Body.Doorphone,
+		Building:       &in.Body.Building,
+		Floor:          in.Body.Floor,
+		Entrance:       in.Body.Entrance,
+		Comment:        &in.Body.Comment,
+		Cost:           db.NumericFromFloat(in.Body.Cost),
+		Status:         string(orderstatus.New),
+		Point:          location.Lng,
+		Point_2:        location.Lat,
+		CreatedAt:      pgtype.Timestamp{Time: time.Now(), Valid: true},
+		BranchID:       branchID,
+		OutOfZone:      !inZone,
+		OrganizationID: orgID,
+		CashToCollect:  db.NumericFromFloat(manualCashToCollect(in.Body)),
+		PromisedAt:     timestampValue(in.Body.PromisedAt),
+		Source:         orderSourceManual,
+		LocationSource: locationSource,
+		CustomerID:     customerID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to create order: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create order", err)
+	}
+
+	if customerID != nil {
+		if err := customers.RememberAddress(ctx, qtx, *customerID, customers.Address{
+			City:      in.Body.City,
+			Street:    in.Body.Street,
+			Building:  in.Body.Building,
+			Apartment: in.Body.Apartment,
+			Entrance:  in.Body.Entrance,
+			Floor:     in.Body.Floor,
+			Doorphone: in.Body.Doorphone,
+			Location:  location,
+		}); err != nil {
+			log.SugaredLogger.Errorf("failed to save customer address: %v", err)
+			return nil, huma.Error500InternalServerError("failed to create order", err)
+		}
+	}
+
+	if _, err := qtx.CreateOrderEvent(ctx, db.CreateOrderEventParams{
+		OrderID:    order.ID,
+		NewStatus:  order.Status,
+		Source:     eventSourceDispatcher,
+		OccurredAt: order.CreatedAt,
+		UserID:     dispatcherActor(ctx).UserID,
+	}); err != nil {
+		log.SugaredLogger.Errorf("failed to create order event: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create order", err)
+	}
+
+	if err := saveManualPayment(ctx, qtx, order.ID, in.Body); err != nil {
+		log.SugaredLogger.Errorf("failed to save order payment: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create order", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create order", err)
+	}
+
+	return GetOrder(ctx, &idPathIn{ID: order.ID})
+}
+
+// UpdateManualOrder replaces the details of a manual order. Orders imported
+// from iiko are managed by iiko and cannot be edited.
+func UpdateManualOrder(ctx context.Context, in *updateManualOrderIn) (*orderOut, error) {
+	orgID := organizationID(ctx)
+
+	location, locationSource, err := resolveOrderLocation(ctx, in.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update order", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+
+	order, err := getManualOrder(ctx, qtx, in.ID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if orderstatus.Status(order.Status).IsTerminal() {
+		return nil, huma.Error409Conflict("finished orders cannot be edited")
+	}
+
+	branchID, inZone, err := resolveOrderBranch(ctx, qtx, orgID, location, in.Body.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	customerID, err := customers.Link(ctx, qtx, orgID, in.Body.CustomerName, in.Body.Phone)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to link customer: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update order", err)
+	}
+
+	if err := qtx.UpdateOrder(ctx, db.UpdateOrderParams{
+		ID:             order.ID,
+		CustomerName:   in.Body.CustomerName,
+		Phone:          &in.Body.Phone,
+		City:           &in.Body.City,
+		Street:         &in.Body.Street,
+		Apartment:      &in.Body.Apartment,
+		Doorphone:      &in.Body.Doorphone,
+		Building:       &in.Body.Building,
+		Floor:          in.Body.Floor,
+		Entrance:       in.Body.Entrance,
+		Comment:        &in.Body.Comment,
+		Cost:           db.NumericFromFloat(in.Body.Cost),
+		Point:          location.Lng,
+		Point_2:        location.Lat,
+		BranchID:       branchID,
+		OutOfZone:      !inZone,
+		GuestCount:     order.GuestCount,
+		CourierName:    order.CourierName,
+		CourierPhone:   order.CourierPhone,
+		CashToCollect:  db.NumericFromFloat(manualCashToCollect(in.Body)),
+		PromisedAt:     timestampValue(in.Body.PromisedAt),
+		LocationSource: locationSource,
+		CustomerID:     customerID,
+	}); err != nil {
+		log.SugaredLogger.Errorf("failed to update order: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update order", err)
+	}
+
+	if err := qtx.DeleteOrderPayments(ctx, order.ID); err != nil {
+		log.SugaredLogger.Errorf("failed to delete order payments: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update order", err)
+	}
+	if err := saveManualPayment(ctx, qtx, order.ID, in.Body); err != nil {
+		log.SugaredLogger.Errorf("failed to save order payment: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update order", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update order", err)
+	}
+
+	return GetOrder(ctx, &idPathIn{ID: order.ID})
+}
+
+// CancelManualOrder cancels a manual order, records the cancellation in the
+// order history and removes the order from active rides
+func CancelManualOrder(ctx context.Context, in *idPathIn) (*orderOut, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to cancel order", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+
+	order, err := getManualOrder(ctx, qtx, in.ID, organizationID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	detachments, err := changeOrderStatus(ctx, qtx, order, orderstatus.Cancelled, dispatcherActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to cancel order", err)
+	}
+
+	if err := rides.PublishDetachments(ctx, centrifugo.Default, order.OrganizationID, detachments); err != nil {
+		log.SugaredLogger.Errorf("failed to publish ride detachments: %v", err)
+	}
+
+	return GetOrder(ctx, &idPathIn{ID: order.ID})
+}
+
+// Helper function to load a manual order of the organization
+func getManualOrder(ctx context.Context, q *db.Queries, id, orgID int64) (db.Order, error) {
+	order, err := q.GetOrder(ctx, db.GetOrderParams{
+		ID:             id,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Order{}, huma.Error404NotFound("order not found")
+		}
+		log.SugaredLogger.Errorf("failed to get order: %v", err)
+		return db.Order{}, huma.Error500InternalServerError("failed to get order", err)
+	}
+
+	if order.Source != orderSourceManual {
+		return db.Order{}, huma.Error409Conflict("only manual orders can be changed, iiko orders are managed in iiko")
+	}
+
+	return order, nil
+}
+
+// Helper function to get the delivery point of a manual order, geocoding the
+// address when no location is given
+func resolveOrderLocation(ctx context.Context, body manualOrderBody) (geo.Point, string, error) {
+	if body.Location != nil {
+		location := geo.Point{Lat: body.Location.Lat, Lng: body.Location.Lng}
+		if !location.Valid() {
+			return geo.Point{}, "", huma.Error400BadRequest("invalid location")
+		}
+		return location, geocode.LocationProvided, nil
+	}
+
+	address := geocode.FormatAddress(body.City, body.Street, body.Building)
+	if address == "" {
+		return geo.Point{}, "", huma.Error400BadRequest("either location or address is required")
+	}
+
+	location, err := geocode.Default.Geocode(ctx, address)
+	if err != nil {
+		if errors.Is(err, geocode.ErrNotFound) {
+			return geo.Point{}, "", huma.Error400BadRequest("address not found, set the location explicitly")
+		}
+		log.SugaredLogger.Errorf("failed to geocode address: %v", err)
+		return geo.Point{}, "", huma.Error502BadGateway("failed to geocode address", err)
+	}
+
+	return location, geocode.LocationGeocoded, nil
+}
+
+// Helper function to pick the branch of a manual order. An explicit branch
+// wins, otherwise the branch is located by delivery zones like iiko orders.
+func resolveOrderBranch(ctx context.Context, q *db.Queries, orgID int64, location geo.Point, branchID *int64) (*int64, bool, error) {
+	zones, err := geo.LoadZoneIndex(ctx, q, orgID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list delivery zones: %v", err)
+		return nil, false, huma.Error500InternalServerError("failed to locate order branch", err)
+	}
+	zone, inZone := zones.Locate(location)
+
+	if branchID != nil {
+		if _, err := q.GetBranch(ctx, db.GetBranchParams{
+			ID:             *branchID,
+			OrganizationID: orgID,
+		}); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, false, huma.Error400BadRequest("branch not found")
+			}
+			log.SugaredLogger.Errorf("failed to get branch: %v", err)
+			return nil, false, huma.Error500InternalServerError("failed to locate order branch", err)
+		}
+		return branchID, inZone, nil
+	}
+
+	if !inZone {
+		return nil, false, nil
+	}
+	return &zone.BranchID, true, nil
+}
+
+// Helper function to record how a manual order is paid
+func saveManualPayment(ctx context.Context, q *db.Queries, orderID int64, body manualOrderBody) error {
+	return q.CreateOrderPayment(ctx, db.CreateOrderPaymentParams{
+		OrderID: orderID,
+		Kind:    body.PaymentKind,
+		Sum:     db.NumericFromFloat(body.Cost),
+	})
+}
+
+// Helper function to compute the cash the courier collects for a manual order
+func manualCashToCollect(body manualOrderBody) float64 {
+	return payment.CashToCollect(body.Cost, []payment.Part{{Kind: body.PaymentKind, Sum: body.Cost}})
+}
+
+// Helper function to generate an external ID for orders that are not known to iiko
+func newManualExternalID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return orderSourceManual + "-" + hex.EncodeToString(b), nil
+}
+
+// Helper function to convert an optional time to a timestamp column value
+func timestampValue(t *time.Time) pgtype.Timestamp {
+	if t == nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/log"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type orderEvent struct {
+	ID           int64     `json:"id" doc:"Event ID"`
+	OldStatus    *string   `json:"old_status" doc:"Status before the transition, null for the first event"`
+	NewStatus    string    `json:"new_status" doc:"Status after the transition"`
+	Source       string    `json:"source" doc:"Who reported the transition: iiko, dispatcher, driver or system"`
+	IikoRevision *int64    `json:"iiko_revision,omitempty" doc:"iiko revision the transition was received with"`
+	UserID       *int64    `json:"user_id,omitempty" doc:"User who made the transition"`
+	OccurredAt   time.Time `json:"occurred_at" doc:"When the transition happened"`
+	CreatedAt    time.Time `json:"created_at" doc:"When the transition was recorded"`
+}
+
+type orderHistoryOut struct {
+	Body struct {
+		OrderID int64        `json:"order_id" doc:"Order ID"`
+		Events  []orderEvent `json:"events" doc:"Status transitions in chronological order"`
+	}
+}
+
+// GetOrderHistory retrieves the status timeline of an order
+func GetOrderHistory(ctx context.Context, in *idPathIn) (*orderHistoryOut, error) {
+	order, err := db.Repository.GetOrder(ctx, db.GetOrderParams{
+		ID:             in.ID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("order not found")
+		}
+		log.SugaredLogger.Errorf("failed to get order: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order history", err)
+	}
+
+	events, err := db.Repository.ListOrderEvents(ctx, order.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list order events: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order history", err)
+	}
+
+	var resp orderHistoryOut
+	resp.Body.OrderID = order.ID
+	resp.Body.Events = make([]orderEvent, 0, len(events))
+
+	for _, event := range events {
+		resp.Body.Events = append(resp.Body.Events, orderEvent{
+			ID:           event.ID,
+			OldStatus:    event.OldStatus,
+			NewStatus:    event.NewStatus,
+			Source:       event.Source,
+			IikoRevision: event.IikoRevision,
+			UserID:       event.UserID,
+			OccurredAt:   event.OccurredAt.Time,
+			CreatedAt:    event.CreatedAt.Time,
+		})
+	}
+
+	return &resp, nil
+}
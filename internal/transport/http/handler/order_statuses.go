@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/orderstatus"
+	"smartDriver/pkg/rides"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type getOrderStatusesIn struct {
+	Lang           string `query:"lang" enum:"ru,en" doc:"Language of the labels, defaults to the Accept-Language header"`
+	AcceptLanguage string `header:"Accept-Language"`
+}
+
+type orderStatusInfo struct {
+	Value    string   `json:"value" doc:"Status value used by the API"`
+	Label    string   `json:"label" doc:"Localized status name"`
+	Terminal bool     `json:"terminal" doc:"Order no longer needs delivering"`
+	Next     []string `json:"next" doc:"Statuses the order may move to"`
+}
+
+type orderStatusesOut struct {
+	Body struct {
+		Statuses []orderStatusInfo `json:"statuses" doc:"Order statuses in lifecycle order"`
+	}
+}
+
+type changeOrderStatusIn struct {
+	ID   int64 `path:"id" doc:"Order ID"`
+	Body struct {
+		Status string `json:"status" enum:"new,cooking,ready,assigned,on_way,delivered,cancelled,failed" doc:"New order status"`
+	}
+}
+
+// GetOrderStatuses lists order statuses with localized labels and allowed
+// transitions
+func GetOrderStatuses(ctx context.Context, in *getOrderStatusesIn) (*orderStatusesOut, error) {
+	lang := in.Lang
+	if lang == "" {
+		lang = preferredLanguage(in.AcceptLanguage)
+	}
+
+	var resp orderStatusesOut
+	resp.Body.Statuses = make([]orderStatusInfo, 0, len(orderstatus.All))
+	for _, status := range orderstatus.All {
+		next := make([]string, 0, len(status.Next()))
+		for _, s := range status.Next() {
+			next = append(next, string(s))
+		}
+
+		resp.Body.Statuses = append(resp.Body.Statuses, orderStatusInfo{
+			Value:    string(status),
+			Label:    status.Label(lang),
+			Terminal: status.IsTerminal(),
+			Next:     next,
+		})
+	}
+
+	return &resp, nil
+}
+
+// ChangeOrderStatus moves a manual order to another status. Statuses of iiko
+// orders follow iiko.
+func ChangeOrderStatus(ctx context.Context, in *changeOrderStatusIn) (*orderOut, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to change order status", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+
+	order, err := getManualOrder(ctx, qtx, in.ID, organizationID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	detachments, err := changeOrderStatus(ctx, qtx, order, orderstatus.Status(in.Body.Status), dispatcherActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to change order status", err)
+	}
+
+	if err := rides.PublishDetachments(ctx, centrifugo.Default, order.OrganizationID, detachments); err != nil {
+		log.SugaredLogger.Errorf("failed to publish ride detachments: %v", err)
+	}
+
+	return GetOrder(ctx, &idPathIn{ID: order.ID})
+}
+
+// orderActor is who changed the status of an order, recorded in the order
+// history
+type orderActor struct {
+	Source string
+	UserID *int64
+}
+
+// systemActor changes order statuses on behalf of auto-dispatch and geofences
+var systemActor = orderActor{Source: eventSourceSystem}
+
+// Helper function to validate and apply a manual status change. Orders
+// reaching a terminal status are detached from active rides, the returned
+// detachments are to be published after commit.
+func changeOrderStatus(ctx context.Context, q *db.Queries, order db.Order, to orderstatus.Status, actor orderActor) ([]rides.Detachment, error) {
+	from := orderstatus.Status(order.Status)
+	if !orderstatus.CanTransition(from, to) {
+		return nil, huma.Error409Conflict(fmt.Sprintf("order status cannot change from %s to %s", from, to))
+	}
+
+	if err := setOrderStatus(ctx, q, order, to, actor); err != nil {
+		return nil, err
+	}
+
+	if !to.IsTerminal() {
+		return nil, nil
+	}
+
+	detachments, err := rides.DetachFinishedOrder(ctx, q, order, string(to), actor.Source)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to detach finished order: %v", err)
+		return nil, huma.Error500InternalServerError("failed to change order status", err)
+	}
+	return detachments, nil
+}
+
+// Helper function to store a new order status and record it in the order
+// history without validating the transition
+func setOrderStatus(ctx context.Context, q *db.Queries, order db.Order, to orderstatus.Status, actor orderActor) error {
+	if err := q.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
+		ID:     order.ID,
+		Status: string(to),
+	}); err != nil {
+		log.SugaredLogger.Errorf("failed to update order status: %v", err)
+		return huma.Error500InternalServerError("failed to change order status", err)
+	}
+
+	if _, err := q.CreateOrderEvent(ctx, db.CreateOrderEventParams{
+		OrderID:    order.ID,
+		OldStatus:  &order.Status,
+		NewStatus:  string(to),
+		Source:     actor.Source,
+		OccurredAt: pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+		UserID:     actor.UserID,
+	}); err != nil {
+		log.SugaredLogger.Errorf("failed to create order event: %v", err)
+		return huma.Error500InternalServerError("failed to change order status", err)
+	}
+
+	return nil
+}
+
+// Helper function to get the dispatcher signed in as the actor of a change
+func dispatcherActor(ctx context.Context) orderActor {
+	actor := orderActor{Source: eventSourceDispatcher}
+	if userID, ok := ctx.Value("user_id").(int64); ok {
+		actor.UserID = &userID
+	}
+	return actor
+}
+
+// Helper function to get the user signed in as the actor of a change. Users
+// with a driver profile act as drivers, anyone else as a dispatcher.
+func requestActor(ctx context.Context, q *db.Queries, orgID int64) (orderActor, error) {
+	actor := dispatcherActor(ctx)
+	if actor.UserID == nil {
+		return actor, nil
+	}
+
+	if _, err := q.GetDriverByUserID(ctx, db.GetDriverByUserIDParams{
+		UserID:         *actor.UserID,
+		OrganizationID: orgID,
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return actor, nil
+		}
+		log.SugaredLogger.Errorf("failed to get driver of user: %v", err)
+		return orderActor{}, huma.Error500InternalServerError("failed to get driver", err)
+	}
+
+	actor.Source = eventSourceDriver
+	return actor, nil
+}
+
+// Helper function to find the status an order returns to when it is removed
+// from a ride. iiko orders take the status iiko reports, manual orders the
+// status they had before the assignment.
+func unassignedStatus(ctx context.Context, q *db.Queries, order db.Order) (orderstatus.Status, error) {
+	if order.IikoStatus != nil {
+		if status, ok := orderstatus.Default.Map(*order.IikoStatus, stringValue(order.IikoDeliveryStatus)); ok && !status.IsTerminal() {
+			return status, nil
+		}
+		return orderstatus.Ready, nil
+	}
+
+	previous, err := q.GetStatusBeforeAssignment(ctx, order.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+	if previous == nil {
+		return orderstatus.New, nil
+	}
+	return orderstatus.Status(*previous), nil
+}
+
+// Helper function to pick the first supported language of an Accept-Language header
+func preferredLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		for _, supported := range orderstatus.Languages() {
+			if lang == supported {
+				return lang
+			}
+		}
+	}
+	return orderstatus.DefaultLanguage
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/orderstatus"
+	"smartDriver/pkg/rides"
+	"smartDriver/pkg/tracking"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// trackingPrecision is the number of decimal places of courier coordinates
+// shown to customers, about a hundred meters
+const trackingPrecision = 3
+
+// trackingPositionMaxAge is how old a courier position may be to be shown to
+// customers. Older positions would point where the courier no longer is.
+const trackingPositionMaxAge = 10 * time.Minute
+
+type orderTrackingLinkOut struct {
+	Body struct {
+		OrderID int64  `json:"order_id" doc:"Order ID"`
+		Token   string `json:"token" doc:"Tracking token"`
+		Path    string `json:"path" doc:"Path of the public tracking endpoint"`
+	}
+}
+
+type getOrderTrackingIn struct {
+	Token          string `path:"token" maxLength:"128" doc:"Tracking token"`
+	Lang           string `query:"lang" enum:"ru,en" doc:"Language of the status label, defaults to the Accept-Language header"`
+	AcceptLanguage string `header:"Accept-Language"`
+}
+
+type orderTrackingOut struct {
+	Body struct {
+		Status      string     `json:"status" doc:"Order status"`
+		StatusLabel string     `json:"status_label" doc:"Localized order status"`
+		CourierName *string    `json:"courier_name" doc:"First name of the courier"`
+		Location    *point     `json:"location" doc:"Approximate courier coordinates while the order is on the way"`
+		LocatedAt   *time.Time `json:"located_at,omitempty" doc:"When the courier position was recorded"`
+		ETA         *time.Time `json:"eta" doc:"Estimated arrival of the courier"`
+	}
+}
+
+// CreateOrderTrackingLink returns the token of the public page a customer
+// follows the order on. The token of an order never changes.
+func CreateOrderTrackingLink(ctx context.Context, in *idPathIn) (*orderTrackingLinkOut, error) {
+	order, err := db.Repository.GetOrder(ctx, db.GetOrderParams{
+		ID:             in.ID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("order not found")
+		}
+		log.SugaredLogger.Errorf("failed to get order: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create tracking link", err)
+	}
+
+	if orderstatus.Status(order.Status).IsTerminal() {
+		return nil, huma.Error409Conflict("order is " + order.Status)
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, huma.Error500InternalServerError("failed to generate tracking token", err)
+	}
+
+	link, err := db.Repository.CreateOrderTrackingLink(ctx, db.CreateOrderTrackingLinkParams{
+		OrderID: order.ID,
+		Token:   token,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to create order tracking link: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create tracking link", err)
+	}
+
+	var resp orderTrackingLinkOut
+	resp.Body.OrderID = order.ID
+	resp.Body.Token = link.Token
+	resp.Body.Path = "/track/" + link.Token
+	return &resp, nil
+}
+
+// GetOrderTracking shows a customer where the order is. The link expires once
+// the order is delivered, cancelled or failed.
+func GetOrderTracking(ctx context.Context, in *getOrderTrackingIn) (*orderTrackingOut, error) {
+	order, err := db.Repository.GetOrderByTrackingToken(ctx, in.Token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("tracking link not found")
+		}
+		log.SugaredLogger.Errorf("failed to get order by tracking token: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order tracking", err)
+	}
+
+	status := orderstatus.Status(order.Order.Status)
+	if status.IsTerminal() {
+		return nil, huma.Error410Gone("tracking link expired")
+	}
+
+	lang := in.Lang
+	if lang == "" {
+		lang = preferredLanguage(in.AcceptLanguage)
+	}
+
+	var resp orderTrackingOut
+	resp.Body.Status = string(status)
+	resp.Body.StatusLabel = status.Label(lang)
+	resp.Body.CourierName = firstName(order.Order.CourierName)
+
+	stop, err := db.Repository.GetOrderTrackingStop(ctx, order.Order.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &resp, nil
+		}
+		log.SugaredLogger.Errorf("failed to get order tracking stop: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order tracking", err)
+	}
+
+	rideStatus := rides.Status(stop.RideStatus)
+	if rideStatus.IsFinished() || rides.StopStatus(stop.StopStatus).IsFinished() {
+		return &resp, nil
+	}
+
+	if name := firstName(stop.DriverName); name != nil {
+		resp.Body.CourierName = name
+	}
+	switch {
+	case stop.Eta.Valid:
+		resp.Body.ETA = &stop.Eta.Time
+	case stop.PlannedArrival.Valid:
+		resp.Body.ETA = &stop.PlannedArrival.Time
+	}
+
+	if !rideStatus.IsOnRoad() || stop.DriverID == nil {
+		return &resp, nil
+	}
+
+	position, found, err := latestDriverPosition(ctx, *stop.DriverID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get latest driver position: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order tracking", err)
+	}
+	if found && time.Since(position.RecordedAt) <= trackingPositionMaxAge {
+		approx := position.Location.Round(trackingPrecision)
+		resp.Body.Location = &point{Lat: approx.Lat, Lng: approx.Lng}
+		resp.Body.LocatedAt = &position.RecordedAt
+	}
+
+	return &resp, nil
+}
+
+// Helper function to get the latest position of a driver from the index,
+// falling back to the database after a restart
+func latestDriverPosition(ctx context.Context, driverID int64) (tracking.Position, bool, error) {
+	if position, ok := tracking.Latest.Get(driverID); ok {
+		return position, true, nil
+	}
+
+	stored, err := db.Repository.GetLatestDriverPosition(ctx, driverID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return tracking.Position{}, false, nil
+		}
+		return tracking.Position{}, false, err
+	}
+
+	return newTrackingPosition(stored), true, nil
+}
+
+// Helper function to take the first word of a name, customers only see the
+// first name of the courier
+func firstName(name *string) *string {
+	if name == nil {
+		return nil
+	}
+	fields := strings.Fields(*name)
+	if len(fields) == 0 {
+		return nil
+	}
+	return &fields[0]
+}
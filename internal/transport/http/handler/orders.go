@@ -2,9 +2,12 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"smartDriver/internal/db"
 	"smartDriver/pkg/log"
+	"smartDriver/pkg/orderstatus"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -13,7 +16,7 @@ import (
 // Input structure for filtering unbound orders
 type getUnboundOrdersIn struct {
 	Query struct {
-		Status   *string   `query:"status" doc:"Filter by order status"`
+		Status   *string   `query:"status" enum:"new,cooking,ready,assigned,on_way,delivered,cancelled,failed" doc:"Filter by order status"`
 		FromDate time.Time `query:"from_date" doc:"Filter orders from this date (RFC3339 format)"`
 		ToDate   time.Time `query:"to_date" doc:"Filter orders until this date (RFC3339 format)"`
 		Limit    int32     `query:"limit" default:"50" doc:"Maximum number of orders to return"`
@@ -34,29 +37,26 @@ type unboundOrdersOut struct {
 // GetUnboundOrders retrieves orders that aren't attached to any rides
 func GetUnboundOrders(ctx context.Context, in *getUnboundOrdersIn) (*unboundOrdersOut, error) {
 	params := db.GetUnboundOrdersParams{
-		Limit:  in.Query.Limit,
-		Offset: in.Query.Offset,
+		OrganizationID: organizationID(ctx),
+		Status:         in.Query.Status,
+		RowLimit:       in.Query.Limit,
+		RowOffset:      in.Query.Offset,
 	}
 
 	if !in.Query.FromDate.IsZero() {
-		params.CreatedAt = pgtype.Timestamp{
+		params.FromDate = pgtype.Timestamp{
 			Time:  in.Query.FromDate,
 			Valid: true,
 		}
 	}
 
 	if !in.Query.ToDate.IsZero() {
-		params.CreatedAt_2 = pgtype.Timestamp{
+		params.ToDate = pgtype.Timestamp{
 			Time:  in.Query.ToDate,
 			Valid: true,
 		}
 	}
 
-	if in.Query.Status != nil {
-		params.Status = in.Query.Status
-
-	}
-
 	// Get unbound orders
 	orders, err := db.Repository.GetUnboundOrders(ctx, params)
 	if err != nil {
@@ -66,9 +66,10 @@ func GetUnboundOrders(ctx context.Context, in *getUnboundOrdersIn) (*unboundOrde
 
 	// Get total count
 	total, err := db.Repository.CountUnboundOrders(ctx, db.CountUnboundOrdersParams{
-		Status:      params.Status,
-		CreatedAt:   params.CreatedAt,
-		CreatedAt_2: params.CreatedAt_2,
+		OrganizationID: params.OrganizationID,
+		Status:         params.Status,
+		FromDate:       params.FromDate,
+		ToDate:         params.ToDate,
 	})
 	if err != nil {
 		log.SugaredLogger.Errorf("failed to count unbound orders: %v", err)
@@ -81,16 +82,246 @@ func GetUnboundOrders(ctx context.Context, in *getUnboundOrdersIn) (*unboundOrde
 	resp.Body.Total = total
 
 	for _, order := range orders {
-		resp.Body.Orders = append(resp.Body.Orders, orderInfo{
-			ID:           order.ID,
-			ExternalID:   order.ExternalID,
-			Status:       *order.Status,
-			Address:      formatAddress(order),
-			Location:     point{Lat: order.Location.P.Y, Lng: order.Location.P.X},
-			CustomerName: order.CustomerName,
-			CreatedAt:    order.CreatedAt.Time,
-			Cost:         order.Cost.Int.Int64(),
+		resp.Body.Orders = append(resp.Body.Orders, newOrderInfo(order))
+	}
+
+	return &resp, nil
+}
+
+// Input structure for listing orders
+type listOrdersIn struct {
+	listIn
+	Query struct {
+		Status   []string  `query:"status" enum:"new,cooking,ready,assigned,on_way,delivered,cancelled,failed" doc:"Filter by order statuses (comma separated)"`
+		FromDate time.Time `query:"from_date" doc:"Filter orders from this date (RFC3339 format)"`
+		ToDate   time.Time `query:"to_date" doc:"Filter orders until this date (RFC3339 format)"`
+		BranchID int64     `query:"branch_id" doc:"Filter by branch ID"`
+		Phone    string    `query:"phone" doc:"Filter by part of the customer phone"`
+		MinCost  float64   `query:"min_cost" minimum:"0" doc:"Minimum order cost"`
+		MaxCost  float64   `query:"max_cost" minimum:"0" doc:"Maximum order cost"`
+		Binding  string    `query:"binding" enum:"all,bound,unbound" default:"all" doc:"Filter by attachment to a ride"`
+		RideID   int64     `query:"ride_id" doc:"Filter by ride ID"`
+		Source   string    `query:"source" enum:"iiko,manual" doc:"Filter by order source"`
+		Sort     string    `query:"sort" enum:"created_at,-created_at,cost,-cost" default:"-created_at" doc:"Sort field, prefixed with - for descending order"`
+	}
+	// huma does not support optional query parameters, so whether the cost
+	// bounds were given at all is resolved from the raw query
+	minCostSet bool
+	maxCostSet bool
+}
+
+// Resolve records which cost bounds the request filters by, a bound of 0 is
+// a valid filter
+func (in *listOrdersIn) Resolve(ctx huma.Context) []error {
+	in.minCostSet = ctx.Query("min_cost") != ""
+	in.maxCostSet = ctx.Query("max_cost") != ""
+	return nil
+}
+
+// Output structure for the orders list
+type listOrdersOut struct {
+	Body struct {
+		Orders     []orderDetails `json:"orders" doc:"List of orders"`
+		NextCursor string         `json:"next_cursor,omitempty" doc:"Cursor of the next page, empty on the last page"`
+	}
+}
+
+type orderOut struct {
+	Body orderDetails
+}
+
+// ordersCursor is the position of the last order of a page
+type ordersCursor struct {
+	Sort      string          `json:"s"`
+	ID        int64           `json:"id"`
+	CreatedAt time.Time       `json:"t"`
+	Cost      *pgtype.Numeric `json:"c,omitempty"`
+}
+
+// ListOrders retrieves orders of the caller's organization using optional
+// filters and cursor pagination
+func ListOrders(ctx context.Context, in *listOrdersIn) (*listOrdersOut, error) {
+	params := db.FilterOrdersParams{
+		OrganizationID: organizationID(ctx),
+		Statuses:       in.Query.Status,
+		Sort:           in.Query.Sort,
+		RowLimit:       int32(in.Limit) + 1,
+	}
+
+	if !in.Query.FromDate.IsZero() {
+		params.FromDate = pgtype.Timestamp{Time: in.Query.FromDate, Valid: true}
+	}
+	if !in.Query.ToDate.IsZero() {
+		params.ToDate = pgtype.Timestamp{Time: in.Query.ToDate, Valid: true}
+	}
+	if in.Query.BranchID != 0 {
+		params.BranchID = &in.Query.BranchID
+	}
+	if in.Query.Phone != "" {
+		params.Phone = &in.Query.Phone
+	}
+	if in.minCostSet {
+		params.MinCost = &in.Query.MinCost
+	}
+	if in.maxCostSet {
+		params.MaxCost = &in.Query.MaxCost
+	}
+	if in.Query.RideID != 0 {
+		params.RideID = &in.Query.RideID
+	}
+	if in.Query.Source != "" {
+		params.Source = &in.Query.Source
+	}
+	switch in.Query.Binding {
+	case "bound":
+		bound := true
+		params.Bound = &bound
+	case "unbound":
+		bound := false
+		params.Bound = &bound
+	}
+
+	if in.Cursor != "" {
+		var cursor ordersCursor
+		if err := decodeCursor(in.Cursor, &cursor); err != nil || cursor.Sort != in.Query.Sort {
+			return nil, huma.Error400BadRequest("invalid cursor")
+		}
+		params.CursorID = &cursor.ID
+		params.CursorCreatedAt = pgtype.Timestamp{Time: cursor.CreatedAt, Valid: true}
+		if cursor.Cost != nil {
+			params.CursorCost = *cursor.Cost
+		}
+	}
+
+	rows, err := db.Repository.FilterOrders(ctx, params)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list orders: %v", err)
+		return nil, huma.Error500InternalServerError("failed to list orders", err)
+	}
+
+	var resp listOrdersOut
+	resp.Body.Orders = make([]orderDetails, 0, len(rows))
+
+	if len(rows) > in.Limit {
+		rows = rows[:in.Limit]
+		last := rows[len(rows)-1].Order
+		cursor, err := encodeCursor(ordersCursor{
+			Sort:      in.Query.Sort,
+			ID:        last.ID,
+			CreatedAt: last.CreatedAt.Time,
+			Cost:      &last.Cost,
 		})
+		if err != nil {
+			log.SugaredLogger.Errorf("failed to encode cursor: %v", err)
+			return nil, huma.Error500InternalServerError("failed to list orders", err)
+		}
+		resp.Body.NextCursor = cursor
+	}
+
+	for _, row := range rows {
+		details := newOrderDetails(row.Order, row.RideID)
+		if row.Eta.Valid {
+			details.ETA = &row.Eta.Time
+		}
+		resp.Body.Orders = append(resp.Body.Orders, details)
+	}
+
+	return &resp, nil
+}
+
+// GetOrder retrieves an order of the caller's organization by ID
+func GetOrder(ctx context.Context, in *idPathIn) (*orderOut, error) {
+	order, err := db.Repository.GetOrder(ctx, db.GetOrderParams{
+		ID:             in.ID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("order not found")
+		}
+		log.SugaredLogger.Errorf("failed to get order: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order", err)
+	}
+
+	var rideID *int64
+	id, err := db.Repository.GetOrderRideID(ctx, order.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.SugaredLogger.Errorf("failed to get order ride: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order", err)
+	}
+	if err == nil {
+		rideID = &id
+	}
+
+	items, err := db.Repository.ListOrderItems(ctx, order.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list order items: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order", err)
+	}
+
+	payments, err := db.Repository.ListOrderPayments(ctx, order.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list order payments: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order", err)
+	}
+
+	sizes, err := orderSizes(ctx, db.Repository, order.OrganizationID, []int64{order.ID})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get order size: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order", err)
+	}
+
+	eta, err := db.Repository.GetOrderStopETA(ctx, order.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.SugaredLogger.Errorf("failed to get order eta: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get order", err)
+	}
+
+	resp := orderOut{Body: newOrderDetails(order, rideID)}
+	if eta.Valid {
+		resp.Body.ETA = &eta.Time
+	}
+	if size, ok := sizes[order.ID]; ok {
+		resp.Body.Size = &orderSize{Weight: size.Weight, Volume: size.Volume}
+	}
+	for _, item := range items {
+		resp.Body.Items = append(resp.Body.Items, orderItem{
+			Name:    item.Name,
+			Amount:  numericValue(item.Amount),
+			Price:   numericValue(item.Price),
+			Cost:    numericValue(item.Cost),
+			Comment: stringValue(item.Comment),
+		})
+	}
+	for _, p := range payments {
+		resp.Body.Payments = append(resp.Body.Payments, orderPayment{
+			Kind: p.Kind,
+			Name: stringValue(p.PaymentTypeName),
+			Sum:  numericValue(p.Sum),
+		})
+	}
+
+	if order.CustomerID != nil {
+		customer, err := db.Repository.GetCustomer(ctx, db.GetCustomerParams{
+			ID:             *order.CustomerID,
+			OrganizationID: order.OrganizationID,
+		})
+		if err != nil {
+			log.SugaredLogger.Errorf("failed to get order customer: %v", err)
+			return nil, huma.Error500InternalServerError("failed to get order", err)
+		}
+
+		flags, err := db.Repository.ListCustomerFlags(ctx, customer.ID)
+		if err != nil {
+			log.SugaredLogger.Errorf("failed to list customer flags: %v", err)
+			return nil, huma.Error500InternalServerError("failed to get order", err)
+		}
+
+		resp.Body.Customer = &orderCustomer{
+			ID:    customer.ID,
+			Notes: customer.Notes,
+			Flags: newCustomerFlags(flags),
+		}
 	}
 
 	return &resp, nil
@@ -98,12 +329,123 @@ func GetUnboundOrders(ctx context.Context, in *getUnboundOrdersIn) (*unboundOrde
 
 // Add this to the existing orderInfo struct
 type orderInfo struct {
-	ID           int64     `json:"id"`
-	ExternalID   string    `json:"external_id"`
-	Status       string    `json:"status"`
-	Address      string    `json:"address"`
-	Location     point     `json:"location"`
-	CustomerName string    `json:"customer_name"`
-	CreatedAt    time.Time `json:"created_at"`
-	Cost         int64     `json:"cost"`
+	ID            int64      `json:"id"`
+	ExternalID    string     `json:"external_id"`
+	Status        string     `json:"status" enum:"new,cooking,ready,assigned,on_way,delivered,cancelled,failed" doc:"Order status, see /orders/statuses"`
+	Address       string     `json:"address"`
+	Location      point      `json:"location"`
+	CustomerName  string     `json:"customer_name"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Cost          int64      `json:"cost"`
+	BranchID      *int64     `json:"branch_id" doc:"Branch whose delivery zone contains the order"`
+	OutOfZone     bool       `json:"out_of_zone" doc:"Order is outside every delivery zone"`
+	CashToCollect float64    `json:"cash_to_collect" doc:"Cash the courier has to collect from the customer"`
+	PromisedAt    *time.Time `json:"promised_at" doc:"Delivery time promised to the customer"`
+	TimeRemaining *int64     `json:"time_remaining,omitempty" doc:"Seconds left until the promised time, negative when overdue. Only set for open orders."`
+	Lateness      string     `json:"lateness" enum:"on_time,at_risk,late" doc:"Lateness level reported to dispatchers"`
+	Source        string     `json:"source" enum:"iiko,manual" doc:"Where the order comes from"`
+	LocationFrom  string     `json:"location_source" enum:"provided,geocoded,missing" doc:"Where the location comes from, missing locations are placeholders"`
+}
+
+// Helper function to convert an order to its response representation
+func newOrderInfo(order db.Order) orderInfo {
+	info := orderInfo{
+		ID:            order.ID,
+		ExternalID:    order.ExternalID,
+		Status:        order.Status,
+		Address:       formatAddress(order),
+		Location:      point{Lat: order.Location.P.Y, Lng: order.Location.P.X},
+		CustomerName:  order.CustomerName,
+		CreatedAt:     order.CreatedAt.Time,
+		Cost:          int64(numericValue(order.Cost)),
+		BranchID:      order.BranchID,
+		OutOfZone:     order.OutOfZone,
+		CashToCollect: numericValue(order.CashToCollect),
+		Lateness:      order.Lateness,
+		Source:        order.Source,
+		LocationFrom:  order.LocationSource,
+	}
+
+	if order.PromisedAt.Valid {
+		info.PromisedAt = &order.PromisedAt.Time
+		if !orderstatus.Status(info.Status).IsTerminal() {
+			remaining := int64(time.Until(order.PromisedAt.Time).Seconds())
+			info.TimeRemaining = &remaining
+		}
+	}
+
+	return info
+}
+
+// Helper function to convert numeric columns keeping their fractional part
+func numericValue(n pgtype.Numeric) float64 {
+	f, err := n.Float64Value()
+	if err != nil {
+		return 0
+	}
+	return f.Float64
+}
+
+// orderDetails extends orderInfo with delivery details of the order
+type orderDetails struct {
+	orderInfo
+	Phone        string         `json:"phone"`
+	City         string         `json:"city"`
+	Street       string         `json:"street"`
+	Building     string         `json:"building"`
+	Apartment    string         `json:"apartment"`
+	Entrance     *int32         `json:"entrance,omitempty"`
+	Floor        *int32         `json:"floor,omitempty"`
+	Doorphone    string         `json:"doorphone"`
+	Comment      string         `json:"comment"`
+	RideID       *int64         `json:"ride_id" doc:"Ride the order is attached to"`
+	ETA          *time.Time     `json:"eta,omitempty" doc:"Estimated arrival of the driver while the order is on a ride"`
+	GuestCount   *int32         `json:"guest_count,omitempty" doc:"Number of guests to bring cutlery for"`
+	CourierName  string         `json:"courier_name,omitempty" doc:"Courier assigned in iiko"`
+	CourierPhone string         `json:"courier_phone,omitempty" doc:"Phone of the courier assigned in iiko"`
+	Items        []orderItem    `json:"items,omitempty" doc:"Order lines"`
+	Size         *orderSize     `json:"size,omitempty" doc:"Room the order takes in a vehicle, derived from the items"`
+	Payments     []orderPayment `json:"payments,omitempty" doc:"Payment breakdown"`
+	Customer     *orderCustomer `json:"customer,omitempty" doc:"Customer profile linked by phone"`
+}
+
+// orderCustomer is what the driver needs to know about the customer
+type orderCustomer struct {
+	ID    int64          `json:"id" doc:"Customer ID"`
+	Notes string         `json:"notes" doc:"Delivery notes for drivers"`
+	Flags []customerFlag `json:"flags" doc:"Flags of a problematic customer"`
+}
+
+type orderItem struct {
+	Name    string  `json:"name" doc:"Product name"`
+	Amount  float64 `json:"amount" doc:"Quantity"`
+	Price   float64 `json:"price" doc:"Price per unit"`
+	Cost    float64 `json:"cost" doc:"Line total"`
+	Comment string  `json:"comment,omitempty" doc:"Line comment"`
+}
+
+type orderPayment struct {
+	Kind string  `json:"kind" enum:"cash,card,prepaid" doc:"Payment kind"`
+	Name string  `json:"name,omitempty" doc:"Payment type name"`
+	Sum  float64 `json:"sum" doc:"Payment sum"`
+}
+
+// Helper function to convert an order to its detailed response representation
+func newOrderDetails(order db.Order, rideID *int64) orderDetails {
+	return orderDetails{
+		orderInfo:    newOrderInfo(order),
+		Phone:        stringValue(order.Phone),
+		City:         stringValue(order.City),
+		Street:       stringValue(order.Street),
+		Building:     stringValue(order.Building),
+		Apartment:    stringValue(order.Apartment),
+		Entrance:     order.Entrance,
+		Floor:        order.Floor,
+		Doorphone:    stringValue(order.Doorphone),
+		Comment:      stringValue(order.Comment),
+		RideID:       rideID,
+		GuestCount:   order.GuestCount,
+		CourierName:  stringValue(order.CourierName),
+		CourierPhone: stringValue(order.CourierPhone),
+	}
 }
@@ -252,6 +252,10 @@ func GetOrganizationApiTokens(ctx context.Context) ([]string, error) {
 }
 
 // Helper functions
+func organizationID(ctx context.Context) int64 {
+	return ctx.Value("organization_id").(int64)
+}
+
 func isAdmin(ctx context.Context) bool {
 	roles := ctx.Value("user_roles").([]string)
 	for _, role := range roles {
@@ -262,6 +266,18 @@ func isAdmin(ctx context.Context) bool {
 	return false
 }
 
+// Helper function to check whether the user manages deliveries of the
+// organization, admins and dispatchers do
+func isDispatcher(ctx context.Context) bool {
+	roles := ctx.Value("user_roles").([]string)
+	for _, role := range roles {
+		if role == "admin" || role == "dispatcher" {
+			return true
+		}
+	}
+	return false
+}
+
 func isSystemProcess(ctx context.Context) bool {
 	return ctx.Value("is_system_process").(bool)
 }
@@ -0,0 +1,777 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/payment"
+	"smartDriver/pkg/payroll"
+	"smartDriver/pkg/rides"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type payRuleBody struct {
+	BranchID       *int64  `json:"branch_id,omitempty" doc:"Branch the rule applies to, the whole organization when empty"`
+	PerOrder       float64 `json:"per_order" minimum:"0" doc:"Pay per delivered order"`
+	PerKm          float64 `json:"per_km" minimum:"0" doc:"Pay per kilometer driven"`
+	PerHour        float64 `json:"per_hour" minimum:"0" doc:"Pay per hour on the road"`
+	MinimumPerRide float64 `json:"minimum_per_ride" minimum:"0" doc:"Guaranteed pay of a ride"`
+}
+
+type payRule struct {
+	ID int64 `json:"id" doc:"Pay rule ID"`
+	payRuleBody
+	UpdatedAt time.Time `json:"updated_at" doc:"When the rule last changed"`
+}
+
+type payRulesOut struct {
+	Body struct {
+		Rules []payRule `json:"rules" doc:"Pay rules of the organization and its branches"`
+	}
+}
+
+type payRuleOut struct {
+	Body payRule
+}
+
+type updatePayRuleIn struct {
+	Body payRuleBody
+}
+
+type earningAdjustment struct {
+	ID         int64     `json:"id" doc:"Adjustment ID"`
+	DriverID   int64     `json:"driver_id" doc:"Driver ID"`
+	Kind       string    `json:"kind" enum:"bonus,tip" doc:"Bonus from the organization or tip from a customer"`
+	Amount     float64   `json:"amount" doc:"Amount"`
+	RideID     *int64    `json:"ride_id" doc:"Ride the adjustment is for"`
+	OrderID    *int64    `json:"order_id" doc:"Order the adjustment is for"`
+	Comment    string    `json:"comment" doc:"Comment"`
+	OccurredAt time.Time `json:"occurred_at" doc:"When the adjustment counts in payroll"`
+	CreatedBy  *int64    `json:"created_by" doc:"User who recorded the adjustment"`
+}
+
+type earningAdjustmentOut struct {
+	Body earningAdjustment
+}
+
+type createEarningAdjustmentIn struct {
+	ID   int64 `path:"id" doc:"Driver ID"`
+	Body struct {
+		Kind       string     `json:"kind" enum:"bonus,tip" doc:"Bonus from the organization or tip from a customer"`
+		Amount     float64    `json:"amount" exclusiveMinimum:"0" doc:"Amount"`
+		RideID     *int64     `json:"ride_id,omitempty" doc:"Ride the adjustment is for, must be driven by the driver"`
+		OrderID    *int64     `json:"order_id,omitempty" doc:"Order the adjustment is for"`
+		Comment    string     `json:"comment,omitempty" maxLength:"1000" doc:"Comment"`
+		OccurredAt *time.Time `json:"occurred_at,omitempty" doc:"When the adjustment counts in payroll, defaults to the ride return or now"`
+	}
+}
+
+type listEarningAdjustmentsIn struct {
+	ID   int64  `path:"id" doc:"Driver ID"`
+	From string `query:"from" format:"date" doc:"First day, defaults to 30 days ago"`
+	To   string `query:"to" format:"date" doc:"Last day, defaults to today"`
+}
+
+type earningAdjustmentsOut struct {
+	Body struct {
+		Adjustments []earningAdjustment `json:"adjustments" doc:"Bonuses and tips of the driver"`
+	}
+}
+
+type deleteEarningAdjustmentIn struct {
+	ID           int64 `path:"id" doc:"Driver ID"`
+	AdjustmentID int64 `path:"adjustment_id" doc:"Adjustment ID"`
+}
+
+type rideEarningsOut struct {
+	Body struct {
+		RideID       int64               `json:"ride_id" doc:"Ride ID"`
+		DriverID     int64               `json:"driver_id" doc:"Driver of the ride"`
+		PayRuleID    *int64              `json:"pay_rule_id" doc:"Pay rule applied, empty when none was configured"`
+		Orders       int32               `json:"orders" doc:"Delivered orders"`
+		Distance     float64             `json:"distance" doc:"Distance driven in meters"`
+		Duration     int32               `json:"duration" doc:"Seconds on the road"`
+		OrderPay     float64             `json:"order_pay" doc:"Pay for delivered orders"`
+		DistancePay  float64             `json:"distance_pay" doc:"Pay for the distance"`
+		TimePay      float64             `json:"time_pay" doc:"Pay for the time on the road"`
+		GuaranteePay float64             `json:"guarantee_pay" doc:"Top up to the guaranteed pay of a ride"`
+		Earned       float64             `json:"earned" doc:"Pay of the ride before bonuses and tips"`
+		Adjustments  []earningAdjustment `json:"adjustments" doc:"Bonuses and tips for the ride"`
+		Total        float64             `json:"total" doc:"Pay of the ride with bonuses and tips"`
+		CalculatedAt time.Time           `json:"calculated_at" doc:"When the pay was calculated"`
+	}
+}
+
+type payrollIn struct {
+	From     string `query:"from" format:"date" doc:"First day of the report, defaults to 30 days ago"`
+	To       string `query:"to" format:"date" doc:"Last day of the report, defaults to today"`
+	DriverID int64  `query:"driver_id" doc:"Only this driver"`
+	BranchID int64  `query:"branch_id" doc:"Only rides of this branch"`
+}
+
+type payrollLine struct {
+	DriverID     int64   `json:"driver_id" doc:"Driver ID"`
+	DriverName   string  `json:"driver_name" doc:"Driver name"`
+	Rides        int64   `json:"rides" doc:"Returned rides"`
+	Orders       int64   `json:"orders" doc:"Delivered orders"`
+	Distance     float64 `json:"distance" doc:"Distance driven in kilometers"`
+	Hours        float64 `json:"hours" doc:"Hours on the road"`
+	OrderPay     float64 `json:"order_pay" doc:"Pay for delivered orders"`
+	DistancePay  float64 `json:"distance_pay" doc:"Pay for the distance"`
+	TimePay      float64 `json:"time_pay" doc:"Pay for the time on the road"`
+	GuaranteePay float64 `json:"guarantee_pay" doc:"Top ups to the guaranteed pay of rides"`
+	Bonuses      float64 `json:"bonuses" doc:"Bonuses"`
+	Tips         float64 `json:"tips" doc:"Tips"`
+	Total        float64 `json:"total" doc:"Pay for the period"`
+}
+
+type payrollOut struct {
+	Body struct {
+		From    string        `json:"from" format:"date" doc:"First day of the report"`
+		To      string        `json:"to" format:"date" doc:"Last day of the report"`
+		Drivers []payrollLine `json:"drivers" doc:"Pay per driver"`
+		Total   float64       `json:"total" doc:"Pay of every driver"`
+	}
+}
+
+type payrollExportOut struct {
+	ContentType        string `header:"Content-Type"`
+	ContentDisposition string `header:"Content-Disposition"`
+	Body               []byte
+}
+
+// payrollColumns is the header row of the payroll export
+var payrollColumns = []string{
+	"driver_id", "driver_name", "rides", "orders", "distance_km", "hours",
+	"order_pay", "distance_pay", "time_pay", "guarantee_pay", "bonuses", "tips", "total",
+}
+
+// ListPayRules lists how couriers of the organization and its branches are
+// paid
+func ListPayRules(ctx context.Context, _ *struct{}) (*payRulesOut, error) {
+	rules, err := db.Repository.ListPayRules(ctx, organizationID(ctx))
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list pay rules: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get pay rules", err)
+	}
+
+	var resp payRulesOut
+	resp.Body.Rules = make([]payRule, 0, len(rules))
+	for _, rule := range rules {
+		resp.Body.Rules = append(resp.Body.Rules, newPayRule(rule))
+	}
+
+	return &resp, nil
+}
+
+// UpdatePayRule sets how couriers of the organization or of a branch are
+// paid. Rides returned afterwards are paid by the new rule.
+func UpdatePayRule(ctx context.Context, in *updatePayRuleIn) (*payRuleOut, error) {
+	if !isDispatcher(ctx) {
+		return nil, huma.Error403Forbidden("only admins and dispatchers can change pay rules")
+	}
+
+	orgID := organizationID(ctx)
+	if err := checkDriverBranch(ctx, orgID, in.Body.BranchID); err != nil {
+		return nil, err
+	}
+
+	rule, err := db.Repository.UpsertPayRule(ctx, db.UpsertPayRuleParams{
+		OrganizationID: orgID,
+		BranchID:       in.Body.BranchID,
+		PerOrder:       db.NumericFromFloat(in.Body.PerOrder),
+		PerKm:          db.NumericFromFloat(in.Body.PerKm),
+		PerHour:        db.NumericFromFloat(in.Body.PerHour),
+		MinimumPerRide: db.NumericFromFloat(in.Body.MinimumPerRide),
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to update pay rule: %v", err)
+		return nil, huma.Error500InternalServerError("failed to update pay rule", err)
+	}
+
+	return &payRuleOut{Body: newPayRule(rule)}, nil
+}
+
+// DeletePayRule removes a pay rule. Branches without a rule fall back to the
+// organization rule.
+func DeletePayRule(ctx context.Context, in *idPathIn) (*successOut, error) {
+	if !isDispatcher(ctx) {
+		return nil, huma.Error403Forbidden("only admins and dispatchers can change pay rules")
+	}
+
+	rows, err := db.Repository.DeletePayRule(ctx, db.DeletePayRuleParams{
+		ID:             in.ID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to delete pay rule: %v", err)
+		return nil, huma.Error500InternalServerError("failed to delete pay rule", err)
+	}
+	if rows == 0 {
+		return nil, huma.Error404NotFound("pay rule not found")
+	}
+
+	var resp successOut
+	resp.Body.Success = true
+	return &resp, nil
+}
+
+// GetRideEarnings returns what the driver earned for a returned ride
+func GetRideEarnings(ctx context.Context, in *idPathIn) (*rideEarningsOut, error) {
+	ride, err := getOrganizationRide(ctx, db.Repository, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	earnings, err := db.Repository.GetRideEarnings(ctx, ride.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("ride earnings not found")
+		}
+		log.SugaredLogger.Errorf("failed to get ride earnings: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get ride earnings", err)
+	}
+	if err := checkEarningsAccess(ctx, earnings.DriverID); err != nil {
+		return nil, err
+	}
+
+	return buildRideEarningsResponse(ctx, earnings)
+}
+
+// RecalculateRideEarnings calculates the earnings of a returned ride again
+// with the current pay rule
+func RecalculateRideEarnings(ctx context.Context, in *idPathIn) (*rideEarningsOut, error) {
+	if !isDispatcher(ctx) {
+		return nil, huma.Error403Forbidden("only admins and dispatchers can recalculate earnings")
+	}
+
+	ride, err := getOrganizationRide(ctx, db.Repository, in.ID)
+	if err != nil {
+		return nil, err
+	}
+	if rides.Status(ride.Status) != rides.StatusReturned {
+		return nil, huma.Error409Conflict(fmt.Sprintf("ride is %s", ride.Status))
+	}
+	if ride.DriverID == nil {
+		return nil, huma.Error409Conflict("ride has no driver")
+	}
+
+	earnings, err := calculateRideEarnings(ctx, db.Repository, organizationID(ctx), ride)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to calculate ride earnings: %v", err)
+		return nil, huma.Error500InternalServerError("failed to calculate ride earnings", err)
+	}
+
+	return buildRideEarningsResponse(ctx, earnings)
+}
+
+// CreateEarningAdjustment records a bonus or a tip of a driver
+func CreateEarningAdjustment(ctx context.Context, in *createEarningAdjustmentIn) (*earningAdjustmentOut, error) {
+	if !isDispatcher(ctx) {
+		return nil, huma.Error403Forbidden("only admins and dispatchers can record bonuses and tips")
+	}
+
+	orgID := organizationID(ctx)
+	userID, _ := ctx.Value("user_id").(int64)
+
+	if _, err := db.Repository.GetDriver(ctx, db.GetDriverParams{
+		ID:             in.ID,
+		OrganizationID: orgID,
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("driver not found")
+		}
+		log.SugaredLogger.Errorf("failed to get driver: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create adjustment", err)
+	}
+
+	occurredAt := time.Now().UTC()
+	if in.Body.RideID != nil {
+		ride, err := getOrganizationRide(ctx, db.Repository, *in.Body.RideID)
+		if err != nil {
+			return nil, err
+		}
+		if ride.DriverID == nil || *ride.DriverID != in.ID {
+			return nil, huma.Error422UnprocessableEntity("ride was not driven by the driver")
+		}
+		if ride.EndedAt.Valid {
+			occurredAt = ride.EndedAt.Time
+		}
+	}
+	if in.Body.OrderID != nil {
+		if _, err := db.Repository.GetOrder(ctx, db.GetOrderParams{
+			ID:             *in.Body.OrderID,
+			OrganizationID: orgID,
+		}); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, huma.Error422UnprocessableEntity("order not found")
+			}
+			log.SugaredLogger.Errorf("failed to get order: %v", err)
+			return nil, huma.Error500InternalServerError("failed to create adjustment", err)
+		}
+	}
+	if in.Body.OccurredAt != nil {
+		occurredAt = in.Body.OccurredAt.UTC()
+	}
+
+	adjustment, err := db.Repository.CreateEarningAdjustment(ctx, db.CreateEarningAdjustmentParams{
+		DriverID:   in.ID,
+		Kind:       in.Body.Kind,
+		Amount:     db.NumericFromFloat(payment.Round(in.Body.Amount)),
+		RideID:     in.Body.RideID,
+		OrderID:    in.Body.OrderID,
+		Comment:    in.Body.Comment,
+		OccurredAt: pgtype.Timestamp{Time: occurredAt, Valid: true},
+		CreatedBy:  &userID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to create earning adjustment: %v", err)
+		return nil, huma.Error500InternalServerError("failed to create adjustment", err)
+	}
+
+	return &earningAdjustmentOut{Body: newEarningAdjustment(adjustment)}, nil
+}
+
+// ListEarningAdjustments lists the bonuses and tips of a driver in a period
+func ListEarningAdjustments(ctx context.Context, in *listEarningAdjustmentsIn) (*earningAdjustmentsOut, error) {
+	if err := checkEarningsAccess(ctx, in.ID); err != nil {
+		return nil, err
+	}
+
+	from, to, err := reportPeriod(in.From, in.To)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments, err := db.Repository.ListDriverEarningAdjustments(ctx, db.ListDriverEarningAdjustmentsParams{
+		DriverID:       in.ID,
+		OrganizationID: organizationID(ctx),
+		DateFrom:       pgtype.Date{Time: from, Valid: true},
+		DateTo:         pgtype.Date{Time: to, Valid: true},
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list earning adjustments: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get adjustments", err)
+	}
+
+	var resp earningAdjustmentsOut
+	resp.Body.Adjustments = newEarningAdjustments(adjustments)
+	return &resp, nil
+}
+
+// DeleteEarningAdjustment removes a bonus or a tip recorded by mistake
+func DeleteEarningAdjustment(ctx context.Context, in *deleteEarningAdjustmentIn) (*successOut, error) {
+	if !isDispatcher(ctx) {
+		return nil, huma.Error403Forbidden("only admins and dispatchers can remove bonuses and tips")
+	}
+
+	rows, err := db.Repository.DeleteEarningAdjustment(ctx, db.DeleteEarningAdjustmentParams{
+		ID:             in.AdjustmentID,
+		DriverID:       in.ID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to delete earning adjustment: %v", err)
+		return nil, huma.Error500InternalServerError("failed to delete adjustment", err)
+	}
+	if rows == 0 {
+		return nil, huma.Error404NotFound("adjustment not found")
+	}
+
+	var resp successOut
+	resp.Body.Success = true
+	return &resp, nil
+}
+
+// GetPayroll sums what each driver earned in a period. Rides count on the
+// day they returned, in the organization's timezone.
+func GetPayroll(ctx context.Context, in *payrollIn) (*payrollOut, error) {
+	if !isDispatcher(ctx) {
+		return nil, huma.Error403Forbidden("only admins and dispatchers can see the payroll")
+	}
+
+	from, to, lines, err := buildPayroll(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp payrollOut
+	resp.Body.From = from.Format(time.DateOnly)
+	resp.Body.To = to.Format(time.DateOnly)
+	resp.Body.Drivers = lines
+	for _, line := range lines {
+		resp.Body.Total += line.Total
+	}
+	resp.Body.Total = payment.Round(resp.Body.Total)
+
+	return &resp, nil
+}
+
+// ExportPayroll returns the payroll of a period as a CSV file
+func ExportPayroll(ctx context.Context, in *payrollIn) (*payrollExportOut, error) {
+	if !isDispatcher(ctx) {
+		return nil, huma.Error403Forbidden("only admins and dispatchers can export the payroll")
+	}
+
+	from, to, lines, err := buildPayroll(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(payrollColumns)
+	for _, line := range lines {
+		_ = w.Write([]string{
+			strconv.FormatInt(line.DriverID, 10),
+			line.DriverName,
+			strconv.FormatInt(line.Rides, 10),
+			strconv.FormatInt(line.Orders, 10),
+			formatAmount(line.Distance),
+			formatAmount(line.Hours),
+			formatAmount(line.OrderPay),
+			formatAmount(line.DistancePay),
+			formatAmount(line.TimePay),
+			formatAmount(line.GuaranteePay),
+			formatAmount(line.Bonuses),
+			formatAmount(line.Tips),
+			formatAmount(line.Total),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.SugaredLogger.Errorf("failed to write payroll csv: %v", err)
+		return nil, huma.Error500InternalServerError("failed to export payroll", err)
+	}
+
+	return &payrollExportOut{
+		ContentType: "text/csv; charset=utf-8",
+		ContentDisposition: fmt.Sprintf(`attachment; filename="payroll-%s-%s.csv"`,
+			from.Format(time.DateOnly), to.Format(time.DateOnly)),
+		Body: buf.Bytes(),
+	}, nil
+}
+
+// Helper function to let admins, dispatchers and the driver themselves see
+// the earnings of a driver
+func checkEarningsAccess(ctx context.Context, driverID int64) error {
+	if isDispatcher(ctx) {
+		return nil
+	}
+
+	userID, _ := ctx.Value("user_id").(int64)
+	driver, err := db.Repository.GetDriverByUserID(ctx, db.GetDriverByUserIDParams{
+		UserID:         userID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.SugaredLogger.Errorf("failed to get driver of user: %v", err)
+		return huma.Error500InternalServerError("failed to check access to earnings", err)
+	}
+	if err != nil || driver.ID != driverID {
+		return huma.Error403Forbidden("only admins, dispatchers and the driver can see earnings")
+	}
+	return nil
+}
+
+// Helper function to calculate the earnings of a ride that just returned.
+// Failures leave the ride without earnings until they are recalculated, they
+// must not keep the ride from returning. Rides whose driver was removed earn
+// nothing.
+func recordRideEarnings(ctx context.Context, orgID int64, ride db.Ride) {
+	if ride.DriverID == nil {
+		return
+	}
+	if _, err := calculateRideEarnings(ctx, db.Repository, orgID, ride); err != nil {
+		log.SugaredLogger.Errorf("failed to calculate ride %d earnings: %v", ride.ID, err)
+	}
+}
+
+// Helper function to calculate and store the earnings of a returned ride by
+// the pay rule of its branch. Distance is taken from the driver track, or
+// from the planned route when the driver reported too few positions.
+func calculateRideEarnings(ctx context.Context, q *db.Queries, orgID int64, ride db.Ride) (db.RideEarning, error) {
+	if ride.DriverID == nil {
+		return db.RideEarning{}, errors.New("ride has no driver")
+	}
+
+	var (
+		rule   payroll.Rule
+		ruleID *int64
+	)
+	stored, err := q.GetBranchPayRule(ctx, db.GetBranchPayRuleParams{
+		OrganizationID: orgID,
+		BranchID:       &ride.BranchID,
+	})
+	switch {
+	case err == nil:
+		ruleID = &stored.ID
+		rule = payroll.Rule{
+			PerOrder:       numericValue(stored.PerOrder),
+			PerKm:          numericValue(stored.PerKm),
+			PerHour:        numericValue(stored.PerHour),
+			MinimumPerRide: numericValue(stored.MinimumPerRide),
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return db.RideEarning{}, fmt.Errorf("failed to get pay rule: %w", err)
+	}
+
+	delivered, err := q.CountDeliveredRideStops(ctx, ride.ID)
+	if err != nil {
+		return db.RideEarning{}, fmt.Errorf("failed to count delivered stops: %w", err)
+	}
+
+	distance, err := rideDistance(ctx, q, orgID, ride.ID)
+	if err != nil {
+		return db.RideEarning{}, err
+	}
+
+	var duration time.Duration
+	if ride.DepartedAt.Valid && ride.EndedAt.Valid {
+		duration = ride.EndedAt.Time.Sub(ride.DepartedAt.Time).Round(time.Second)
+	}
+
+	earnings := payroll.Calculate(rule, payroll.Ride{
+		Orders:   int(delivered),
+		Distance: distance,
+		Duration: duration,
+	})
+
+	saved, err := q.UpsertRideEarnings(ctx, db.UpsertRideEarningsParams{
+		RideID:       ride.ID,
+		DriverID:     *ride.DriverID,
+		PayRuleID:    ruleID,
+		Orders:       int32(delivered),
+		Distance:     distance,
+		Duration:     int32(duration / time.Second),
+		OrderPay:     db.NumericFromFloat(earnings.OrderPay),
+		DistancePay:  db.NumericFromFloat(earnings.DistancePay),
+		TimePay:      db.NumericFromFloat(earnings.TimePay),
+		GuaranteePay: db.NumericFromFloat(earnings.GuaranteePay),
+		Total:        db.NumericFromFloat(earnings.Total),
+	})
+	if err != nil {
+		return db.RideEarning{}, fmt.Errorf("failed to store ride earnings: %w", err)
+	}
+
+	return saved, nil
+}
+
+// Helper function to measure the distance a ride covered in meters
+func rideDistance(ctx context.Context, q *db.Queries, orgID, rideID int64) (float64, error) {
+	positions, err := q.ListRideTrack(ctx, &rideID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list ride track: %w", err)
+	}
+	if len(positions) >= 2 {
+		return trackDistance(positions), nil
+	}
+
+	stops, err := q.ListRideStops(ctx, db.ListRideStopsParams{
+		RideID:         rideID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list ride stops: %w", err)
+	}
+
+	var distance float64
+	for _, stop := range stops {
+		if stop.LegDistance != nil {
+			distance += *stop.LegDistance
+		}
+	}
+	return distance, nil
+}
+
+// Helper function to build the earnings response of a ride with its bonuses
+// and tips
+func buildRideEarningsResponse(ctx context.Context, earnings db.RideEarning) (*rideEarningsOut, error) {
+	adjustments, err := db.Repository.ListRideEarningAdjustments(ctx, &earnings.RideID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list ride earning adjustments: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get ride earnings", err)
+	}
+
+	var resp rideEarningsOut
+	resp.Body.RideID = earnings.RideID
+	resp.Body.DriverID = earnings.DriverID
+	resp.Body.PayRuleID = earnings.PayRuleID
+	resp.Body.Orders = earnings.Orders
+	resp.Body.Distance = earnings.Distance
+	resp.Body.Duration = earnings.Duration
+	resp.Body.OrderPay = numericValue(earnings.OrderPay)
+	resp.Body.DistancePay = numericValue(earnings.DistancePay)
+	resp.Body.TimePay = numericValue(earnings.TimePay)
+	resp.Body.GuaranteePay = numericValue(earnings.GuaranteePay)
+	resp.Body.Earned = numericValue(earnings.Total)
+	resp.Body.Adjustments = newEarningAdjustments(adjustments)
+	resp.Body.CalculatedAt = earnings.CalculatedAt.Time
+
+	total := resp.Body.Earned
+	for _, adjustment := range resp.Body.Adjustments {
+		total += adjustment.Amount
+	}
+	resp.Body.Total = payment.Round(total)
+
+	return &resp, nil
+}
+
+// Helper function to sum ride earnings, bonuses and tips per driver over the
+// period of a payroll request
+func buildPayroll(ctx context.Context, in *payrollIn) (time.Time, time.Time, []payrollLine, error) {
+	from, to, err := reportPeriod(in.From, in.To)
+	if err != nil {
+		return time.Time{}, time.Time{}, nil, err
+	}
+
+	orgID := organizationID(ctx)
+	var driverID, branchID *int64
+	if in.DriverID != 0 {
+		driverID = &in.DriverID
+	}
+	if in.BranchID != 0 {
+		branchID = &in.BranchID
+	}
+	dateFrom := pgtype.Date{Time: from, Valid: true}
+	dateTo := pgtype.Date{Time: to, Valid: true}
+
+	rideRows, err := db.Repository.ListPayrollRides(ctx, db.ListPayrollRidesParams{
+		OrganizationID: orgID,
+		DateFrom:       dateFrom,
+		DateTo:         dateTo,
+		DriverID:       driverID,
+		BranchID:       branchID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list payroll rides: %v", err)
+		return time.Time{}, time.Time{}, nil, huma.Error500InternalServerError("failed to get payroll", err)
+	}
+
+	adjustmentRows, err := db.Repository.ListPayrollAdjustments(ctx, db.ListPayrollAdjustmentsParams{
+		OrganizationID: orgID,
+		DateFrom:       dateFrom,
+		DateTo:         dateTo,
+		DriverID:       driverID,
+		BranchID:       branchID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list payroll adjustments: %v", err)
+		return time.Time{}, time.Time{}, nil, huma.Error500InternalServerError("failed to get payroll", err)
+	}
+
+	lines := make(map[int64]*payrollLine)
+	line := func(driverID int64) *payrollLine {
+		if l, ok := lines[driverID]; ok {
+			return l
+		}
+		l := &payrollLine{DriverID: driverID}
+		lines[driverID] = l
+		return l
+	}
+	for _, row := range rideRows {
+		l := line(row.DriverID)
+		l.Rides = row.RideCount
+		l.Orders = row.Orders
+		l.Distance = math.Round(row.Distance/100) / 10
+		l.Hours = math.Round(time.Duration(row.Duration*int64(time.Second)).Hours()*100) / 100
+		l.OrderPay = payment.Round(numericValue(row.OrderPay))
+		l.DistancePay = payment.Round(numericValue(row.DistancePay))
+		l.TimePay = payment.Round(numericValue(row.TimePay))
+		l.GuaranteePay = payment.Round(numericValue(row.GuaranteePay))
+		l.Total += numericValue(row.Total)
+	}
+	for _, row := range adjustmentRows {
+		l := line(row.DriverID)
+		l.Bonuses = payment.Round(numericValue(row.Bonuses))
+		l.Tips = payment.Round(numericValue(row.Tips))
+		l.Total += l.Bonuses + l.Tips
+	}
+
+	driverIDs := make([]int64, 0, len(lines))
+	for id := range lines {
+		driverIDs = append(driverIDs, id)
+	}
+	drivers, err := db.Repository.ListPayrollDrivers(ctx, db.ListPayrollDriversParams{
+		OrganizationID: orgID,
+		DriverIds:      driverIDs,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list payroll drivers: %v", err)
+		return time.Time{}, time.Time{}, nil, huma.Error500InternalServerError("failed to get payroll", err)
+	}
+	for _, driver := range drivers {
+		lines[driver.ID].DriverName = formatPersonName(driver.Name, driver.Surname)
+	}
+
+	result := make([]payrollLine, 0, len(lines))
+	for _, l := range lines {
+		l.Total = payment.Round(l.Total)
+		result = append(result, *l)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DriverName != result[j].DriverName {
+			return result[i].DriverName < result[j].DriverName
+		}
+		return result[i].DriverID < result[j].DriverID
+	})
+
+	return from, to, result, nil
+}
+
+// Helper function to format an amount for export with two decimal places
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// Helper function to convert a stored pay rule to its response representation
+func newPayRule(rule db.PayRule) payRule {
+	return payRule{
+		ID: rule.ID,
+		payRuleBody: payRuleBody{
+			BranchID:       rule.BranchID,
+			PerOrder:       numericValue(rule.PerOrder),
+			PerKm:          numericValue(rule.PerKm),
+			PerHour:        numericValue(rule.PerHour),
+			MinimumPerRide: numericValue(rule.MinimumPerRide),
+		},
+		UpdatedAt: rule.UpdatedAt.Time,
+	}
+}
+
+// Helper function to convert stored adjustments to their response
+// representation
+func newEarningAdjustments(adjustments []db.EarningAdjustment) []earningAdjustment {
+	result := make([]earningAdjustment, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		result = append(result, newEarningAdjustment(adjustment))
+	}
+	return result
+}
+
+// Helper function to convert a stored adjustment to its response
+// representation
+func newEarningAdjustment(adjustment db.EarningAdjustment) earningAdjustment {
+	return earningAdjustment{
+		ID:         adjustment.ID,
+		DriverID:   adjustment.DriverID,
+		Kind:       adjustment.Kind,
+		Amount:     numericValue(adjustment.Amount),
+		RideID:     adjustment.RideID,
+		OrderID:    adjustment.OrderID,
+		Comment:    adjustment.Comment,
+		OccurredAt: adjustment.OccurredAt.Time,
+		CreatedBy:  adjustment.CreatedBy,
+	}
+}
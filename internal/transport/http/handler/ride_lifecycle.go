@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/orderstatus"
+	"smartDriver/pkg/rides"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type changeRideStatusIn struct {
+	ID int64 `path:"id" doc:"Ride ID"`
+	rideVersionIn
+	Body struct {
+		Status string `json:"status" enum:"departed,in_progress,returned" doc:"New ride status"`
+	}
+}
+
+type changeRideStopStatusIn struct {
+	ID      int64 `path:"id" doc:"Ride ID"`
+	OrderID int64 `path:"order_id" doc:"Order ID of the stop"`
+	rideVersionIn
+	Body struct {
+		Status string `json:"status" enum:"arrived,delivered,failed" doc:"New stop status"`
+		Reason string `json:"reason,omitempty" maxLength:"500" doc:"Why the order could not be delivered"`
+	}
+}
+
+// ChangeRideStatus moves a ride along its lifecycle. Departing puts the
+// orders on the way, returning requires every stop to be delivered or failed.
+func ChangeRideStatus(ctx context.Context, in *changeRideStatusIn) (*rideOut, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to change ride status", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
+
+	ride, err := lockRide(ctx, qtx, in.ID, in.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := requestActor(ctx, qtx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	var change rides.StatusChange
+	now := time.Now().UTC()
+	switch to := rides.Status(in.Body.Status); to {
+	case rides.StatusDeparted:
+		ride, change, err = departRide(ctx, qtx, orgID, ride, now, actor)
+	case rides.StatusReturned:
+		ride, change, err = returnRide(ctx, qtx, ride, now)
+	default:
+		ride, change, err = moveRide(ctx, qtx, ride, to, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to change ride status", err)
+	}
+
+	if err := rides.PublishStatusChanges(ctx, centrifugo.Default, orgID, []rides.StatusChange{change}); err != nil {
+		log.SugaredLogger.Errorf("failed to publish ride status change: %v", err)
+	}
+
+	if rides.Status(ride.Status) == rides.StatusReturned {
+		recordRideEarnings(ctx, orgID, ride)
+	}
+
+	return buildRideResponse(ctx, *db.Repository, orgID, ride)
+}
+
+// ChangeRideStopStatus records the driver reaching or finishing a stop of a
+// ride on the road. The first stop reached starts the ride.
+func ChangeRideStopStatus(ctx context.Context, in *changeRideStopStatusIn) (*rideOut, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to change stop status", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
+
+	ride, err := lockRide(ctx, qtx, in.ID, in.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := requestActor(ctx, qtx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	ride, changes, err := advanceRideStop(ctx, qtx, orgID, ride, in.OrderID, rides.StopStatus(in.Body.Status), in.Body.Reason, time.Now().UTC(), actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to change stop status", err)
+	}
+
+	if err := rides.PublishStatusChanges(ctx, centrifugo.Default, orgID, changes); err != nil {
+		log.SugaredLogger.Errorf("failed to publish ride status change: %v", err)
+	}
+
+	return buildRideResponse(ctx, *db.Repository, orgID, ride)
+}
+
+// Helper function to send a planned ride on the road and put its orders on
+// the way
+func departRide(ctx context.Context, q *db.Queries, orgID int64, ride db.Ride, at time.Time, actor orderActor) (db.Ride, rides.StatusChange, error) {
+	if ride.DriverID == nil {
+		return db.Ride{}, rides.StatusChange{}, huma.Error409Conflict("ride has no driver")
+	}
+	if !rides.CanTransition(rides.Status(ride.Status), rides.StatusDeparted) {
+		return db.Ride{}, rides.StatusChange{}, huma.Error409Conflict(fmt.Sprintf("ride status cannot change from %s to %s", ride.Status, rides.StatusDeparted))
+	}
+	if err := departRideOrders(ctx, q, orgID, ride.ID, actor); err != nil {
+		return db.Ride{}, rides.StatusChange{}, err
+	}
+	return moveRide(ctx, q, ride, rides.StatusDeparted, at)
+}
+
+// Helper function to end a ride once every stop is delivered or failed
+func returnRide(ctx context.Context, q *db.Queries, ride db.Ride, at time.Time) (db.Ride, rides.StatusChange, error) {
+	unfinished, err := q.ListUnfinishedRideStops(ctx, ride.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list unfinished ride stops: %v", err)
+		return db.Ride{}, rides.StatusChange{}, huma.Error500InternalServerError("failed to change ride status", err)
+	}
+	if len(unfinished) > 0 {
+		return db.Ride{}, rides.StatusChange{}, huma.Error409Conflict(fmt.Sprintf("stop of order %d is not finished", unfinished[0]))
+	}
+	return moveRide(ctx, q, ride, rides.StatusReturned, at)
+}
+
+// Helper function to move a stop of a ride on the road to another status.
+// Reaching the first stop starts the ride, finishing a stop finishes its
+// order.
+func advanceRideStop(ctx context.Context, q *db.Queries, orgID int64, ride db.Ride, orderID int64, to rides.StopStatus, reason string, at time.Time, actor orderActor) (db.Ride, []rides.StatusChange, error) {
+	if !rides.Status(ride.Status).IsOnRoad() {
+		return db.Ride{}, nil, huma.Error409Conflict(fmt.Sprintf("ride is %s", ride.Status))
+	}
+
+	stop, err := q.GetRideStop(ctx, db.GetRideStopParams{
+		RideID:  ride.ID,
+		OrderID: orderID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Ride{}, nil, huma.Error404NotFound("order is not on the ride")
+		}
+		log.SugaredLogger.Errorf("failed to get ride stop: %v", err)
+		return db.Ride{}, nil, huma.Error500InternalServerError("failed to change stop status", err)
+	}
+
+	from := rides.StopStatus(stop.StopStatus)
+	if !rides.CanAdvanceStop(from, to) {
+		return db.Ride{}, nil, huma.Error409Conflict(fmt.Sprintf("stop status cannot change from %s to %s", from, to))
+	}
+
+	stamp := pgtype.Timestamp{Time: at, Valid: true}
+	params := db.UpdateRideStopStatusParams{
+		StopStatus:  string(to),
+		DeliveredAt: stop.DeliveredAt,
+		FailedAt:    stop.FailedAt,
+		RideID:      ride.ID,
+		OrderID:     stop.OrderID,
+		FromStatus:  string(from),
+	}
+	switch to {
+	case rides.StopArrived:
+		params.ArrivedAt = stamp
+	case rides.StopDelivered:
+		params.DeliveredAt = stamp
+	case rides.StopFailed:
+		params.FailedAt = stamp
+		params.FailureReason = reason
+	}
+
+	if _, err := q.UpdateRideStopStatus(ctx, params); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Ride{}, nil, huma.Error409Conflict("stop status changed concurrently")
+		}
+		log.SugaredLogger.Errorf("failed to update ride stop status: %v", err)
+		return db.Ride{}, nil, huma.Error500InternalServerError("failed to change stop status", err)
+	}
+
+	changes := []rides.StatusChange{{
+		Type:      rides.EventStopStatusChanged,
+		RideID:    ride.ID,
+		OrderID:   &stop.OrderID,
+		OldStatus: string(from),
+		NewStatus: string(to),
+		ChangedAt: at,
+	}}
+
+	if rides.Status(ride.Status) == rides.StatusDeparted {
+		var change rides.StatusChange
+		ride, change, err = moveRide(ctx, q, ride, rides.StatusInProgress, at)
+		if err != nil {
+			return db.Ride{}, nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	if to.IsFinished() {
+		if err := finishStopOrder(ctx, q, orgID, stop.OrderID, to, actor); err != nil {
+			return db.Ride{}, nil, err
+		}
+	}
+
+	return ride, changes, nil
+}
+
+// Helper function to move a ride to another status and stamp the transition.
+// The ride must be locked with lockRide, which bumps its version.
+func moveRide(ctx context.Context, q *db.Queries, ride db.Ride, to rides.Status, at time.Time) (db.Ride, rides.StatusChange, error) {
+	from := rides.Status(ride.Status)
+	if !rides.CanTransition(from, to) {
+		return db.Ride{}, rides.StatusChange{}, huma.Error409Conflict(fmt.Sprintf("ride status cannot change from %s to %s", from, to))
+	}
+
+	stamp := pgtype.Timestamp{Time: at, Valid: true}
+	params := db.UpdateRideStatusParams{
+		Status:     string(to),
+		ID:         ride.ID,
+		FromStatus: string(from),
+	}
+	switch to {
+	case rides.StatusDeparted:
+		params.DepartedAt = stamp
+	case rides.StatusInProgress:
+		params.StartedAt = stamp
+	case rides.StatusReturned, rides.StatusCancelled:
+		params.EndedAt = stamp
+	}
+
+	updated, err := q.UpdateRideStatus(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Ride{}, rides.StatusChange{}, huma.Error409Conflict("ride status changed concurrently")
+		}
+		log.SugaredLogger.Errorf("failed to update ride status: %v", err)
+		return db.Ride{}, rides.StatusChange{}, huma.Error500InternalServerError("failed to change ride status", err)
+	}
+
+	return updated, rides.StatusChange{
+		Type:      rides.EventRideStatusChanged,
+		RideID:    ride.ID,
+		OldStatus: string(from),
+		NewStatus: string(to),
+		ChangedAt: at,
+	}, nil
+}
+
+// Helper function to put the orders of a departing ride on the way
+func departRideOrders(ctx context.Context, q *db.Queries, orgID, rideID int64, actor orderActor) error {
+	orders, err := q.GetOrdersByRideID(ctx, db.GetOrdersByRideIDParams{
+		RideID:         rideID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get orders for ride: %v", err)
+		return huma.Error500InternalServerError("failed to change ride status", err)
+	}
+	if len(orders) == 0 {
+		return huma.Error409Conflict("ride has no orders")
+	}
+
+	for _, order := range orders {
+		if orderstatus.CanTransition(orderstatus.Status(order.Status), orderstatus.OnWay) {
+			if err := setOrderStatus(ctx, q, order, orderstatus.OnWay, actor); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Helper function to move the order of a finished stop to the matching
+// terminal status. The stop stays on the ride as its history.
+func finishStopOrder(ctx context.Context, q *db.Queries, orgID, orderID int64, stop rides.StopStatus, actor orderActor) error {
+	order, err := q.GetOrder(ctx, db.GetOrderParams{
+		ID:             orderID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get order: %v", err)
+		return huma.Error500InternalServerError("failed to change stop status", err)
+	}
+
+	to := orderstatus.Delivered
+	if stop == rides.StopFailed {
+		to = orderstatus.Failed
+	}
+	if !orderstatus.CanTransition(orderstatus.Status(order.Status), to) {
+		return nil
+	}
+	return setOrderStatus(ctx, q, order, to, actor)
+}
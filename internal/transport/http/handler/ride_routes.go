@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/rides"
+	"smartDriver/pkg/route"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// rideStop is an order in the visiting sequence of a ride
+type rideStop struct {
+	Sequence       int32      `json:"sequence" doc:"Position in the visiting sequence, starting from 1"`
+	OrderID        int64      `json:"order_id" doc:"Order ID"`
+	Distance       float64    `json:"distance" doc:"Estimated road distance from the previous stop, or from where the ride was when the route was planned, meters"`
+	PlannedArrival *time.Time `json:"planned_arrival" doc:"Arrival at the stop when the route was planned"`
+	ETA            *time.Time `json:"eta" doc:"Live estimate of the arrival at the stop, from the driver position once the ride departed"`
+	Late           bool       `json:"late" doc:"The driver is expected after the promised time"`
+	Status         string     `json:"status" enum:"pending,arrived,delivered,failed" doc:"Stop status"`
+	ArrivedAt      *time.Time `json:"arrived_at,omitempty" doc:"When the driver reached the stop"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" doc:"When the order was handed over"`
+	FailedAt       *time.Time `json:"failed_at,omitempty" doc:"When the driver gave up on the order"`
+	FailureReason  string     `json:"failure_reason,omitempty" doc:"Why the order could not be delivered"`
+}
+
+// OptimizeRideRoute recomputes the visiting sequence of an active ride from
+// the current time, e.g. after orders were finished or their promised time
+// changed
+func OptimizeRideRoute(ctx context.Context, in *idPathIn) (*rideOut, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to optimize ride route", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
+
+	ride, err := lockRide(ctx, qtx, in.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	if ride.EndedAt.Valid {
+		return nil, huma.Error400BadRequest("cannot optimize completed ride")
+	}
+
+	if err := sequenceRide(ctx, qtx, orgID, ride); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to optimize ride route", err)
+	}
+
+	return buildRideResponse(ctx, *db.Repository, orgID, ride)
+}
+
+// Helper function to compute the visiting sequence of the orders of a ride
+// starting now from where the ride is and store it on the ride. Finished
+// stops keep their place at the head of the sequence. The speed follows the
+// vehicle of the ride driver.
+func sequenceRide(ctx context.Context, q *db.Queries, orgID int64, ride db.Ride) error {
+	branch, err := q.GetBranch(ctx, db.GetBranchParams{
+		ID:             ride.BranchID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get ride branch: %v", err)
+		return huma.Error500InternalServerError("failed to plan ride route", err)
+	}
+
+	rows, err := q.ListRideStops(ctx, db.ListRideStopsParams{
+		RideID:         ride.ID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get orders for ride: %v", err)
+		return huma.Error500InternalServerError("failed to plan ride route", err)
+	}
+
+	finished := 0
+	stops := make([]route.Stop, 0, len(rows))
+	for _, row := range rows {
+		if rides.StopStatus(row.StopStatus).IsFinished() {
+			finished++
+			continue
+		}
+		stop := route.Stop{ID: row.Order.ID, Location: geo.PointFromPg(row.Order.Location)}
+		if row.Order.PromisedAt.Valid {
+			stop.PromisedAt = row.Order.PromisedAt.Time
+		}
+		stops = append(stops, stop)
+	}
+
+	profile, err := rideProfile(ctx, q, orgID, ride)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get ride vehicle profile: %v", err)
+		return huma.Error500InternalServerError("failed to plan ride route", err)
+	}
+
+	origin, err := rideOrigin(ctx, ride, geo.PointFromPg(branch.Location), rows)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get ride position: %v", err)
+		return huma.Error500InternalServerError("failed to plan ride route", err)
+	}
+
+	plan := route.Optimize(origin, stops, time.Now().UTC(), profile.Options())
+
+	for i, leg := range plan.Legs {
+		sequence := int32(finished + i + 1)
+		distance := leg.Distance
+		if err := q.UpdateRideStop(ctx, db.UpdateRideStopParams{
+			Sequence:       &sequence,
+			LegDistance:    &distance,
+			PlannedArrival: pgtype.Timestamp{Time: leg.Arrival, Valid: true},
+			RideID:         ride.ID,
+			OrderID:        leg.StopID,
+		}); err != nil {
+			log.SugaredLogger.Errorf("failed to update ride stop: %v", err)
+			return huma.Error500InternalServerError("failed to plan ride route", err)
+		}
+	}
+
+	if _, err := refreshRideETA(ctx, q, orgID, ride); err != nil {
+		log.SugaredLogger.Errorf("failed to estimate ride arrivals: %v", err)
+		return huma.Error500InternalServerError("failed to plan ride route", err)
+	}
+
+	return nil
+}
+
+// Helper function to find where the remaining route of a ride starts. A ride
+// on the road continues from the latest driver position, or from the last
+// finished stop when the driver reported none. Other rides start at the
+// branch.
+func rideOrigin(ctx context.Context, ride db.Ride, branch geo.Point, stops []db.ListRideStopsRow) (geo.Point, error) {
+	if !rides.Status(ride.Status).IsOnRoad() {
+		return branch, nil
+	}
+
+	if ride.DriverID != nil {
+		position, found, err := latestDriverPosition(ctx, *ride.DriverID)
+		if err != nil {
+			return geo.Point{}, err
+		}
+		if found {
+			return position.Location, nil
+		}
+	}
+
+	origin := branch
+	var finishedAt time.Time
+	for _, stop := range stops {
+		at := stop.DeliveredAt
+		if !at.Valid {
+			at = stop.FailedAt
+		}
+		if at.Valid && at.Time.After(finishedAt) {
+			origin = geo.PointFromPg(stop.Order.Location)
+			finishedAt = at.Time
+		}
+	}
+	return origin, nil
+}
+
+// Helper function to convert a ride stop to its response representation
+func newRideStop(row db.ListRideStopsRow) rideStop {
+	stop := rideStop{
+		OrderID:       row.Order.ID,
+		Status:        row.StopStatus,
+		FailureReason: row.FailureReason,
+	}
+	if row.ArrivedAt.Valid {
+		stop.ArrivedAt = &row.ArrivedAt.Time
+	}
+	if row.DeliveredAt.Valid {
+		stop.DeliveredAt = &row.DeliveredAt.Time
+	}
+	if row.FailedAt.Valid {
+		stop.FailedAt = &row.FailedAt.Time
+	}
+	if row.Sequence != nil {
+		stop.Sequence = *row.Sequence
+	}
+	if row.LegDistance != nil {
+		stop.Distance = *row.LegDistance
+	}
+	if row.PlannedArrival.Valid {
+		stop.PlannedArrival = &row.PlannedArrival.Time
+		stop.Late = row.Order.PromisedAt.Valid && row.PlannedArrival.Time.After(row.Order.PromisedAt.Time)
+	}
+	if row.Eta.Valid {
+		stop.ETA = &row.Eta.Time
+		stop.Late = row.Order.PromisedAt.Valid && row.Eta.Time.After(row.Order.PromisedAt.Time)
+	}
+	return stop
+}
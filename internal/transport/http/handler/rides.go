@@ -2,45 +2,133 @@ package handler
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
 	"smartDriver/pkg/log"
+	"smartDriver/pkg/orderstatus"
+	"smartDriver/pkg/rides"
+	"strings"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // Input/Output structures
 type createRideIn struct {
 	Body struct {
 		BranchID int64   `json:"branch_id" doc:"Branch ID"`
+		DriverID *int64  `json:"driver_id,omitempty" doc:"Driver of the ride"`
 		OrderIDs []int64 `json:"order_ids" doc:"List of order IDs to attach to the ride"`
 	}
 }
 
+// rideVersionIn carries the version of the ride a change is based on
+type rideVersionIn struct {
+	IfMatch string `header:"If-Match" doc:"ETag of the ride the change is based on. The change fails with 412 if the ride was modified since."`
+}
+
 type updateRideIn struct {
-	ID   int64 `path:"id" doc:"Ride ID"`
+	ID int64 `path:"id" doc:"Ride ID"`
+	rideVersionIn
 	Body struct {
+		DriverID *int64  `json:"driver_id,omitempty" doc:"New driver of the ride, the driver is kept when omitted"`
 		OrderIDs []int64 `json:"order_ids" doc:"List of order IDs to attach to the ride"`
 	}
 }
 
 type deleteRideIn struct {
 	ID int64 `path:"id" doc:"Ride ID"`
+	rideVersionIn
+}
+
+type addRideOrdersIn struct {
+	ID int64 `path:"id" doc:"Ride ID"`
+	rideVersionIn
+	Body struct {
+		OrderIDs []int64 `json:"order_ids" minItems:"1" doc:"Orders to add to the ride"`
+	}
+}
+
+type removeRideOrderIn struct {
+	ID      int64 `path:"id" doc:"Ride ID"`
+	OrderID int64 `path:"order_id" doc:"Order to remove from the ride"`
+	rideVersionIn
 }
 
 type rideOut struct {
+	ETag string `header:"ETag" doc:"Version of the ride, send it as If-Match to change the ride"`
 	Body struct {
-		ID        int64       `json:"id" doc:"Ride ID"`
-		BranchID  int64       `json:"branch_id" doc:"Branch ID"`
-		CreatedAt time.Time   `json:"created_at" doc:"Ride created timestamp"`
-		EndedAt   time.Time   `json:"ended_at,omitempty" doc:"Ride ended timestamp"`
-		Orders    []orderInfo `json:"orders,omitempty" doc:"List of orders attached to the ride"`
+		ID         int64           `json:"id" doc:"Ride ID"`
+		Version    int32           `json:"version" doc:"Incremented on every change of the ride"`
+		BranchID   int64           `json:"branch_id" doc:"Branch ID"`
+		DriverID   *int64          `json:"driver_id" doc:"Driver of the ride"`
+		Status     string          `json:"status" enum:"planned,departed,in_progress,returned,cancelled" doc:"Ride status"`
+		CreatedAt  time.Time       `json:"created_at" doc:"Ride created timestamp"`
+		DepartedAt *time.Time      `json:"departed_at,omitempty" doc:"When the driver left the branch"`
+		StartedAt  *time.Time      `json:"started_at,omitempty" doc:"When the driver reached the first stop"`
+		EndedAt    time.Time       `json:"ended_at,omitempty" doc:"When the ride was returned or cancelled"`
+		Orders     []orderInfo     `json:"orders,omitempty" doc:"List of orders attached to the ride, in visiting order"`
+		Stops      []rideStop      `json:"stops,omitempty" doc:"Visiting sequence with distance and arrival estimates"`
+		Distance   float64         `json:"distance" doc:"Estimated road distance of the route, meters"`
+		Detached   []detachedOrder `json:"detached_orders,omitempty" doc:"Orders removed from the ride after they were finished"`
+	}
+}
+
+type listRidesIn struct {
+	listIn
+	Query struct {
+		BranchID int64     `query:"branch_id" doc:"Filter by branch ID"`
+		DriverID int64     `query:"driver_id" doc:"Filter by driver ID"`
+		Status   []string  `query:"status" enum:"planned,departed,in_progress,returned,cancelled" doc:"Filter by ride statuses (comma separated)"`
+		FromDate time.Time `query:"from_date" doc:"Filter rides created from this date (RFC3339 format)"`
+		ToDate   time.Time `query:"to_date" doc:"Filter rides created until this date (RFC3339 format)"`
 	}
 }
 
+// rideSummary is a ride in the rides list
+type rideSummary struct {
+	ID             int64      `json:"id" doc:"Ride ID"`
+	BranchID       int64      `json:"branch_id" doc:"Branch ID"`
+	DriverID       *int64     `json:"driver_id" doc:"Driver of the ride"`
+	DriverName     string     `json:"driver_name,omitempty" doc:"Driver name"`
+	Status         string     `json:"status" enum:"planned,departed,in_progress,returned,cancelled" doc:"Ride status"`
+	CreatedAt      time.Time  `json:"created_at" doc:"Ride created timestamp"`
+	DepartedAt     *time.Time `json:"departed_at,omitempty" doc:"When the driver left the branch"`
+	StartedAt      *time.Time `json:"started_at,omitempty" doc:"When the driver reached the first stop"`
+	EndedAt        *time.Time `json:"ended_at,omitempty" doc:"When the ride was returned or cancelled"`
+	OrderCount     int64      `json:"order_count" doc:"Orders on the ride"`
+	DeliveredCount int64      `json:"delivered_count" doc:"Stops delivered"`
+	FailedCount    int64      `json:"failed_count" doc:"Stops the driver failed to deliver"`
+	DetachedCount  int64      `json:"detached_count" doc:"Orders removed from the ride after they were finished elsewhere"`
+	WaitDuration   int64      `json:"wait_duration" doc:"Seconds from creation until departure, or until now for planned rides"`
+	RoadDuration   int64      `json:"road_duration" doc:"Seconds from departure until return, or until now for rides on the road"`
+}
+
+type listRidesOut struct {
+	Body struct {
+		Rides      []rideSummary `json:"rides" doc:"List of rides, newest first"`
+		NextCursor string        `json:"next_cursor,omitempty" doc:"Cursor of the next page, empty on the last page"`
+	}
+}
+
+// ridesCursor is the position of the last ride of a page
+type ridesCursor struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"t"`
+}
+
+// detachedOrder tells why an order was removed from a ride
+type detachedOrder struct {
+	OrderID    int64     `json:"order_id" doc:"Order ID"`
+	Reason     string    `json:"reason" doc:"Status the order reached"`
+	Source     string    `json:"source" doc:"Who finished the order"`
+	DetachedAt time.Time `json:"detached_at" doc:"When the order was removed"`
+}
+
 type point struct {
 	Lat float64 `json:"lat"`
 	Lng float64 `json:"lng"`
@@ -56,26 +144,11 @@ func CreateRide(ctx context.Context, in *createRideIn) (*rideOut, error) {
 	defer tx.Rollback(ctx)
 
 	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
 
-	// Create the ride
-	ride, err := qtx.CreateRide(ctx, in.Body.BranchID)
+	ride, err := createRide(ctx, qtx, orgID, in.Body.BranchID, in.Body.DriverID, in.Body.OrderIDs, dispatcherActor(ctx))
 	if err != nil {
-		log.SugaredLogger.Errorf("failed to create ride: %v", err)
-		return nil, huma.Error500InternalServerError("failed to create ride", err)
-	}
-
-	// Attach orders if provided
-	if len(in.Body.OrderIDs) > 0 {
-		for _, orderID := range in.Body.OrderIDs {
-			err := qtx.AttachOrderToRide(ctx, db.AttachOrderToRideParams{
-				RideID:  ride.ID,
-				OrderID: orderID,
-			})
-			if err != nil {
-				log.SugaredLogger.Errorf("failed to attach order %d to ride: %v", orderID, err)
-				return nil, huma.Error500InternalServerError("failed to attach orders to ride", err)
-			}
-		}
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -83,24 +156,92 @@ func CreateRide(ctx context.Context, in *createRideIn) (*rideOut, error) {
 		return nil, huma.Error500InternalServerError("failed to create ride", err)
 	}
 
-	return buildRideResponse(ctx, *qtx, ride)
+	return buildRideResponse(ctx, *db.Repository, orgID, ride)
 }
 
 // GetRide retrieves a ride by ID including its attached orders
 func GetRide(ctx context.Context, in *idPathIn) (*rideOut, error) {
-	ride, err := db.Repository.GetRide(ctx, in.ID)
+	orgID := organizationID(ctx)
+
+	ride, err := db.Repository.GetRide(ctx, db.GetRideParams{
+		ID:             in.ID,
+		OrganizationID: orgID,
+	})
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, huma.Error404NotFound("ride not found")
 		}
 		log.SugaredLogger.Errorf("failed to get ride: %v", err)
 		return nil, huma.Error500InternalServerError("failed to get ride", err)
 	}
 
-	return buildRideResponse(ctx, *db.Repository, ride)
+	return buildRideResponse(ctx, *db.Repository, orgID, ride)
 }
 
-// UpdateRide updates the orders attached to a ride
+// ListRides retrieves active and finished rides of the caller's organization
+// using optional filters and cursor pagination
+func ListRides(ctx context.Context, in *listRidesIn) (*listRidesOut, error) {
+	params := db.FilterRidesParams{
+		OrganizationID: organizationID(ctx),
+		Statuses:       in.Query.Status,
+		RowLimit:       int32(in.Limit) + 1,
+	}
+
+	if in.Query.BranchID != 0 {
+		params.BranchID = &in.Query.BranchID
+	}
+	if in.Query.DriverID != 0 {
+		params.DriverID = &in.Query.DriverID
+	}
+	if !in.Query.FromDate.IsZero() {
+		params.FromDate = pgtype.Timestamp{Time: in.Query.FromDate, Valid: true}
+	}
+	if !in.Query.ToDate.IsZero() {
+		params.ToDate = pgtype.Timestamp{Time: in.Query.ToDate, Valid: true}
+	}
+
+	if in.Cursor != "" {
+		var cursor ridesCursor
+		if err := decodeCursor(in.Cursor, &cursor); err != nil {
+			return nil, huma.Error400BadRequest("invalid cursor")
+		}
+		params.CursorID = &cursor.ID
+		params.CursorCreatedAt = pgtype.Timestamp{Time: cursor.CreatedAt, Valid: true}
+	}
+
+	rows, err := db.Repository.FilterRides(ctx, params)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list rides: %v", err)
+		return nil, huma.Error500InternalServerError("failed to list rides", err)
+	}
+
+	var resp listRidesOut
+	resp.Body.Rides = make([]rideSummary, 0, len(rows))
+
+	if len(rows) > in.Limit {
+		rows = rows[:in.Limit]
+		last := rows[len(rows)-1].Ride
+		cursor, err := encodeCursor(ridesCursor{
+			ID:        last.ID,
+			CreatedAt: last.CreatedAt.Time,
+		})
+		if err != nil {
+			log.SugaredLogger.Errorf("failed to encode cursor: %v", err)
+			return nil, huma.Error500InternalServerError("failed to list rides", err)
+		}
+		resp.Body.NextCursor = cursor
+	}
+
+	now := time.Now().UTC()
+	for _, row := range rows {
+		resp.Body.Rides = append(resp.Body.Rides, newRideSummary(row, now))
+	}
+
+	return &resp, nil
+}
+
+// UpdateRide updates the driver and the orders attached to a ride. The orders
+// must fit the vehicle of the driver.
 func UpdateRide(ctx context.Context, in *updateRideIn) (*rideOut, error) {
 	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
@@ -110,48 +251,150 @@ func UpdateRide(ctx context.Context, in *updateRideIn) (*rideOut, error) {
 	defer tx.Rollback(ctx)
 
 	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
 
 	// Get the ride to ensure it exists and isn't completed
-	ride, err := qtx.GetRide(ctx, in.ID)
+	ride, err := lockRide(ctx, qtx, in.ID, in.IfMatch)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, huma.Error404NotFound("ride not found")
+		return nil, err
+	}
+
+	// Orders of a ride on the road are finished stop by stop
+	if rides.Status(ride.Status) != rides.StatusPlanned {
+		return nil, huma.Error409Conflict(fmt.Sprintf("cannot update %s ride", ride.Status))
+	}
+
+	if in.Body.DriverID != nil {
+		if err := assignRideDriver(ctx, qtx, orgID, ride.ID, in.Body.DriverID); err != nil {
+			return nil, err
 		}
-		log.SugaredLogger.Errorf("failed to get ride: %v", err)
-		return nil, huma.Error500InternalServerError("failed to update ride", err)
+		ride.DriverID = in.Body.DriverID
 	}
 
-	if !ride.EndedAt.Time.IsZero() {
-		return nil, huma.Error400BadRequest("cannot update completed ride")
+	if err := replaceRideOrders(ctx, qtx, orgID, ride.ID, in.Body.OrderIDs, dispatcherActor(ctx)); err != nil {
+		return nil, err
 	}
 
-	// Remove all existing order associations
-	if err := qtx.DetachAllOrdersFromRide(ctx, ride.ID); err != nil {
-		log.SugaredLogger.Errorf("failed to detach orders from ride: %v", err)
+	if err := sequenceRide(ctx, qtx, orgID, ride); err != nil {
+		return nil, err
+	}
+
+	if err := checkRideCapacity(ctx, qtx, orgID, ride); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
 		return nil, huma.Error500InternalServerError("failed to update ride", err)
 	}
 
-	// Attach new orders
-	for _, orderID := range in.Body.OrderIDs {
-		err := qtx.AttachOrderToRide(ctx, db.AttachOrderToRideParams{
-			RideID:  ride.ID,
-			OrderID: orderID,
-		})
-		if err != nil {
-			log.SugaredLogger.Errorf("failed to attach order %d to ride: %v", orderID, err)
-			return nil, huma.Error500InternalServerError("failed to update ride", err)
+	return buildRideResponse(ctx, *db.Repository, orgID, ride)
+}
+
+// AddRideOrders attaches more orders to a planned ride keeping the orders
+// already on it, as long as they fit the vehicle of the driver
+func AddRideOrders(ctx context.Context, in *addRideOrdersIn) (*rideOut, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to add orders to ride", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
+
+	ride, err := lockRide(ctx, qtx, in.ID, in.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	if rides.Status(ride.Status) != rides.StatusPlanned {
+		return nil, huma.Error409Conflict(fmt.Sprintf("cannot add orders to %s ride", ride.Status))
+	}
+
+	if err := attachOrders(ctx, qtx, orgID, ride.ID, in.Body.OrderIDs, dispatcherActor(ctx)); err != nil {
+		return nil, err
+	}
+
+	if err := sequenceRide(ctx, qtx, orgID, ride); err != nil {
+		return nil, err
+	}
+
+	if err := checkRideCapacity(ctx, qtx, orgID, ride); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to add orders to ride", err)
+	}
+
+	return buildRideResponse(ctx, *db.Repository, orgID, ride)
+}
+
+// RemoveRideOrder detaches an order from a planned ride. An assigned order
+// goes back to the status it would have without the ride.
+func RemoveRideOrder(ctx context.Context, in *removeRideOrderIn) (*rideOut, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to remove order from ride", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
+
+	ride, err := lockRide(ctx, qtx, in.ID, in.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	if rides.Status(ride.Status) != rides.StatusPlanned {
+		return nil, huma.Error409Conflict(fmt.Sprintf("cannot remove orders from %s ride", ride.Status))
+	}
+
+	order, err := qtx.GetOrder(ctx, db.GetOrderParams{
+		ID:             in.OrderID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("order not found")
 		}
+		log.SugaredLogger.Errorf("failed to get order: %v", err)
+		return nil, huma.Error500InternalServerError("failed to remove order from ride", err)
+	}
+
+	detached, err := qtx.DetachOrderFromRide(ctx, db.DetachOrderFromRideParams{
+		RideID:  ride.ID,
+		OrderID: order.ID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to detach order from ride: %v", err)
+		return nil, huma.Error500InternalServerError("failed to remove order from ride", err)
+	}
+	if detached == 0 {
+		return nil, huma.Error404NotFound("order is not on the ride")
+	}
+
+	if err := releaseOrder(ctx, qtx, order, dispatcherActor(ctx)); err != nil {
+		return nil, err
+	}
+
+	if err := sequenceRide(ctx, qtx, orgID, ride); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
 		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
-		return nil, huma.Error500InternalServerError("failed to update ride", err)
+		return nil, huma.Error500InternalServerError("failed to remove order from ride", err)
 	}
 
-	return buildRideResponse(ctx, *db.Repository, ride)
+	return buildRideResponse(ctx, *db.Repository, orgID, ride)
 }
 
-// DeleteRide marks a ride as completed
+// DeleteRide cancels a ride that has not departed yet and releases its
+// orders. Rides on the road are returned instead, so their history is kept.
 func DeleteRide(ctx context.Context, in *deleteRideIn) (*successOut, error) {
 	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
@@ -161,27 +404,23 @@ func DeleteRide(ctx context.Context, in *deleteRideIn) (*successOut, error) {
 	defer tx.Rollback(ctx)
 
 	qtx := db.Repository.WithTx(tx)
+	orgID := organizationID(ctx)
 
 	// Get the ride to ensure it exists
-	ride, err := qtx.GetRide(ctx, in.ID)
+	ride, err := lockRide(ctx, qtx, in.ID, in.IfMatch)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, huma.Error404NotFound("ride not found")
-		}
-		log.SugaredLogger.Errorf("failed to get ride: %v", err)
-		return nil, huma.Error500InternalServerError("failed to delete ride", err)
+		return nil, err
 	}
 
-	// Mark ride as completed
-	if err := qtx.CompleteRide(ctx, in.ID); err != nil {
-		log.SugaredLogger.Errorf("failed to complete ride: %v", err)
-		return nil, huma.Error500InternalServerError("failed to delete ride", err)
+	// Mark ride as cancelled
+	_, change, err := moveRide(ctx, qtx, ride, rides.StatusCancelled, time.Now().UTC())
+	if err != nil {
+		return nil, err
 	}
 
 	// Detach all orders
-	if err := qtx.DetachAllOrdersFromRide(ctx, ride.ID); err != nil {
-		log.SugaredLogger.Errorf("failed to detach orders from ride: %v", err)
-		return nil, huma.Error500InternalServerError("failed to delete ride", err)
+	if err := releaseOrders(ctx, qtx, orgID, ride.ID, dispatcherActor(ctx)); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -189,6 +428,10 @@ func DeleteRide(ctx context.Context, in *deleteRideIn) (*successOut, error) {
 		return nil, huma.Error500InternalServerError("failed to delete ride", err)
 	}
 
+	if err := rides.PublishStatusChanges(ctx, centrifugo.Default, orgID, []rides.StatusChange{change}); err != nil {
+		log.SugaredLogger.Errorf("failed to publish ride status change: %v", err)
+	}
+
 	return &successOut{Body: struct {
 		Success bool `json:"success" example:"true" doc:"Status of succession"`
 	}(struct {
@@ -196,40 +439,340 @@ func DeleteRide(ctx context.Context, in *deleteRideIn) (*successOut, error) {
 	}{Success: true})}, nil
 }
 
+// Helper function to create a ride with its driver and orders and plan its
+// route. The orders must fit the vehicle of the driver.
+func createRide(ctx context.Context, q *db.Queries, orgID, branchID int64, driverID *int64, orderIDs []int64, actor orderActor) (db.Ride, error) {
+	ride, err := q.CreateRide(ctx, db.CreateRideParams{
+		BranchID:       branchID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Ride{}, huma.Error400BadRequest("branch not found")
+		}
+		log.SugaredLogger.Errorf("failed to create ride: %v", err)
+		return db.Ride{}, huma.Error500InternalServerError("failed to create ride", err)
+	}
+
+	if driverID != nil {
+		if err := assignRideDriver(ctx, q, orgID, ride.ID, driverID); err != nil {
+			return db.Ride{}, err
+		}
+		ride.DriverID = driverID
+	}
+
+	// Attach orders if provided
+	if err := attachOrders(ctx, q, orgID, ride.ID, orderIDs, actor); err != nil {
+		return db.Ride{}, err
+	}
+
+	if err := sequenceRide(ctx, q, orgID, ride); err != nil {
+		return db.Ride{}, err
+	}
+
+	if err := checkRideCapacity(ctx, q, orgID, ride); err != nil {
+		return db.Ride{}, err
+	}
+
+	return ride, nil
+}
+
+// Helper function to attach orders of the caller's organization to a ride.
+// Orders waiting for delivery become assigned, finished orders are rejected.
+func attachOrders(ctx context.Context, q *db.Queries, orgID, rideID int64, orderIDs []int64, actor orderActor) error {
+	for _, orderID := range orderIDs {
+		order, err := q.GetOrder(ctx, db.GetOrderParams{
+			ID:             orderID,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return huma.Error400BadRequest(fmt.Sprintf("order %d not found", orderID))
+			}
+			log.SugaredLogger.Errorf("failed to get order %d: %v", orderID, err)
+			return huma.Error500InternalServerError("failed to attach orders to ride", err)
+		}
+		if orderstatus.Status(order.Status).IsTerminal() {
+			return huma.Error400BadRequest(fmt.Sprintf("order %d is already finished", orderID))
+		}
+
+		activeRideID, err := q.GetOrderActiveRideID(ctx, orderID)
+		if err == nil {
+			return huma.Error409Conflict(fmt.Sprintf("order %d is already on ride %d", orderID, activeRideID))
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.SugaredLogger.Errorf("failed to get order %d ride: %v", orderID, err)
+			return huma.Error500InternalServerError("failed to attach orders to ride", err)
+		}
+
+		attached, err := q.AttachOrderToRide(ctx, db.AttachOrderToRideParams{
+			RideID:         rideID,
+			OrderID:        orderID,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			// Another request attached the order concurrently
+			if isUniqueViolation(err) {
+				return huma.Error409Conflict(fmt.Sprintf("order %d is already on another ride", orderID))
+			}
+			log.SugaredLogger.Errorf("failed to attach order %d to ride: %v", orderID, err)
+			return huma.Error500InternalServerError("failed to attach orders to ride", err)
+		}
+		if attached == 0 {
+			return huma.Error400BadRequest(fmt.Sprintf("order %d not found", orderID))
+		}
+
+		if orderstatus.CanTransition(orderstatus.Status(order.Status), orderstatus.Assigned) {
+			if err := setOrderStatus(ctx, q, order, orderstatus.Assigned, actor); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Helper function to make the orders of a ride match the given list. Orders
+// staying on the ride are left untouched, removed orders are released and
+// new ones attached.
+func replaceRideOrders(ctx context.Context, q *db.Queries, orgID, rideID int64, orderIDs []int64, actor orderActor) error {
+	current, err := q.GetOrdersByRideID(ctx, db.GetOrdersByRideIDParams{
+		RideID:         rideID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get orders for ride: %v", err)
+		return huma.Error500InternalServerError("failed to update ride orders", err)
+	}
+
+	wanted := make(map[int64]bool, len(orderIDs))
+	for _, id := range orderIDs {
+		wanted[id] = true
+	}
+
+	onRide := make(map[int64]bool, len(current))
+	for _, order := range current {
+		onRide[order.ID] = true
+		if wanted[order.ID] {
+			continue
+		}
+		if _, err := q.DetachOrderFromRide(ctx, db.DetachOrderFromRideParams{
+			RideID:  rideID,
+			OrderID: order.ID,
+		}); err != nil {
+			log.SugaredLogger.Errorf("failed to detach order from ride: %v", err)
+			return huma.Error500InternalServerError("failed to update ride orders", err)
+		}
+		if err := releaseOrder(ctx, q, order, actor); err != nil {
+			return err
+		}
+	}
+
+	added := make([]int64, 0, len(orderIDs))
+	for _, id := range orderIDs {
+		if !onRide[id] {
+			added = append(added, id)
+			onRide[id] = true
+		}
+	}
+	return attachOrders(ctx, q, orgID, rideID, added, actor)
+}
+
+// Helper function to detach all orders from a ride. Assigned orders go back
+// to the status they would have without the ride.
+func releaseOrders(ctx context.Context, q *db.Queries, orgID, rideID int64, actor orderActor) error {
+	orders, err := q.GetOrdersByRideID(ctx, db.GetOrdersByRideIDParams{
+		RideID:         rideID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get orders for ride: %v", err)
+		return huma.Error500InternalServerError("failed to detach orders from ride", err)
+	}
+
+	if err := q.DetachAllOrdersFromRide(ctx, db.DetachAllOrdersFromRideParams{
+		RideID:         rideID,
+		OrganizationID: orgID,
+	}); err != nil {
+		log.SugaredLogger.Errorf("failed to detach orders from ride: %v", err)
+		return huma.Error500InternalServerError("failed to detach orders from ride", err)
+	}
+
+	for _, order := range orders {
+		if err := releaseOrder(ctx, q, order, actor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Helper function to return an order detached from a ride to the status it
+// would have without the ride
+func releaseOrder(ctx context.Context, q *db.Queries, order db.Order, actor orderActor) error {
+	if orderstatus.Status(order.Status) != orderstatus.Assigned {
+		return nil
+	}
+
+	status, err := unassignedStatus(ctx, q, order)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get order %d status before assignment: %v", order.ID, err)
+		return huma.Error500InternalServerError("failed to detach orders from ride", err)
+	}
+	return setOrderStatus(ctx, q, order, status, actor)
+}
+
 // Helper function to build ride response with orders
-func buildRideResponse(ctx context.Context, q db.Queries, ride db.Ride) (*rideOut, error) {
-	orders, err := q.GetOrdersByRideID(ctx, ride.ID)
+func buildRideResponse(ctx context.Context, q db.Queries, orgID int64, ride db.Ride) (*rideOut, error) {
+	stops, err := q.ListRideStops(ctx, db.ListRideStopsParams{
+		RideID:         ride.ID,
+		OrganizationID: orgID,
+	})
 	if err != nil {
 		log.SugaredLogger.Errorf("failed to get orders for ride: %v", err)
 		return nil, huma.Error500InternalServerError("failed to get ride details", err)
 	}
 
 	var resp rideOut
+	resp.ETag = rideETag(ride)
 	resp.Body.ID = ride.ID
+	resp.Body.Version = ride.Version
 	resp.Body.BranchID = ride.BranchID
+	resp.Body.DriverID = ride.DriverID
+	resp.Body.Status = ride.Status
 	resp.Body.CreatedAt = ride.CreatedAt.Time
 	resp.Body.EndedAt = ride.EndedAt.Time
+	if ride.DepartedAt.Valid {
+		resp.Body.DepartedAt = &ride.DepartedAt.Time
+	}
+	if ride.StartedAt.Valid {
+		resp.Body.StartedAt = &ride.StartedAt.Time
+	}
 
-	for _, order := range orders {
-		resp.Body.Orders = append(resp.Body.Orders, orderInfo{
-			ID:           order.ID,
-			ExternalID:   order.ExternalID,
-			Status:       *order.Status,
-			Address:      formatAddress(order),
-			Location:     point{Lat: order.Location.P.Y, Lng: order.Location.P.X},
-			CustomerName: order.CustomerName,
+	for _, stop := range stops {
+		resp.Body.Orders = append(resp.Body.Orders, newOrderInfo(stop.Order))
+		resp.Body.Stops = append(resp.Body.Stops, newRideStop(stop))
+		if stop.LegDistance != nil {
+			resp.Body.Distance += *stop.LegDistance
+		}
+	}
+
+	detachments, err := q.ListRideOrderDetachments(ctx, ride.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list ride detachments: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get ride details", err)
+	}
+
+	for _, d := range detachments {
+		resp.Body.Detached = append(resp.Body.Detached, detachedOrder{
+			OrderID:    d.OrderID,
+			Reason:     d.Reason,
+			Source:     d.Source,
+			DetachedAt: d.DetachedAt.Time,
 		})
 	}
 
 	return &resp, nil
 }
 
+// Helper function to lock a ride of the caller's organization for a change and
+// bump its version. ifMatch, when set, must match the ETag of the ride before
+// the change.
+func lockRide(ctx context.Context, q *db.Queries, id int64, ifMatch string) (db.Ride, error) {
+	ride, err := getOrganizationRide(ctx, q, id)
+	if err != nil {
+		return db.Ride{}, err
+	}
+
+	// Waits for concurrent changes of the ride to commit
+	locked, err := q.BumpRideVersion(ctx, ride.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to bump ride version: %v", err)
+		return db.Ride{}, huma.Error500InternalServerError("failed to change ride", err)
+	}
+
+	if ifMatch != "" {
+		previous := locked
+		previous.Version--
+		if !etagMatches(ifMatch, rideETag(previous)) {
+			return db.Ride{}, huma.Error412PreconditionFailed(fmt.Sprintf("ride was modified, current version is %d", previous.Version))
+		}
+	}
+
+	return locked, nil
+}
+
+// Helper function to build the ETag of a ride version
+func rideETag(ride db.Ride) string {
+	return fmt.Sprintf("\"%d\"", ride.Version)
+}
+
+// Helper function to check an If-Match header against an ETag. The header may
+// list several ETags, weak ETags compare by value.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function to convert a listed ride to its response representation.
+// Durations of unfinished phases run until now.
+func newRideSummary(row db.FilterRidesRow, now time.Time) rideSummary {
+	ride := row.Ride
+	summary := rideSummary{
+		ID:             ride.ID,
+		BranchID:       ride.BranchID,
+		DriverID:       ride.DriverID,
+		DriverName:     formatPersonName(row.DriverName, row.DriverSurname),
+		Status:         ride.Status,
+		CreatedAt:      ride.CreatedAt.Time,
+		OrderCount:     row.OrderCount,
+		DeliveredCount: row.DeliveredCount,
+		FailedCount:    row.FailedCount,
+		DetachedCount:  row.DetachedCount,
+	}
+	if ride.DepartedAt.Valid {
+		summary.DepartedAt = &ride.DepartedAt.Time
+	}
+	if ride.StartedAt.Valid {
+		summary.StartedAt = &ride.StartedAt.Time
+	}
+	if ride.EndedAt.Valid {
+		summary.EndedAt = &ride.EndedAt.Time
+	}
+
+	end := now
+	if ride.EndedAt.Valid {
+		end = ride.EndedAt.Time
+	}
+	departed := end
+	if ride.DepartedAt.Valid {
+		departed = ride.DepartedAt.Time
+	}
+	summary.WaitDuration = int64(departed.Sub(ride.CreatedAt.Time).Seconds())
+	summary.RoadDuration = int64(end.Sub(departed).Seconds())
+
+	return summary
+}
+
 // Helper function to format address
 func formatAddress(order db.Order) string {
 	return fmt.Sprintf("%s, %s, д. %s, кв. %s",
-		order.City,
-		order.Street,
-		order.Building,
-		order.Apartment,
+		stringValue(order.City),
+		stringValue(order.Street),
+		stringValue(order.Building),
+		stringValue(order.Apartment),
 	)
 }
+
+// Helper function to dereference optional text columns
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
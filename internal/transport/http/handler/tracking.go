@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/rides"
+	"smartDriver/pkg/tracking"
+	"sort"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// positionBody is a location fix of the courier app
+type positionBody struct {
+	Lat        float64   `json:"lat" minimum:"-90" maximum:"90" doc:"Latitude"`
+	Lng        float64   `json:"lng" minimum:"-180" maximum:"180" doc:"Longitude"`
+	Accuracy   *float64  `json:"accuracy,omitempty" minimum:"0" doc:"Location accuracy in meters"`
+	Speed      *float64  `json:"speed,omitempty" minimum:"0" doc:"Speed in meters per second"`
+	RecordedAt time.Time `json:"recorded_at" doc:"When the device took the fix"`
+}
+
+type reportPositionIn struct {
+	Body positionBody
+}
+
+type reportPositionsIn struct {
+	Body struct {
+		Positions []positionBody `json:"positions" minItems:"1" maxItems:"500" doc:"Positions buffered while offline, in any order"`
+	}
+}
+
+type reportPositionsOut struct {
+	Body struct {
+		Accepted int    `json:"accepted" doc:"Number of stored positions"`
+		RideID   *int64 `json:"ride_id" doc:"Ride the positions were recorded for"`
+	}
+}
+
+type trackPoint struct {
+	Location   point     `json:"location" doc:"Driver coordinates"`
+	Accuracy   *float64  `json:"accuracy,omitempty" doc:"Location accuracy in meters"`
+	Speed      *float64  `json:"speed,omitempty" doc:"Speed in meters per second"`
+	RecordedAt time.Time `json:"recorded_at" doc:"When the device took the fix"`
+}
+
+type driverPositionOut struct {
+	Body struct {
+		DriverID int64  `json:"driver_id" doc:"Driver ID"`
+		RideID   *int64 `json:"ride_id" doc:"Ride the driver was on"`
+		trackPoint
+	}
+}
+
+type rideTrackOut struct {
+	Body struct {
+		RideID   int64        `json:"ride_id" doc:"Ride ID"`
+		Distance float64      `json:"distance" doc:"Straight line distance along the track without coarse fixes and GPS glitches, meters"`
+		Points   []trackPoint `json:"points" doc:"Positions in recording order"`
+	}
+}
+
+// ReportPosition stores the current position of the driver signed in
+func ReportPosition(ctx context.Context, in *reportPositionIn) (*reportPositionsOut, error) {
+	return recordPositions(ctx, []positionBody{in.Body})
+}
+
+// ReportPositions stores positions the courier app buffered while offline
+func ReportPositions(ctx context.Context, in *reportPositionsIn) (*reportPositionsOut, error) {
+	return recordPositions(ctx, in.Body.Positions)
+}
+
+// GetDriverPosition returns the latest known position of a driver
+func GetDriverPosition(ctx context.Context, in *idPathIn) (*driverPositionOut, error) {
+	driver, err := db.Repository.GetDriver(ctx, db.GetDriverParams{
+		ID:             in.ID,
+		OrganizationID: organizationID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("driver not found")
+		}
+		log.SugaredLogger.Errorf("failed to get driver: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get driver position", err)
+	}
+
+	var resp driverPositionOut
+	resp.Body.DriverID = driver.ID
+
+	if position, ok := tracking.Latest.Get(driver.ID); ok {
+		resp.Body.RideID = position.RideID
+		resp.Body.trackPoint = trackPoint{
+			Location:   point{Lat: position.Location.Lat, Lng: position.Location.Lng},
+			Accuracy:   position.Accuracy,
+			Speed:      position.Speed,
+			RecordedAt: position.RecordedAt,
+		}
+		return &resp, nil
+	}
+
+	// The index is empty after a restart
+	position, err := db.Repository.GetLatestDriverPosition(ctx, driver.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("driver has not reported a position")
+		}
+		log.SugaredLogger.Errorf("failed to get latest driver position: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get driver position", err)
+	}
+
+	resp.Body.RideID = position.RideID
+	resp.Body.trackPoint = newTrackPoint(position)
+	return &resp, nil
+}
+
+// GetRideTrack returns the positions the driver reported during a ride
+func GetRideTrack(ctx context.Context, in *idPathIn) (*rideTrackOut, error) {
+	ride, err := getOrganizationRide(ctx, db.Repository, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := db.Repository.ListRideTrack(ctx, &ride.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list ride track: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get ride track", err)
+	}
+
+	var resp rideTrackOut
+	resp.Body.RideID = ride.ID
+	resp.Body.Points = make([]trackPoint, 0, len(positions))
+	resp.Body.Distance = trackDistance(positions)
+	for _, position := range positions {
+		resp.Body.Points = append(resp.Body.Points, newTrackPoint(position))
+	}
+
+	return &resp, nil
+}
+
+// Helper function to store positions of the driver signed in. Positions
+// recorded after the active ride departed are added to its track. The newest
+// position updates the index and is published, and moves the arrival
+// estimates of the ride.
+func recordPositions(ctx context.Context, positions []positionBody) (*reportPositionsOut, error) {
+	orgID := organizationID(ctx)
+	userID, _ := ctx.Value("user_id").(int64)
+
+	driver, err := db.Repository.GetDriverByUserID(ctx, db.GetDriverByUserIDParams{
+		UserID:         userID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error403Forbidden("user is not a driver")
+		}
+		log.SugaredLogger.Errorf("failed to get driver: %v", err)
+		return nil, huma.Error500InternalServerError("failed to record positions", err)
+	}
+
+	now := time.Now().UTC()
+	for i, p := range positions {
+		if !(geo.Point{Lat: p.Lat, Lng: p.Lng}).Valid() {
+			return nil, huma.Error400BadRequest(fmt.Sprintf("position %d has invalid coordinates", i))
+		}
+		if p.RecordedAt.After(now.Add(tracking.MaxClockSkew)) {
+			return nil, huma.Error400BadRequest(fmt.Sprintf("position %d is recorded in the future", i))
+		}
+	}
+
+	sorted := make([]positionBody, len(positions))
+	copy(sorted, positions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].RecordedAt.Before(sorted[j].RecordedAt)
+	})
+
+	ride, found, err := driverActiveRide(ctx, orgID, driver.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to get driver active ride: %v", err)
+		return nil, huma.Error500InternalServerError("failed to record positions", err)
+	}
+	onRoad := found && rides.Status(ride.Status).IsOnRoad() && ride.DepartedAt.Valid
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to record positions", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+
+	recorded := make([]tracking.Position, 0, len(sorted))
+	for _, p := range sorted {
+		position := tracking.Position{
+			DriverID:   driver.ID,
+			Location:   geo.Point{Lat: p.Lat, Lng: p.Lng},
+			Accuracy:   p.Accuracy,
+			Speed:      p.Speed,
+			RecordedAt: p.RecordedAt.UTC(),
+		}
+		if onRoad && !position.RecordedAt.Before(ride.DepartedAt.Time) {
+			position.RideID = &ride.ID
+		}
+
+		if err := qtx.CreateDriverPosition(ctx, db.CreateDriverPositionParams{
+			DriverID:   position.DriverID,
+			RideID:     position.RideID,
+			Lng:        position.Location.Lng,
+			Lat:        position.Location.Lat,
+			Accuracy:   position.Accuracy,
+			Speed:      position.Speed,
+			RecordedAt: pgtype.Timestamp{Time: position.RecordedAt, Valid: true},
+		}); err != nil {
+			log.SugaredLogger.Errorf("failed to create driver position: %v", err)
+			return nil, huma.Error500InternalServerError("failed to record positions", err)
+		}
+		recorded = append(recorded, position)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to record positions", err)
+	}
+
+	// Buffered positions older than the known one only go to the track
+	fresh := recorded
+	if previous, ok := tracking.Latest.Get(driver.ID); ok {
+		fresh = nil
+		for _, position := range recorded {
+			if position.RecordedAt.After(previous.RecordedAt) {
+				fresh = append(fresh, position)
+			}
+		}
+	}
+
+	latest := recorded[len(recorded)-1]
+	if tracking.Latest.Update(latest) {
+		event := tracking.Event{Type: tracking.EventDriverPosition, Position: latest}
+		channels := []string{centrifugo.OrganizationChannel(orgID)}
+		if latest.RideID != nil {
+			channels = append(channels, centrifugo.RideChannel(*latest.RideID))
+		}
+		for _, channel := range channels {
+			if err := centrifugo.Default.Publish(ctx, channel, event); err != nil {
+				log.SugaredLogger.Errorf("failed to publish driver position: %v", err)
+			}
+		}
+	}
+
+	// Positions are stored even if the ride could not follow them
+	if err := applyGeofences(ctx, orgID, driver.ID, fresh); err != nil {
+		log.SugaredLogger.Errorf("failed to apply geofences of driver %d: %v", driver.ID, err)
+	}
+	if len(fresh) > 0 {
+		if err := updateDriverETA(ctx, orgID, driver.ID); err != nil {
+			log.SugaredLogger.Errorf("failed to update eta of driver %d: %v", driver.ID, err)
+		}
+	}
+
+	var resp reportPositionsOut
+	resp.Body.Accepted = len(recorded)
+	if onRoad {
+		resp.Body.RideID = &ride.ID
+	}
+	return &resp, nil
+}
+
+// Helper function to get the active ride of a driver
+func driverActiveRide(ctx context.Context, orgID, driverID int64) (db.Ride, bool, error) {
+	rideID, err := db.Repository.GetDriverActiveRideID(ctx, &driverID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Ride{}, false, nil
+		}
+		return db.Ride{}, false, fmt.Errorf("failed to get driver active ride: %w", err)
+	}
+
+	ride, err := db.Repository.GetRide(ctx, db.GetRideParams{
+		ID:             rideID,
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return db.Ride{}, false, fmt.Errorf("failed to get ride: %w", err)
+	}
+
+	return ride, true, nil
+}
+
+// Helper function to convert a stored position to its response representation
+func newTrackPoint(position db.DriverPosition) trackPoint {
+	return trackPoint{
+		Location:   point{Lat: position.Location.P.Y, Lng: position.Location.P.X},
+		Accuracy:   position.Accuracy,
+		Speed:      position.Speed,
+		RecordedAt: position.RecordedAt.Time,
+	}
+}
+
+// Helper function to measure the distance driven along the stored positions
+// of a track in meters, skipping coarse fixes and GPS glitches
+func trackDistance(positions []db.DriverPosition) float64 {
+	track := make([]tracking.Position, 0, len(positions))
+	for _, position := range positions {
+		track = append(track, newTrackingPosition(position))
+	}
+	return tracking.Distance(track)
+}
+
+// Helper function to convert a stored position to its tracking representation
+func newTrackingPosition(position db.DriverPosition) tracking.Position {
+	return tracking.Position{
+		DriverID:   position.DriverID,
+		RideID:     position.RideID,
+		Location:   geo.PointFromPg(position.Location),
+		Accuracy:   position.Accuracy,
+		Speed:      position.Speed,
+		RecordedAt: position.RecordedAt.Time,
+	}
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/log"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type importBranchZonesIn struct {
+	ID   int64 `path:"id" doc:"Branch ID"`
+	Body geo.FeatureCollection
+}
+
+type branchZonesOut struct {
+	Body geo.FeatureCollection
+}
+
+// GetBranchZones exports delivery zones of a branch as a GeoJSON feature collection
+func GetBranchZones(ctx context.Context, in *idPathIn) (*branchZonesOut, error) {
+	if _, err := db.Repository.GetBranch(ctx, db.GetBranchParams{
+		ID:             in.ID,
+		OrganizationID: organizationID(ctx),
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("branch not found")
+		}
+		log.SugaredLogger.Errorf("failed to get branch: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get delivery zones", err)
+	}
+
+	zones, err := db.Repository.ListBranchDeliveryZones(ctx, in.ID)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to list delivery zones: %v", err)
+		return nil, huma.Error500InternalServerError("failed to get delivery zones", err)
+	}
+
+	return buildZonesResponse(zones), nil
+}
+
+// ImportBranchZones replaces delivery zones of a branch with the polygons of
+// the given GeoJSON feature collection. The "name" property of each feature
+// is used as the zone name.
+func ImportBranchZones(ctx context.Context, in *importBranchZonesIn) (*branchZonesOut, error) {
+	type zoneParams struct {
+		name string
+		area geo.Polygon
+	}
+
+	params := make([]zoneParams, 0, len(in.Body.Features))
+	for i, feature := range in.Body.Features {
+		area, err := geo.PolygonFromGeometry(feature.Geometry)
+		if err != nil {
+			return nil, huma.Error400BadRequest(fmt.Sprintf("invalid feature %d: %v", i, err))
+		}
+
+		name, _ := feature.Properties["name"].(string)
+		if name == "" {
+			name = fmt.Sprintf("Zone %d", i+1)
+		}
+
+		params = append(params, zoneParams{name: name, area: area})
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.SugaredLogger.Errorf("failed to begin transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to import delivery zones", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.Repository.WithTx(tx)
+
+	if _, err := qtx.GetBranch(ctx, db.GetBranchParams{
+		ID:             in.ID,
+		OrganizationID: organizationID(ctx),
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, huma.Error404NotFound("branch not found")
+		}
+		log.SugaredLogger.Errorf("failed to get branch: %v", err)
+		return nil, huma.Error500InternalServerError("failed to import delivery zones", err)
+	}
+
+	if err := qtx.DeleteBranchDeliveryZones(ctx, in.ID); err != nil {
+		log.SugaredLogger.Errorf("failed to delete delivery zones: %v", err)
+		return nil, huma.Error500InternalServerError("failed to import delivery zones", err)
+	}
+
+	zones := make([]db.DeliveryZone, 0, len(params))
+	for _, p := range params {
+		zone, err := qtx.CreateDeliveryZone(ctx, db.CreateDeliveryZoneParams{
+			BranchID: in.ID,
+			Name:     p.name,
+			Area:     p.area.Pg(),
+		})
+		if err != nil {
+			log.SugaredLogger.Errorf("failed to create delivery zone: %v", err)
+			return nil, huma.Error500InternalServerError("failed to import delivery zones", err)
+		}
+		zones = append(zones, zone)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.SugaredLogger.Errorf("failed to commit transaction: %v", err)
+		return nil, huma.Error500InternalServerError("failed to import delivery zones", err)
+	}
+
+	return buildZonesResponse(zones), nil
+}
+
+// Helper function to convert delivery zones to a GeoJSON feature collection
+func buildZonesResponse(zones []db.DeliveryZone) *branchZonesOut {
+	var resp branchZonesOut
+	resp.Body.Type = geo.TypeFeatureCollection
+	resp.Body.Features = make([]geo.Feature, 0, len(zones))
+
+	for _, zone := range zones {
+		resp.Body.Features = append(resp.Body.Features, geo.Feature{
+			Type:     geo.TypeFeature,
+			Geometry: geo.PolygonFromPg(zone.Area).Geometry(),
+			Properties: map[string]any{
+				"id":        zone.ID,
+				"name":      zone.Name,
+				"branch_id": zone.BranchID,
+			},
+		})
+	}
+
+	return &resp
+}
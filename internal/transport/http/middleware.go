@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+	"smartDriver/internal/db"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// publicMetadata marks operations that can be called without a session
+var publicMetadata = map[string]any{"public": true}
+
+// AuthMiddleware resolves the session token and stores the caller's user,
+// organization and roles in the request context. Every non-public operation
+// is scoped to the organization stored here.
+func AuthMiddleware(api huma.API) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		if public, _ := ctx.Operation().Metadata["public"].(bool); public {
+			next(ctx)
+			return
+		}
+
+		token := ctx.Header("Authorization")
+		if token == "" {
+			huma.WriteErr(api, ctx, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		session, err := db.Repository.GetSessionByToken(ctx.Context(), token)
+		if err != nil {
+			huma.WriteErr(api, ctx, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		roles, err := db.Repository.GetUserRoles(ctx.Context(), session.UserID)
+		if err != nil {
+			huma.WriteErr(api, ctx, http.StatusInternalServerError, "failed to get user roles", err)
+			return
+		}
+
+		roleNames := make([]string, 0, len(roles))
+		for _, role := range roles {
+			roleNames = append(roleNames, role.Name)
+		}
+
+		ctx = huma.WithValue(ctx, "user_id", session.UserID)
+		ctx = huma.WithValue(ctx, "organization_id", session.OrganizationID)
+		ctx = huma.WithValue(ctx, "user_roles", roleNames)
+		ctx = huma.WithValue(ctx, "session_token", token)
+		next(ctx)
+	}
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"net/http"
 	"smartDriver/internal/transport/http/handler"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -17,6 +18,7 @@ func Register(api huma.API) {
 		Description:   "Create a new user account",
 		Tags:          []string{"Authorization"},
 		DefaultStatus: http.StatusCreated,
+		Metadata:      publicMetadata,
 	}, handler.Register)
 
 	//huma.Register(api, huma.Operation{
@@ -77,6 +79,7 @@ func Register(api huma.API) {
 		Description:   "Authenticate user and create session",
 		Tags:          []string{"Authorization"},
 		DefaultStatus: http.StatusOK,
+		Metadata:      publicMetadata,
 	}, handler.Login)
 
 	huma.Register(api, huma.Operation{
@@ -97,6 +100,7 @@ func Register(api huma.API) {
 		Description:   "Refresh session token using refresh token",
 		Tags:          []string{"Authorization"},
 		DefaultStatus: http.StatusOK,
+		Metadata:      publicMetadata,
 	}, handler.RefreshToken)
 
 	// Organizations endpoints
@@ -198,6 +202,16 @@ func Register(api huma.API) {
 	}, handler.ListPlans)
 
 	// Rides endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-rides",
+		Method:        http.MethodGet,
+		Path:          "/rides",
+		Summary:       "List rides",
+		Description:   "List active and finished rides with order counts and durations",
+		Tags:          []string{"Rides"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListRides)
+
 	huma.Register(api, huma.Operation{
 		OperationID:   "create-ride",
 		Method:        http.MethodPost,
@@ -218,6 +232,16 @@ func Register(api huma.API) {
 		DefaultStatus: http.StatusOK,
 	}, handler.GetRide)
 
+	huma.Register(api, huma.Operation{
+		OperationID:   "optimize-ride-route",
+		Method:        http.MethodPost,
+		Path:          "/rides/{id}/route",
+		Summary:       "Optimize ride route",
+		Description:   "Recompute the visiting sequence of an active ride starting from its branch now",
+		Tags:          []string{"Rides"},
+		DefaultStatus: http.StatusOK,
+	}, handler.OptimizeRideRoute)
+
 	huma.Register(api, huma.Operation{
 		OperationID:   "update-ride",
 		Method:        http.MethodPut,
@@ -228,16 +252,77 @@ func Register(api huma.API) {
 		DefaultStatus: http.StatusOK,
 	}, handler.UpdateRide)
 
+	huma.Register(api, huma.Operation{
+		OperationID:   "add-ride-orders",
+		Method:        http.MethodPost,
+		Path:          "/rides/{id}/orders",
+		Summary:       "Add orders to ride",
+		Description:   "Attach orders to a planned ride. Orders already on another active ride are rejected with 409",
+		Tags:          []string{"Rides"},
+		DefaultStatus: http.StatusOK,
+	}, handler.AddRideOrders)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "remove-ride-order",
+		Method:        http.MethodDelete,
+		Path:          "/rides/{id}/orders/{order_id}",
+		Summary:       "Remove order from ride",
+		Description:   "Detach an order from a planned ride",
+		Tags:          []string{"Rides"},
+		DefaultStatus: http.StatusOK,
+	}, handler.RemoveRideOrder)
+
 	huma.Register(api, huma.Operation{
 		OperationID:   "delete-ride",
 		Method:        http.MethodDelete,
 		Path:          "/rides/{id}",
 		Summary:       "Delete ride",
-		Description:   "Complete ride and detach orders",
+		Description:   "Cancel a ride that has not departed and release its orders",
 		Tags:          []string{"Rides"},
 		DefaultStatus: http.StatusOK,
 	}, handler.DeleteRide)
 
+	huma.Register(api, huma.Operation{
+		OperationID:   "change-ride-status",
+		Method:        http.MethodPost,
+		Path:          "/rides/{id}/status",
+		Summary:       "Change ride status",
+		Description:   "Depart, start or return a ride. A ride returns once every stop is delivered or failed",
+		Tags:          []string{"Rides"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ChangeRideStatus)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "change-ride-stop-status",
+		Method:        http.MethodPost,
+		Path:          "/rides/{id}/stops/{order_id}/status",
+		Summary:       "Change ride stop status",
+		Description:   "Report arriving at a stop of a departed ride, delivering its order or failing to",
+		Tags:          []string{"Rides"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ChangeRideStopStatus)
+
+	// Delivery zones endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-branch-zones",
+		Method:        http.MethodGet,
+		Path:          "/branches/{id}/zones",
+		Summary:       "Get branch delivery zones",
+		Description:   "Export branch delivery zones as a GeoJSON feature collection",
+		Tags:          []string{"Delivery zones"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetBranchZones)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "import-branch-zones",
+		Method:        http.MethodPut,
+		Path:          "/branches/{id}/zones",
+		Summary:       "Import branch delivery zones",
+		Description:   "Replace branch delivery zones with polygons from a GeoJSON feature collection",
+		Tags:          []string{"Delivery zones"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ImportBranchZones)
+
 	// Orders endpoints
 	huma.Register(api, huma.Operation{
 		OperationID:   "get-unbound-orders",
@@ -249,15 +334,579 @@ func Register(api huma.API) {
 		DefaultStatus: http.StatusOK,
 	}, handler.GetUnboundOrders)
 
-	//huma.Register(api, huma.Operation{
-	//	OperationID:   "get-order-statuses",
-	//	Method:        http.MethodGet,
-	//	Path:          "/orders/statuses",
-	//	Summary:       "Get order statuses",
-	//	Description:   "Get list of available order statuses",
-	//	Tags:          []string{"Orders"},
-	//	DefaultStatus: http.StatusOK,
-	//}, handler.GetOrderStatuses)
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-orders",
+		Method:        http.MethodGet,
+		Path:          "/orders",
+		Summary:       "List orders",
+		Description:   "List orders with optional filters, sorting and cursor pagination",
+		Tags:          []string{"Orders"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListOrders)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-order",
+		Method:        http.MethodGet,
+		Path:          "/orders/{id}",
+		Summary:       "Get order",
+		Description:   "Get order details by ID",
+		Tags:          []string{"Orders"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetOrder)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-order-history",
+		Method:        http.MethodGet,
+		Path:          "/orders/{id}/history",
+		Summary:       "Get order history",
+		Description:   "Get the status timeline of an order",
+		Tags:          []string{"Orders"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetOrderHistory)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-order",
+		Method:        http.MethodPost,
+		Path:          "/orders",
+		Summary:       "Create manual order",
+		Description:   "Create an order taken by a dispatcher outside of iiko",
+		Tags:          []string{"Orders"},
+		DefaultStatus: http.StatusCreated,
+	}, handler.CreateManualOrder)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "update-order",
+		Method:        http.MethodPut,
+		Path:          "/orders/{id}",
+		Summary:       "Update manual order",
+		Description:   "Update a manual order. Orders imported from iiko cannot be edited.",
+		Tags:          []string{"Orders"},
+		DefaultStatus: http.StatusOK,
+	}, handler.UpdateManualOrder)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "cancel-order",
+		Method:        http.MethodPost,
+		Path:          "/orders/{id}/cancel",
+		Summary:       "Cancel manual order",
+		Description:   "Cancel a manual order. Orders imported from iiko are cancelled in iiko.",
+		Tags:          []string{"Orders"},
+		DefaultStatus: http.StatusOK,
+	}, handler.CancelManualOrder)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-order-statuses",
+		Method:        http.MethodGet,
+		Path:          "/orders/statuses",
+		Summary:       "Get order statuses",
+		Description:   "Get list of available order statuses with localized labels and allowed transitions",
+		Tags:          []string{"Orders"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetOrderStatuses)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "change-order-status",
+		Method:        http.MethodPost,
+		Path:          "/orders/{id}/status",
+		Summary:       "Change manual order status",
+		Description:   "Move a manual order to another status. Only allowed transitions are accepted.",
+		Tags:          []string{"Orders"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ChangeOrderStatus)
+
+	// Customers endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-customers",
+		Method:        http.MethodGet,
+		Path:          "/customers",
+		Summary:       "List customers",
+		Description:   "List customer profiles built from orders",
+		Tags:          []string{"Customers"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListCustomers)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-customer",
+		Method:        http.MethodGet,
+		Path:          "/customers/{id}",
+		Summary:       "Get customer",
+		Description:   "Get customer profile with saved addresses and flags",
+		Tags:          []string{"Customers"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetCustomer)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "update-customer",
+		Method:        http.MethodPut,
+		Path:          "/customers/{id}",
+		Summary:       "Update customer",
+		Description:   "Update customer name and delivery notes for drivers",
+		Tags:          []string{"Customers"},
+		DefaultStatus: http.StatusOK,
+	}, handler.UpdateCustomer)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-customer-orders",
+		Method:        http.MethodGet,
+		Path:          "/customers/{id}/orders",
+		Summary:       "Get customer orders",
+		Description:   "Get delivery history of a customer",
+		Tags:          []string{"Customers"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListCustomerOrders)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "add-customer-flag",
+		Method:        http.MethodPost,
+		Path:          "/customers/{id}/flags",
+		Summary:       "Flag customer",
+		Description:   "Mark a customer as problematic",
+		Tags:          []string{"Customers"},
+		DefaultStatus: http.StatusOK,
+	}, handler.AddCustomerFlag)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-customer-flag",
+		Method:        http.MethodDelete,
+		Path:          "/customers/{id}/flags/{flag_id}",
+		Summary:       "Remove customer flag",
+		Description:   "Remove a flag from a customer",
+		Tags:          []string{"Customers"},
+		DefaultStatus: http.StatusOK,
+	}, handler.DeleteCustomerFlag)
+
+	// Proof of delivery endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:     "confirm-delivery",
+		Method:          http.MethodPost,
+		Path:            "/orders/{id}/delivery-confirmation",
+		Summary:         "Confirm delivery",
+		Description:     "Upload photos or a signature proving the order was handed over, with the hand over time and driver coordinates passed as lat, lng, accuracy, delivered_at, recipient_name and comment form fields",
+		Tags:            []string{"Delivery"},
+		DefaultStatus:   http.StatusCreated,
+		BodyReadTimeout: time.Minute,
+	}, handler.ConfirmDelivery)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-delivery-confirmation",
+		Method:        http.MethodGet,
+		Path:          "/orders/{id}/delivery-confirmation",
+		Summary:       "Get delivery confirmation",
+		Description:   "Get the proof of delivery of an order",
+		Tags:          []string{"Delivery"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetDeliveryConfirmation)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-delivery-attachment",
+		Method:        http.MethodGet,
+		Path:          "/orders/{id}/delivery-confirmation/attachments/{attachment_id}",
+		Summary:       "Download delivery attachment",
+		Description:   "Download a photo or signature of the proof of delivery",
+		Tags:          []string{"Delivery"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetDeliveryAttachment)
+
+	// Cash reconciliation endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-ride-cash",
+		Method:        http.MethodGet,
+		Path:          "/rides/{id}/cash",
+		Summary:       "Get ride cash",
+		Description:   "Get the cash expected from the driver of a ride and the reconciliation state",
+		Tags:          []string{"Cash"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetRideCash)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "report-ride-cash",
+		Method:        http.MethodPost,
+		Path:          "/rides/{id}/cash/report",
+		Summary:       "Report collected cash",
+		Description:   "Report the cash a driver collected during a ride",
+		Tags:          []string{"Cash"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ReportRideCash)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "confirm-ride-cash",
+		Method:        http.MethodPost,
+		Path:          "/rides/{id}/cash/confirm",
+		Summary:       "Confirm cash handover",
+		Description:   "Confirm the branch received the cash of a ride",
+		Tags:          []string{"Cash"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ConfirmRideCash)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-cash-discrepancies",
+		Method:        http.MethodGet,
+		Path:          "/cash/discrepancies",
+		Summary:       "Cash discrepancy report",
+		Description:   "Get expected and handed over cash per driver and day",
+		Tags:          []string{"Cash"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetCashDiscrepancies)
+
+	// Drivers endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-driver",
+		Method:        http.MethodPost,
+		Path:          "/drivers",
+		Summary:       "Create driver",
+		Description:   "Create a driver profile for a user of the organization",
+		Tags:          []string{"Drivers"},
+		DefaultStatus: http.StatusCreated,
+	}, handler.CreateDriver)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-drivers",
+		Method:        http.MethodGet,
+		Path:          "/drivers",
+		Summary:       "List drivers",
+		Description:   "List drivers of the organization with their active rides",
+		Tags:          []string{"Drivers"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListDrivers)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-driver",
+		Method:        http.MethodGet,
+		Path:          "/drivers/{id}",
+		Summary:       "Get driver",
+		Description:   "Get driver profile",
+		Tags:          []string{"Drivers"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetDriver)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "update-driver",
+		Method:        http.MethodPut,
+		Path:          "/drivers/{id}",
+		Summary:       "Update driver",
+		Description:   "Update driver profile and status",
+		Tags:          []string{"Drivers"},
+		DefaultStatus: http.StatusOK,
+	}, handler.UpdateDriver)
+
+	// Dispatch endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-dispatch-settings",
+		Method:        http.MethodGet,
+		Path:          "/dispatch/settings",
+		Summary:       "Get dispatch settings",
+		Description:   "Get ride proposal limits and automatic dispatch setting of the organization",
+		Tags:          []string{"Dispatch"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetDispatchSettings)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "update-dispatch-settings",
+		Method:        http.MethodPut,
+		Path:          "/dispatch/settings",
+		Summary:       "Update dispatch settings",
+		Description:   "Update ride proposal limits and enable or disable automatic dispatch",
+		Tags:          []string{"Dispatch"},
+		DefaultStatus: http.StatusOK,
+	}, handler.UpdateDispatchSettings)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-ride-proposals",
+		Method:        http.MethodGet,
+		Path:          "/branches/{id}/ride-proposals",
+		Summary:       "Get ride proposals",
+		Description:   "Group ready orders of a branch waiting for a ride into proposed rides by proximity and promised time",
+		Tags:          []string{"Dispatch"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetRideProposals)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "accept-ride-proposal",
+		Method:        http.MethodPost,
+		Path:          "/branches/{id}/ride-proposals/accept",
+		Summary:       "Accept ride proposal",
+		Description:   "Create a ride from a proposal",
+		Tags:          []string{"Dispatch"},
+		DefaultStatus: http.StatusCreated,
+	}, handler.AcceptRideProposal)
+
+	// Tracking endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "report-position",
+		Method:        http.MethodPost,
+		Path:          "/tracking/positions",
+		Summary:       "Report position",
+		Description:   "Store the current position of the driver signed in and publish it",
+		Tags:          []string{"Tracking"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ReportPosition)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "report-positions",
+		Method:        http.MethodPost,
+		Path:          "/tracking/positions/batch",
+		Summary:       "Report buffered positions",
+		Description:   "Store positions the courier app recorded while offline",
+		Tags:          []string{"Tracking"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ReportPositions)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-driver-position",
+		Method:        http.MethodGet,
+		Path:          "/drivers/{id}/position",
+		Summary:       "Get driver position",
+		Description:   "Get the latest position reported by a driver",
+		Tags:          []string{"Tracking"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetDriverPosition)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-ride-track",
+		Method:        http.MethodGet,
+		Path:          "/rides/{id}/track",
+		Summary:       "Get ride track",
+		Description:   "Get the positions the driver reported since the ride departed",
+		Tags:          []string{"Tracking"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetRideTrack)
+
+	// Geofence endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-geofence-settings",
+		Method:        http.MethodGet,
+		Path:          "/geofence/settings",
+		Summary:       "Get geofence settings",
+		Description:   "Get the radii used to detect arrivals at stops and returns to the branch",
+		Tags:          []string{"Geofence"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetGeofenceSettings)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "update-geofence-settings",
+		Method:        http.MethodPut,
+		Path:          "/geofence/settings",
+		Summary:       "Update geofence settings",
+		Description:   "Update the radii used to detect arrivals at stops and returns to the branch",
+		Tags:          []string{"Geofence"},
+		DefaultStatus: http.StatusOK,
+	}, handler.UpdateGeofenceSettings)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-ride-geofence-events",
+		Method:        http.MethodGet,
+		Path:          "/rides/{id}/geofence-events",
+		Summary:       "Get ride geofence events",
+		Description:   "List when the driver of a ride entered or left the branch and the stops",
+		Tags:          []string{"Geofence"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetRideGeofenceEvents)
+
+	// Order tracking endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-order-tracking-link",
+		Method:        http.MethodPost,
+		Path:          "/orders/{id}/tracking-link",
+		Summary:       "Create order tracking link",
+		Description:   "Get the token of the public page a customer follows the order on",
+		Tags:          []string{"Order tracking"},
+		DefaultStatus: http.StatusOK,
+	}, handler.CreateOrderTrackingLink)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-order-tracking",
+		Method:        http.MethodGet,
+		Path:          "/track/{token}",
+		Summary:       "Track order",
+		Description:   "Get the status, courier and approximate courier position of an order by its tracking token. The link expires once the order is delivered.",
+		Tags:          []string{"Order tracking"},
+		DefaultStatus: http.StatusOK,
+		Metadata:      publicMetadata,
+	}, handler.GetOrderTracking)
+
+	// ETA endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-vehicle-profiles",
+		Method:        http.MethodGet,
+		Path:          "/eta/profiles",
+		Summary:       "List vehicle profiles",
+		Description:   "Get the speed and handover time used to estimate arrivals for each vehicle type",
+		Tags:          []string{"ETA"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListVehicleProfiles)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "update-vehicle-profile",
+		Method:        http.MethodPut,
+		Path:          "/eta/profiles/{vehicle_type}",
+		Summary:       "Update vehicle profile",
+		Description:   "Set the speed and handover time used to estimate arrivals for a vehicle type",
+		Tags:          []string{"ETA"},
+		DefaultStatus: http.StatusOK,
+	}, handler.UpdateVehicleProfile)
+
+	// Vehicle capacity endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-vehicle-limits",
+		Method:        http.MethodGet,
+		Path:          "/vehicles/limits",
+		Summary:       "List vehicle limits",
+		Description:   "Get the orders, weight, volume and route length each vehicle type can carry in a ride",
+		Tags:          []string{"Vehicles"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListVehicleLimits)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "update-vehicle-limits",
+		Method:        http.MethodPut,
+		Path:          "/vehicles/{vehicle_type}/limits",
+		Summary:       "Update vehicle limits",
+		Description:   "Set the orders, weight, volume and route length a vehicle type can carry in a ride",
+		Tags:          []string{"Vehicles"},
+		DefaultStatus: http.StatusOK,
+	}, handler.UpdateVehicleLimits)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-product-sizes",
+		Method:        http.MethodGet,
+		Path:          "/products/sizes",
+		Summary:       "List product sizes",
+		Description:   "Get the weight and volume of products used to derive order sizes",
+		Tags:          []string{"Vehicles"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListProductSizes)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "update-product-size",
+		Method:        http.MethodPut,
+		Path:          "/products/{product_id}/size",
+		Summary:       "Update product size",
+		Description:   "Set the weight and volume of one unit of a product",
+		Tags:          []string{"Vehicles"},
+		DefaultStatus: http.StatusOK,
+	}, handler.UpdateProductSize)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-product-size",
+		Method:        http.MethodDelete,
+		Path:          "/products/{product_id}/size",
+		Summary:       "Delete product size",
+		Description:   "Count units of a product with the default size again",
+		Tags:          []string{"Vehicles"},
+		DefaultStatus: http.StatusOK,
+	}, handler.DeleteProductSize)
+
+	// Payroll endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-pay-rules",
+		Method:        http.MethodGet,
+		Path:          "/payroll/rules",
+		Summary:       "List pay rules",
+		Description:   "Get how couriers of the organization and its branches are paid per order, kilometer and hour",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListPayRules)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "update-pay-rule",
+		Method:        http.MethodPut,
+		Path:          "/payroll/rules",
+		Summary:       "Update pay rule",
+		Description:   "Set how couriers of the organization or of a branch are paid",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusOK,
+	}, handler.UpdatePayRule)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-pay-rule",
+		Method:        http.MethodDelete,
+		Path:          "/payroll/rules/{id}",
+		Summary:       "Delete pay rule",
+		Description:   "Remove a pay rule, the branch falls back to the organization rule",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusOK,
+	}, handler.DeletePayRule)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-ride-earnings",
+		Method:        http.MethodGet,
+		Path:          "/rides/{id}/earnings",
+		Summary:       "Get ride earnings",
+		Description:   "Get what the driver earned for a returned ride with its bonuses and tips",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetRideEarnings)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "recalculate-ride-earnings",
+		Method:        http.MethodPost,
+		Path:          "/rides/{id}/earnings",
+		Summary:       "Recalculate ride earnings",
+		Description:   "Calculate the earnings of a returned ride again with the current pay rule",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusOK,
+	}, handler.RecalculateRideEarnings)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "list-earning-adjustments",
+		Method:        http.MethodGet,
+		Path:          "/drivers/{id}/adjustments",
+		Summary:       "List earning adjustments",
+		Description:   "Get the bonuses and tips of a driver in a period",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ListEarningAdjustments)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-earning-adjustment",
+		Method:        http.MethodPost,
+		Path:          "/drivers/{id}/adjustments",
+		Summary:       "Create earning adjustment",
+		Description:   "Record a bonus or a tip of a driver",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusCreated,
+	}, handler.CreateEarningAdjustment)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-earning-adjustment",
+		Method:        http.MethodDelete,
+		Path:          "/drivers/{id}/adjustments/{adjustment_id}",
+		Summary:       "Delete earning adjustment",
+		Description:   "Remove a bonus or a tip recorded by mistake",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusOK,
+	}, handler.DeleteEarningAdjustment)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "get-payroll",
+		Method:        http.MethodGet,
+		Path:          "/payroll",
+		Summary:       "Get payroll",
+		Description:   "Get what each driver earned in a period",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusOK,
+	}, handler.GetPayroll)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "export-payroll",
+		Method:        http.MethodGet,
+		Path:          "/payroll/export",
+		Summary:       "Export payroll",
+		Description:   "Download the payroll of a period as a CSV file",
+		Tags:          []string{"Payroll"},
+		DefaultStatus: http.StatusOK,
+	}, handler.ExportPayroll)
+
+	// Geocoding endpoints
+	huma.Register(api, huma.Operation{
+		OperationID:   "suggest-addresses",
+		Method:        http.MethodGet,
+		Path:          "/geocode/suggest",
+		Summary:       "Suggest addresses",
+		Description:   "Autocomplete an address and return its coordinates",
+		Tags:          []string{"Geocoding"},
+		DefaultStatus: http.StatusOK,
+	}, handler.SuggestAddresses)
 
 	// Internal endpoints (requires system authentication)
 	//huma.Register(api, huma.Operation{
@@ -0,0 +1,62 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"smartDriver/internal/config"
+)
+
+// Storage drivers
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Object is a stored file opened for reading
+type Object struct {
+	io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// Storage keeps binary objects such as delivery photos
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing an existing object
+	Put(ctx context.Context, key, contentType string, r io.Reader, size int64) error
+	// Get opens the object stored under key
+	Get(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object stored under key, missing objects are ignored
+	Delete(ctx context.Context, key string) error
+}
+
+// Default is the storage configured by Init
+var Default Storage
+
+// Init creates the Default storage for the configured driver
+func Init(cfg *config.Config) error {
+	switch cfg.Blob.Driver {
+	case DriverLocal:
+		storage, err := NewLocal(cfg.Blob.LocalDir)
+		if err != nil {
+			return err
+		}
+		Default = storage
+	case DriverS3:
+		Default = NewS3(S3Options{
+			Endpoint:  cfg.Blob.S3Endpoint,
+			Region:    cfg.Blob.S3Region,
+			Bucket:    cfg.Blob.S3Bucket,
+			AccessKey: cfg.Blob.S3AccessKey,
+			SecretKey: cfg.Blob.S3SecretKey,
+			PathStyle: cfg.Blob.S3PathStyle,
+		})
+	default:
+		return fmt.Errorf("unknown blob storage driver %q", cfg.Blob.Driver)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// contentTypeSuffix names the sidecar file keeping the content type of an object
+const contentTypeSuffix = ".content-type"
+
+// Local stores objects as files under a root directory
+type Local struct {
+	root string
+}
+
+// NewLocal creates a storage keeping objects under root, creating the
+// directory when needed
+func NewLocal(root string) (*Local, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create blob directory: %w", err)
+	}
+	return &Local{root: root}, nil
+}
+
+func (l *Local) Put(ctx context.Context, key, contentType string, r io.Reader, size int64) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create object directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("create object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, io.LimitReader(r, size)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write object: %w", err)
+	}
+	if err := os.WriteFile(path+contentTypeSuffix, []byte(contentType), 0o644); err != nil {
+		return fmt.Errorf("write object content type: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("store object: %w", err)
+	}
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (*Object, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("open object: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("stat object: %w", err)
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if data, err := os.ReadFile(path + contentTypeSuffix); err == nil {
+		contentType = string(data)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &Object{ReadCloser: file, ContentType: contentType, Size: info.Size()}, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{path, path + contentTypeSuffix} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("delete object: %w", err)
+		}
+	}
+	return nil
+}
+
+// Helper function to resolve a key to a path inside the root directory
+func (l *Local) path(key string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(key))
+	if clean == string(filepath.Separator) || strings.HasSuffix(clean, contentTypeSuffix) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.root, clean), nil
+}
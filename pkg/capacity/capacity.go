@@ -0,0 +1,88 @@
+package capacity
+
+import (
+	"fmt"
+	"smartDriver/pkg/drivers"
+)
+
+// DefaultItemWeight and DefaultItemVolume are the size of one unit of a
+// product missing from the product catalog, in grams and milliliters
+const (
+	DefaultItemWeight = 500
+	DefaultItemVolume = 1000
+)
+
+// Size is how much room orders take in a vehicle
+type Size struct {
+	Weight float64 // grams
+	Volume float64 // milliliters
+}
+
+// Add returns the size of both orders together
+func (s Size) Add(other Size) Size {
+	return Size{Weight: s.Weight + other.Weight, Volume: s.Volume + other.Volume}
+}
+
+// Limits is what a vehicle type can carry in a ride. Zero values are not
+// limited.
+type Limits struct {
+	MaxOrders   int
+	MaxWeight   float64 // grams
+	MaxVolume   float64 // milliliters
+	MaxDistance float64 // meters of route from the branch to the last stop
+}
+
+// defaultLimits apply to vehicle types the organization did not configure
+var defaultLimits = map[string]Limits{
+	drivers.VehicleFoot:    {MaxOrders: 3, MaxWeight: 5000, MaxVolume: 20000, MaxDistance: 3000},
+	drivers.VehicleBicycle: {MaxOrders: 5, MaxWeight: 10000, MaxVolume: 40000, MaxDistance: 8000},
+	drivers.VehicleScooter: {MaxOrders: 8, MaxWeight: 20000, MaxVolume: 60000, MaxDistance: 20000},
+	drivers.VehicleCar:     {},
+}
+
+// DefaultLimits returns the limits of a vehicle type the organization did
+// not configure
+func DefaultLimits(vehicleType string) Limits {
+	return defaultLimits[vehicleType]
+}
+
+// Exceeded is returned by Check with the first limit a ride does not fit
+type Exceeded struct {
+	Limit string
+	Value float64
+	Max   float64
+}
+
+// units of the limits in error messages
+var units = map[string]string{
+	"weight":   " g",
+	"volume":   " ml",
+	"distance": " m",
+}
+
+func (e *Exceeded) Error() string {
+	unit := units[e.Limit]
+	return fmt.Sprintf("%s %.0f%s exceeds the vehicle limit of %.0f%s", e.Limit, e.Value, unit, e.Max, unit)
+}
+
+// Check reports the first limit a ride with the orders of the total size and
+// route distance does not fit
+func (l Limits) Check(orders int, size Size, distance float64) error {
+	switch {
+	case l.MaxOrders > 0 && orders > l.MaxOrders:
+		return &Exceeded{Limit: "orders", Value: float64(orders), Max: float64(l.MaxOrders)}
+	case l.MaxWeight > 0 && size.Weight > l.MaxWeight:
+		return &Exceeded{Limit: "weight", Value: size.Weight, Max: l.MaxWeight}
+	case l.MaxVolume > 0 && size.Volume > l.MaxVolume:
+		return &Exceeded{Limit: "volume", Value: size.Volume, Max: l.MaxVolume}
+	case l.MaxDistance > 0 && distance > l.MaxDistance:
+		return &Exceeded{Limit: "distance", Value: distance, Max: l.MaxDistance}
+	}
+	return nil
+}
+
+// Fits reports whether a ride with the orders of the total size fits the
+// limits, not considering the route distance
+func (l Limits) Fits(orders int, size Size) bool {
+	return l.Check(orders, size, 0) == nil
+}
@@ -0,0 +1,94 @@
+package centrifugo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"smartDriver/internal/config"
+	"strings"
+	"time"
+)
+
+// Default is the client configured by Init
+var Default *Client
+
+// Init creates the Default client from the Centrifugo configuration
+func Init(cfg *config.Config) {
+	Default = NewClient(cfg.Centrifugo.URL, cfg.Centrifugo.APIKey)
+}
+
+// Client publishes messages through the Centrifugo server HTTP API
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+}
+
+type publishRequest struct {
+	Channel string `json:"channel"`
+	Data    any    `json:"data"`
+}
+
+type apiResponse struct {
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewClient creates a client for the Centrifugo server at baseURL
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+	}
+}
+
+// OrganizationChannel returns the channel dispatchers of an organization
+// are subscribed to
+func OrganizationChannel(organizationID int64) string {
+	return fmt.Sprintf("orders:%d", organizationID)
+}
+
+// RideChannel returns the channel the driver of a ride is subscribed to
+func RideChannel(rideID int64) string {
+	return fmt.Sprintf("rides:%d", rideID)
+}
+
+// Publish sends data to every subscriber of the channel
+func (c *Client) Publish(ctx context.Context, channel string, data any) error {
+	body, err := json.Marshal(publishRequest{Channel: channel, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal publication: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/publish", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("centrifugo responded with status %d", resp.StatusCode)
+	}
+
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Error != nil {
+		return fmt.Errorf("centrifugo error %d: %s", result.Error.Code, result.Error.Message)
+	}
+
+	return nil
+}
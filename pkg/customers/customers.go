@@ -0,0 +1,77 @@
+package customers
+
+import (
+	"context"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/phone"
+	"strings"
+)
+
+// Flags dispatchers put on problematic customers
+const (
+	FlagNoShow         = "no_show"
+	FlagRefusedPayment = "refused_payment"
+	FlagAggressive     = "aggressive"
+	FlagFraud          = "fraud"
+	FlagOther          = "other"
+)
+
+// Address is a delivery address of a customer
+type Address struct {
+	City      string
+	Street    string
+	Building  string
+	Apartment string
+	Entrance  *int32
+	Floor     *int32
+	Doorphone string
+	Location  geo.Point
+}
+
+// Link finds the customer of an organization by phone, creating it on the
+// first order. Returns nil when the phone cannot be normalized.
+func Link(ctx context.Context, q *db.Queries, organizationID int64, name, rawPhone string) (*int64, error) {
+	normalized := phone.Normalize(rawPhone)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	customer, err := q.UpsertCustomer(ctx, db.UpsertCustomerParams{
+		OrganizationID: organizationID,
+		Phone:          normalized,
+		Name:           strings.TrimSpace(name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert customer: %w", err)
+	}
+
+	return &customer.ID, nil
+}
+
+// RememberAddress saves the delivery address of a new order to the
+// customer's addresses
+func RememberAddress(ctx context.Context, q *db.Queries, customerID int64, addr Address) error {
+	var doorphone *string
+	if addr.Doorphone != "" {
+		doorphone = &addr.Doorphone
+	}
+
+	if err := q.UpsertCustomerAddress(ctx, db.UpsertCustomerAddressParams{
+		CustomerID: customerID,
+		City:       strings.TrimSpace(addr.City),
+		Street:     strings.TrimSpace(addr.Street),
+		Building:   strings.TrimSpace(addr.Building),
+		Apartment:  strings.TrimSpace(addr.Apartment),
+		Entrance:   addr.Entrance,
+		Floor:      addr.Floor,
+		Doorphone:  doorphone,
+		Lng:        addr.Location.Lng,
+		Lat:        addr.Location.Lat,
+	}); err != nil {
+		return fmt.Errorf("failed to save customer address: %w", err)
+	}
+
+	return nil
+}
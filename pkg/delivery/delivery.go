@@ -0,0 +1,37 @@
+package delivery
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"smartDriver/internal/config"
+)
+
+// Kinds of proof of delivery attachments
+const (
+	KindPhoto     = "photo"
+	KindSignature = "signature"
+)
+
+// MaxUploadSize is the largest attachment accepted, in bytes
+var MaxUploadSize int64 = 10 << 20
+
+// Init applies the proof of delivery configuration
+func Init(cfg *config.Config) {
+	if cfg.Delivery.MaxUploadSize > 0 {
+		MaxUploadSize = cfg.Delivery.MaxUploadSize
+	}
+}
+
+// AttachmentKey returns a new unique storage key for an attachment of an order
+func AttachmentKey(organizationID, orderID int64, kind, contentType string) string {
+	var b [8]byte
+	rand.Read(b[:])
+
+	ext := ""
+	if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+		ext = exts[0]
+	}
+	return fmt.Sprintf("deliveries/%d/%d/%s-%s%s", organizationID, orderID, kind, hex.EncodeToString(b[:]), ext)
+}
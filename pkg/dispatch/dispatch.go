@@ -0,0 +1,213 @@
+package dispatch
+
+import (
+	"math"
+	"smartDriver/pkg/capacity"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/route"
+	"sort"
+	"time"
+)
+
+// unpromisedDelay is how urgent an order without a promised time is
+// considered, relative to its creation
+const unpromisedDelay = time.Hour
+
+// Settings limit the rides proposed for a branch
+type Settings struct {
+	// Capacity is the maximum number of orders in a ride
+	Capacity int
+	// Radius is the maximum distance in meters between an order and the
+	// closest order already in the ride
+	Radius float64
+	// TimeWindow is the maximum difference between the promised times of
+	// the most urgent order of a ride and the other orders
+	TimeWindow time.Duration
+	// Vehicle limits the orders, their size and the route of a ride on top of
+	// Capacity
+	Vehicle capacity.Limits
+}
+
+// Order is an order waiting for a ride
+type Order struct {
+	ID         int64
+	Location   geo.Point
+	PromisedAt time.Time
+	CreatedAt  time.Time
+	Size       capacity.Size
+}
+
+// Proposal is a ride suggested to dispatchers
+type Proposal struct {
+	// OrderIDs are in visiting order
+	OrderIDs []int64
+	Plan     route.Plan
+	// Size is the total size of the orders
+	Size capacity.Size
+	// Slack is how long the ride may wait before an order would be late,
+	// zero when the ride should leave now
+	Slack time.Duration
+	// Full reports whether the ride reached the capacity or the vehicle
+	// limits
+	Full bool
+}
+
+// Due reports whether the ride should be created now rather than wait for
+// more orders: it is full or would otherwise be late within lead.
+func (p Proposal) Due(lead time.Duration) bool {
+	return p.Full || p.Slack <= lead
+}
+
+// Propose groups orders into rides leaving origin at now. Starting from the
+// most urgent order, each ride takes the nearest orders within the radius
+// and time window as long as no order becomes late because of it and the
+// ride fits the vehicle. Orders without a usable location and orders the
+// vehicle cannot carry alone are skipped.
+func Propose(origin geo.Point, orders []Order, now time.Time, settings Settings, opts route.Options) []Proposal {
+	pending := make([]Order, 0, len(orders))
+	for _, order := range orders {
+		if order.Location.Valid() {
+			pending = append(pending, order)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return urgency(pending[i]).Before(urgency(pending[j]))
+	})
+
+	limit := settings.Capacity
+	if limit < 1 {
+		limit = 1
+	}
+	if maxOrders := settings.Vehicle.MaxOrders; maxOrders > 0 && maxOrders < limit {
+		limit = maxOrders
+	}
+
+	used := make(map[int64]bool, len(pending))
+	var proposals []Proposal
+
+	for _, seed := range pending {
+		if used[seed.ID] {
+			continue
+		}
+
+		ride := []Order{seed}
+		size := seed.Size
+		plan := route.Optimize(origin, stops(ride), now, opts)
+		if settings.Vehicle.Check(len(ride), size, plan.Distance) != nil {
+			continue
+		}
+		used[seed.ID] = true
+
+		// Orders that would make the ride late or not fit the vehicle are not
+		// reconsidered for it
+		skipped := make(map[int64]bool)
+		full := false
+		for len(ride) < limit {
+			candidate, ok := nearest(ride, pending, used, skipped, seed, settings)
+			if !ok {
+				break
+			}
+
+			next := append(append([]Order(nil), ride...), candidate)
+			nextSize := size.Add(candidate.Size)
+			if !settings.Vehicle.Fits(len(next), nextSize) {
+				skipped[candidate.ID] = true
+				full = true
+				continue
+			}
+			nextPlan := route.Optimize(origin, stops(next), now, opts)
+			if settings.Vehicle.Check(len(next), nextSize, nextPlan.Distance) != nil {
+				skipped[candidate.ID] = true
+				full = true
+				continue
+			}
+			if lateness(nextPlan) > lateness(plan) {
+				skipped[candidate.ID] = true
+				continue
+			}
+			used[candidate.ID] = true
+			ride, size, plan = next, nextSize, nextPlan
+		}
+
+		proposal := newProposal(ride, plan, limit)
+		proposal.Full = proposal.Full || full
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals
+}
+
+// Helper function to find the unused order closest to a ride that fits the
+// radius and the time window of its seed
+func nearest(ride, pending []Order, used, skipped map[int64]bool, seed Order, settings Settings) (Order, bool) {
+	var best Order
+	bestDistance := math.Inf(1)
+	for _, order := range pending {
+		if used[order.ID] || skipped[order.ID] {
+			continue
+		}
+		if settings.TimeWindow > 0 && !seed.PromisedAt.IsZero() && !order.PromisedAt.IsZero() &&
+			order.PromisedAt.Sub(seed.PromisedAt).Abs() > settings.TimeWindow {
+			continue
+		}
+		for _, member := range ride {
+			distance := geo.Distance(member.Location, order.Location)
+			if settings.Radius > 0 && distance > settings.Radius {
+				continue
+			}
+			if distance < bestDistance {
+				best, bestDistance = order, distance
+			}
+		}
+	}
+	return best, !math.IsInf(bestDistance, 1)
+}
+
+// Helper function to build a proposal from a ride and its plan
+func newProposal(ride []Order, plan route.Plan, limit int) Proposal {
+	promised := make(map[int64]time.Time, len(ride))
+	proposal := Proposal{Plan: plan, Full: len(ride) >= limit}
+	for _, order := range ride {
+		promised[order.ID] = order.PromisedAt
+		proposal.Size = proposal.Size.Add(order.Size)
+	}
+
+	slack := time.Duration(math.MaxInt64)
+	for _, leg := range plan.Legs {
+		proposal.OrderIDs = append(proposal.OrderIDs, leg.StopID)
+		if at := promised[leg.StopID]; !at.IsZero() {
+			slack = min(slack, at.Sub(leg.Arrival))
+		}
+	}
+	if slack == time.Duration(math.MaxInt64) || slack < 0 {
+		slack = 0
+	}
+	proposal.Slack = slack
+	return proposal
+}
+
+// Helper function to convert orders to route stops
+func stops(orders []Order) []route.Stop {
+	result := make([]route.Stop, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, route.Stop{ID: order.ID, Location: order.Location, PromisedAt: order.PromisedAt})
+	}
+	return result
+}
+
+// Helper function to sum the lateness of a plan
+func lateness(plan route.Plan) time.Duration {
+	var total time.Duration
+	for _, leg := range plan.Legs {
+		total += leg.Late
+	}
+	return total
+}
+
+// Helper function to get the time an order should be delivered by
+func urgency(order Order) time.Time {
+	if !order.PromisedAt.IsZero() {
+		return order.PromisedAt
+	}
+	return order.CreatedAt.Add(unpromisedDelay)
+}
@@ -0,0 +1,200 @@
+package dispatch
+
+import (
+	"smartDriver/pkg/capacity"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/route"
+	"testing"
+	"time"
+)
+
+var (
+	now    = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	origin = geo.Point{Lat: 55.7, Lng: 37.6}
+	opts   = route.Options{Speed: 36, DetourFactor: 1, ServiceTime: 5 * time.Minute}
+)
+
+// north returns the point about km kilometers north of origin
+func north(km float64) geo.Point {
+	return geo.Point{Lat: origin.Lat + km*0.009, Lng: origin.Lng}
+}
+
+func TestPropose(t *testing.T) {
+	tests := []struct {
+		name     string
+		orders   []Order
+		settings Settings
+		want     [][]int64
+		full     []bool
+	}{
+		{
+			name:     "no orders",
+			settings: Settings{Capacity: 3},
+		},
+		{
+			name: "one ride",
+			orders: []Order{
+				{ID: 1, Location: north(2), CreatedAt: now},
+				{ID: 2, Location: north(1), CreatedAt: now},
+			},
+			settings: Settings{Capacity: 3},
+			want:     [][]int64{{2, 1}},
+			full:     []bool{false},
+		},
+		{
+			name: "capacity splits rides",
+			orders: []Order{
+				{ID: 1, Location: north(1), PromisedAt: now.Add(time.Hour)},
+				{ID: 2, Location: north(1.1), PromisedAt: now.Add(time.Hour)},
+				{ID: 3, Location: north(1.2), PromisedAt: now.Add(2 * time.Hour)},
+			},
+			settings: Settings{Capacity: 2},
+			want:     [][]int64{{1, 2}, {3}},
+			full:     []bool{true, false},
+		},
+		{
+			name: "radius",
+			orders: []Order{
+				{ID: 1, Location: north(1), PromisedAt: now.Add(time.Hour)},
+				{ID: 2, Location: north(5), PromisedAt: now.Add(time.Hour)},
+			},
+			settings: Settings{Capacity: 3, Radius: 2000},
+			want:     [][]int64{{1}, {2}},
+			full:     []bool{false, false},
+		},
+		{
+			name: "time window",
+			orders: []Order{
+				{ID: 1, Location: north(1), PromisedAt: now.Add(time.Hour)},
+				{ID: 2, Location: north(1.1), PromisedAt: now.Add(3 * time.Hour)},
+			},
+			settings: Settings{Capacity: 3, TimeWindow: 30 * time.Minute},
+			want:     [][]int64{{1}, {2}},
+			full:     []bool{false, false},
+		},
+		{
+			name: "order that would be late stays out",
+			orders: []Order{
+				{ID: 1, Location: north(1), PromisedAt: now.Add(2 * time.Minute)},
+				{ID: 2, Location: north(-1), PromisedAt: now.Add(4 * time.Minute)},
+			},
+			settings: Settings{Capacity: 3},
+			want:     [][]int64{{1}, {2}},
+			full:     []bool{false, false},
+		},
+		{
+			name: "orders without a location are skipped",
+			orders: []Order{
+				{ID: 1, CreatedAt: now},
+				{ID: 2, Location: north(1), CreatedAt: now},
+			},
+			settings: Settings{Capacity: 3},
+			want:     [][]int64{{2}},
+			full:     []bool{false},
+		},
+		{
+			name: "vehicle weight",
+			orders: []Order{
+				{ID: 1, Location: north(1), PromisedAt: now.Add(time.Hour), Size: capacity.Size{Weight: 3000}},
+				{ID: 2, Location: north(1.1), PromisedAt: now.Add(time.Hour), Size: capacity.Size{Weight: 3000}},
+			},
+			settings: Settings{Capacity: 3, Vehicle: capacity.Limits{MaxWeight: 5000}},
+			want:     [][]int64{{1}, {2}},
+			full:     []bool{true, false},
+		},
+		{
+			name: "too heavy for the vehicle alone",
+			orders: []Order{
+				{ID: 1, Location: north(1), PromisedAt: now.Add(time.Hour), Size: capacity.Size{Weight: 8000}},
+				{ID: 2, Location: north(1.1), PromisedAt: now.Add(time.Hour)},
+			},
+			settings: Settings{Capacity: 3, Vehicle: capacity.Limits{MaxWeight: 5000}},
+			want:     [][]int64{{2}},
+			full:     []bool{true},
+		},
+		{
+			name: "vehicle orders below capacity",
+			orders: []Order{
+				{ID: 1, Location: north(1), PromisedAt: now.Add(time.Hour)},
+				{ID: 2, Location: north(1.1), PromisedAt: now.Add(time.Hour)},
+			},
+			settings: Settings{Capacity: 5, Vehicle: capacity.Limits{MaxOrders: 1}},
+			want:     [][]int64{{1}, {2}},
+			full:     []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proposals := Propose(origin, tt.orders, now, tt.settings, opts)
+			if len(proposals) != len(tt.want) {
+				t.Fatalf("Propose() returned %d rides, want %d: %+v", len(proposals), len(tt.want), proposals)
+			}
+			for i, proposal := range proposals {
+				if !equalIDs(proposal.OrderIDs, tt.want[i]) {
+					t.Errorf("ride %d has orders %v, want %v", i, proposal.OrderIDs, tt.want[i])
+				}
+				if proposal.Full != tt.full[i] {
+					t.Errorf("ride %d Full = %v, want %v", i, proposal.Full, tt.full[i])
+				}
+			}
+		})
+	}
+}
+
+func TestProposalSlack(t *testing.T) {
+	tests := []struct {
+		name  string
+		order Order
+		want  time.Duration
+	}{
+		{"not promised", Order{ID: 1, Location: north(1), CreatedAt: now}, 0},
+		{"late already", Order{ID: 1, Location: north(1), PromisedAt: now.Add(time.Minute)}, 0},
+		{"time to wait", Order{ID: 1, Location: north(1), PromisedAt: now.Add(time.Hour)}, time.Hour - opts.TravelTime(geo.Distance(origin, north(1)))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proposals := Propose(origin, []Order{tt.order}, now, Settings{Capacity: 3}, opts)
+			if len(proposals) != 1 {
+				t.Fatalf("Propose() returned %d rides, want 1", len(proposals))
+			}
+			if got := proposals[0].Slack; got != tt.want {
+				t.Errorf("Slack = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProposalDue(t *testing.T) {
+	tests := []struct {
+		name     string
+		proposal Proposal
+		want     bool
+	}{
+		{"full", Proposal{Full: true, Slack: time.Hour}, true},
+		{"within lead", Proposal{Slack: 5 * time.Minute}, true},
+		{"at lead", Proposal{Slack: 10 * time.Minute}, true},
+		{"can wait", Proposal{Slack: time.Hour}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.proposal.Due(10 * time.Minute); got != tt.want {
+				t.Errorf("Due() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package dispatch
+
+import (
+	"context"
+	"smartDriver/pkg/log"
+	"time"
+)
+
+// EventRideAutoCreated is published to dispatchers when a ride is created
+// automatically
+const EventRideAutoCreated = "ride_auto_created"
+
+// Event tells dispatchers about an automatically created ride
+type Event struct {
+	Type     string  `json:"type"`
+	RideID   int64   `json:"ride_id"`
+	BranchID int64   `json:"branch_id"`
+	DriverID *int64  `json:"driver_id"`
+	OrderIDs []int64 `json:"order_ids"`
+}
+
+// RunFunc creates the rides that are due for organizations with automatic
+// dispatch enabled. Rides are due when full or when waiting longer than
+// lead would make an order late.
+type RunFunc func(ctx context.Context, lead time.Duration) error
+
+// Worker periodically creates rides for organizations that enabled
+// automatic dispatch
+type Worker struct {
+	run      RunFunc
+	interval time.Duration
+	lead     time.Duration
+}
+
+// NewWorker creates a new automatic dispatch worker
+func NewWorker(run RunFunc, interval, lead time.Duration) *Worker {
+	return &Worker{
+		run:      run,
+		interval: interval,
+		lead:     lead,
+	}
+}
+
+// Start runs the dispatch in the background until the context is cancelled
+func (w *Worker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := w.run(ctx, w.lead); err != nil {
+				log.SugaredLogger.Errorf("failed to dispatch rides automatically: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
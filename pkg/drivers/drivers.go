@@ -0,0 +1,24 @@
+package drivers
+
+// Driver statuses
+const (
+	// StatusOnDuty drivers work their shift and may be assigned rides
+	StatusOnDuty = "on_duty"
+	// StatusOffDuty drivers are not working at the moment
+	StatusOffDuty = "off_duty"
+	// StatusInactive drivers no longer work for the organization
+	StatusInactive = "inactive"
+)
+
+// Vehicle types
+const (
+	VehicleFoot    = "foot"
+	VehicleBicycle = "bicycle"
+	VehicleScooter = "scooter"
+	VehicleCar     = "car"
+)
+
+// CanDrive reports whether a driver in the status may be assigned a ride
+func CanDrive(status string) bool {
+	return status == StatusOnDuty
+}
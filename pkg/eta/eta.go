@@ -0,0 +1,112 @@
+package eta
+
+import (
+	"smartDriver/pkg/drivers"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/route"
+	"time"
+)
+
+// EventETAUpdated is published when the arrival estimates of a ride change
+const EventETAUpdated = "eta_updated"
+
+// Profile is how fast a vehicle type moves and how long handing over an
+// order takes
+type Profile struct {
+	// Speed is the average speed in kilometers per hour
+	Speed float64
+	// ServiceTime is spent at each stop handing over the order
+	ServiceTime time.Duration
+}
+
+// defaultSpeeds are the average speeds in kilometers per hour of vehicle
+// types in city traffic. Cars follow the route configuration.
+var defaultSpeeds = map[string]float64{
+	drivers.VehicleFoot:    5,
+	drivers.VehicleBicycle: 15,
+	drivers.VehicleScooter: 25,
+}
+
+// VehicleTypes lists the vehicle types profiles are kept for
+var VehicleTypes = []string{drivers.VehicleFoot, drivers.VehicleBicycle, drivers.VehicleScooter, drivers.VehicleCar}
+
+// DefaultProfile returns the profile of a vehicle type the organization did
+// not configure
+func DefaultProfile(vehicleType string) Profile {
+	profile := Profile{
+		Speed:       route.DefaultOptions.Speed,
+		ServiceTime: route.DefaultOptions.ServiceTime,
+	}
+	if speed, ok := defaultSpeeds[vehicleType]; ok {
+		profile.Speed = speed
+	}
+	return profile
+}
+
+// Options converts the profile to route options
+func (p Profile) Options() route.Options {
+	return route.Options{
+		Speed:        p.Speed,
+		DetourFactor: route.DefaultOptions.DetourFactor,
+		ServiceTime:  p.ServiceTime,
+	}
+}
+
+// Stop is an unfinished stop of a ride
+type Stop struct {
+	OrderID  int64
+	Location geo.Point
+	// ArrivedAt is when the driver reached the stop, zero while on the way
+	ArrivedAt time.Time
+}
+
+// Arrival is the estimated arrival of the driver at a stop
+type Arrival struct {
+	OrderID int64     `json:"order_id"`
+	ETA     time.Time `json:"eta"`
+}
+
+// Event tells the ride channel when the driver is expected at each stop
+type Event struct {
+	Type     string    `json:"type"`
+	RideID   int64     `json:"ride_id"`
+	Arrivals []Arrival `json:"arrivals"`
+}
+
+// Estimate returns when the driver at origin reaches the stops, visiting them
+// in the given order from now on. Stops the driver reached keep their arrival
+// time and the driver leaves them once the order is handed over. Stops
+// without a usable location add only the service time.
+func Estimate(origin geo.Point, now time.Time, stops []Stop, opts route.Options) []Arrival {
+	arrivals := make([]Arrival, 0, len(stops))
+	position, clock := origin, now
+	busy := false // the driver is handing over an order
+
+	for _, stop := range stops {
+		if !stop.ArrivedAt.IsZero() {
+			arrivals = append(arrivals, Arrival{OrderID: stop.OrderID, ETA: stop.ArrivedAt})
+			if stop.Location.Valid() {
+				position = stop.Location
+			}
+			if leave := stop.ArrivedAt.Add(opts.ServiceTime); leave.After(clock) {
+				clock = leave
+			}
+			busy = false
+			continue
+		}
+
+		if busy {
+			clock = clock.Add(opts.ServiceTime)
+		}
+		if stop.Location.Valid() && position.Valid() {
+			clock = clock.Add(opts.TravelTime(opts.RoadDistance(position, stop.Location)))
+		}
+		if stop.Location.Valid() {
+			position = stop.Location
+		}
+		arrivals = append(arrivals, Arrival{OrderID: stop.OrderID, ETA: clock})
+		busy = true
+	}
+
+	return arrivals
+}
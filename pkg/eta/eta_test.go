@@ -0,0 +1,98 @@
+package eta
+
+import (
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/route"
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	origin := geo.Point{Lat: 55.7, Lng: 37.6}
+	first := geo.Point{Lat: 55.709, Lng: 37.6}
+	second := geo.Point{Lat: 55.718, Lng: 37.6}
+	opts := route.Options{Speed: 36, DetourFactor: 1, ServiceTime: 5 * time.Minute}
+
+	toFirst := opts.TravelTime(geo.Distance(origin, first))
+	between := opts.TravelTime(geo.Distance(first, second))
+
+	tests := []struct {
+		name   string
+		origin geo.Point
+		stops  []Stop
+		want   []time.Time
+	}{
+		{
+			name:   "no stops",
+			origin: origin,
+			want:   []time.Time{},
+		},
+		{
+			name:   "one stop",
+			origin: origin,
+			stops:  []Stop{{OrderID: 1, Location: first}},
+			want:   []time.Time{now.Add(toFirst)},
+		},
+		{
+			name:   "service time between stops",
+			origin: origin,
+			stops:  []Stop{{OrderID: 1, Location: first}, {OrderID: 2, Location: second}},
+			want:   []time.Time{now.Add(toFirst), now.Add(toFirst + opts.ServiceTime + between)},
+		},
+		{
+			name:   "arrived stop keeps its time",
+			origin: origin,
+			stops: []Stop{
+				{OrderID: 1, Location: first, ArrivedAt: now.Add(-2 * time.Minute)},
+				{OrderID: 2, Location: second},
+			},
+			want: []time.Time{now.Add(-2 * time.Minute), now.Add(3*time.Minute + between)},
+		},
+		{
+			name:   "handed over long ago",
+			origin: origin,
+			stops: []Stop{
+				{OrderID: 1, Location: first, ArrivedAt: now.Add(-time.Hour)},
+				{OrderID: 2, Location: second},
+			},
+			want: []time.Time{now.Add(-time.Hour), now.Add(between)},
+		},
+		{
+			name:   "stop without a location adds the service time",
+			origin: origin,
+			stops: []Stop{
+				{OrderID: 1, Location: first},
+				{OrderID: 2},
+				{OrderID: 3, Location: second},
+			},
+			want: []time.Time{
+				now.Add(toFirst),
+				now.Add(toFirst + opts.ServiceTime),
+				now.Add(toFirst + 2*opts.ServiceTime + between),
+			},
+		},
+		{
+			name:  "unknown driver position",
+			stops: []Stop{{OrderID: 1, Location: first}},
+			want:  []time.Time{now},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Estimate(tt.origin, now, tt.stops, opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Estimate() returned %d arrivals, want %d", len(got), len(tt.want))
+			}
+			for i, arrival := range got {
+				if arrival.OrderID != tt.stops[i].OrderID {
+					t.Errorf("arrival %d is for order %d, want %d", i, arrival.OrderID, tt.stops[i].OrderID)
+				}
+				if !arrival.ETA.Equal(tt.want[i]) {
+					t.Errorf("ETA of order %d = %v, want %v", arrival.OrderID, arrival.ETA, tt.want[i])
+				}
+			}
+		})
+	}
+}
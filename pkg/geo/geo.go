@@ -0,0 +1,115 @@
+package geo
+
+import (
+	"math"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const earthRadius = 6371000.0 // meters
+
+// Point is a geographic coordinate. Database points store longitude in X
+// and latitude in Y, the same order GeoJSON uses.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// IsZero reports whether the point is the (0, 0) placeholder iiko leaves
+// when an order has no coordinates.
+func (p Point) IsZero() bool {
+	return p.Lat == 0 && p.Lng == 0
+}
+
+// Valid reports whether the point is a usable coordinate: within the
+// latitude and longitude ranges and not the (0, 0) placeholder.
+func (p Point) Valid() bool {
+	return !p.IsZero() &&
+		p.Lat >= -90 && p.Lat <= 90 &&
+		p.Lng >= -180 && p.Lng <= 180
+}
+
+// Pg converts the point to its database representation
+func (p Point) Pg() pgtype.Point {
+	return pgtype.Point{P: pgtype.Vec2{X: p.Lng, Y: p.Lat}, Valid: true}
+}
+
+// PointFromPg converts a database point to Point
+func PointFromPg(p pgtype.Point) Point {
+	return Point{Lat: p.P.Y, Lng: p.P.X}
+}
+
+// Round returns the point with coordinates rounded to the number of decimal
+// places. Three places blur a position to about a hundred meters.
+func (p Point) Round(places int) Point {
+	scale := math.Pow(10, float64(places))
+	return Point{
+		Lat: math.Round(p.Lat*scale) / scale,
+		Lng: math.Round(p.Lng*scale) / scale,
+	}
+}
+
+// Distance returns the great-circle distance between two points in meters
+func Distance(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Polygon is a simple closed ring of points. The closing point is implicit
+// and must not be repeated.
+type Polygon []Point
+
+// Contains reports whether the point lies inside the polygon using the
+// even-odd ray casting rule. Points exactly on an edge may fall either way.
+func (p Polygon) Contains(pt Point) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lng < (b.Lng-a.Lng)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Bounds returns the south-west and north-east corners of the polygon
+func (p Polygon) Bounds() (min, max Point) {
+	if len(p) == 0 {
+		return Point{}, Point{}
+	}
+
+	min, max = p[0], p[0]
+	for _, pt := range p[1:] {
+		min.Lat = math.Min(min.Lat, pt.Lat)
+		min.Lng = math.Min(min.Lng, pt.Lng)
+		max.Lat = math.Max(max.Lat, pt.Lat)
+		max.Lng = math.Max(max.Lng, pt.Lng)
+	}
+	return min, max
+}
+
+// Pg converts the polygon to its database representation
+func (p Polygon) Pg() pgtype.Polygon {
+	vertices := make([]pgtype.Vec2, 0, len(p))
+	for _, pt := range p {
+		vertices = append(vertices, pgtype.Vec2{X: pt.Lng, Y: pt.Lat})
+	}
+	return pgtype.Polygon{P: vertices, Valid: true}
+}
+
+// PolygonFromPg converts a database polygon to Polygon
+func PolygonFromPg(p pgtype.Polygon) Polygon {
+	polygon := make(Polygon, 0, len(p.P))
+	for _, v := range p.P {
+		polygon = append(polygon, Point{Lat: v.Y, Lng: v.X})
+	}
+	return polygon
+}
@@ -0,0 +1,174 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+// square returns the polygon with the south-west corner at lat, lng and
+// sides of one degree
+func square(lat, lng float64) Polygon {
+	return Polygon{{lat, lng}, {lat, lng + 1}, {lat + 1, lng + 1}, {lat + 1, lng}}
+}
+
+func TestPolygonContains(t *testing.T) {
+	concave := Polygon{{55, 37}, {55, 40}, {58, 40}, {58, 39}, {56, 39}, {56, 38}, {58, 38}, {58, 37}}
+
+	tests := []struct {
+		name    string
+		polygon Polygon
+		point   Point
+		want    bool
+	}{
+		{"inside", square(55, 37), Point{55.5, 37.5}, true},
+		{"outside north", square(55, 37), Point{56.5, 37.5}, false},
+		{"outside east", square(55, 37), Point{55.5, 38.5}, false},
+		{"in line with an edge outside", square(55, 37), Point{55, 39}, false},
+		{"concave arm", concave, Point{57, 37.5}, true},
+		{"concave notch", concave, Point{57, 38.5}, false},
+		{"concave base", concave, Point{55.5, 38.5}, true},
+		{"triangle", Polygon{{55, 37}, {55, 39}, {57, 38}}, Point{55.5, 38}, true},
+		{"beside triangle", Polygon{{55, 37}, {55, 39}, {57, 38}}, Point{56.5, 37.1}, false},
+		{"empty", nil, Point{55.5, 37.5}, false},
+		{"single point", Polygon{{55.5, 37.5}}, Point{55.5, 37.5}, false},
+		{"segment", Polygon{{55, 37}, {56, 38}}, Point{55.5, 37.5}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.polygon.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+// Points on an edge or vertex may fall either way, but a point shared by
+// adjacent zones must belong to exactly one of them
+func TestPolygonContainsSharedBoundary(t *testing.T) {
+	grid := []Polygon{square(55, 37), square(55, 38), square(56, 37), square(56, 38)}
+
+	tests := []struct {
+		name  string
+		point Point
+	}{
+		{"shared vertex", Point{56, 38}},
+		{"vertical edge", Point{55.5, 38}},
+		{"horizontal edge", Point{56, 37.5}},
+		{"upper vertical edge", Point{56.5, 38}},
+		{"right horizontal edge", Point{56, 38.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := 0
+			for _, polygon := range grid {
+				if polygon.Contains(tt.point) {
+					count++
+				}
+			}
+			if count != 1 {
+				t.Errorf("%v is in %d zones, want 1", tt.point, count)
+			}
+		})
+	}
+}
+
+func TestPolygonBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		polygon  Polygon
+		min, max Point
+	}{
+		{"empty", nil, Point{}, Point{}},
+		{"square", square(55, 37), Point{55, 37}, Point{56, 38}},
+		{"triangle", Polygon{{57, 38}, {55, 39}, {55, 37}}, Point{55, 37}, Point{57, 39}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			min, max := tt.polygon.Bounds()
+			if min != tt.min || max != tt.max {
+				t.Errorf("Bounds() = %v, %v, want %v, %v", min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{"same point", Point{55.75, 37.62}, Point{55.75, 37.62}, 0},
+		{"one degree of latitude", Point{55, 37}, Point{56, 37}, 111195},
+		{"one degree of longitude at the equator", Point{0, 37}, Point{0, 38}, 111195},
+		{"antipodes", Point{0, 0}, Point{0, 180}, math.Pi * earthRadius},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Distance(tt.a, tt.b)
+			if math.Abs(got-tt.want) > 1 {
+				t.Errorf("Distance() = %.0f, want %.0f", got, tt.want)
+			}
+			if back := Distance(tt.b, tt.a); math.Abs(back-got) > 1e-6 {
+				t.Errorf("Distance() is not symmetric: %.3f and %.3f", got, back)
+			}
+		})
+	}
+}
+
+func TestPointValid(t *testing.T) {
+	tests := []struct {
+		name  string
+		point Point
+		want  bool
+	}{
+		{"moscow", Point{55.75, 37.62}, true},
+		{"placeholder", Point{}, false},
+		{"equator", Point{0, 37.62}, true},
+		{"latitude out of range", Point{91, 37}, false},
+		{"longitude out of range", Point{55, -181}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.point.Valid(); got != tt.want {
+				t.Errorf("Valid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZoneIndexLocate(t *testing.T) {
+	index := NewZoneIndex([]Zone{
+		{ID: 1, BranchID: 10, BranchLocation: Point{55.1, 37.1}, Area: Polygon{{55, 37}, {55, 39}, {56, 39}, {56, 37}}},
+		{ID: 2, BranchID: 20, BranchLocation: Point{55.9, 38.9}, Area: Polygon{{55, 38}, {55, 40}, {56, 40}, {56, 38}}},
+		{ID: 3, BranchID: 30, BranchLocation: Point{57.5, 37.5}, Area: square(57, 37)},
+	})
+
+	tests := []struct {
+		name  string
+		point Point
+		want  int64
+		found bool
+	}{
+		{"single zone", Point{55.5, 37.5}, 1, true},
+		{"other zone", Point{57.5, 37.5}, 3, true},
+		{"overlap closer to the first branch", Point{55.2, 38.1}, 1, true},
+		{"overlap closer to the second branch", Point{55.8, 38.9}, 2, true},
+		{"outside", Point{58.5, 37.5}, 0, false},
+		{"within bounds of a concave gap", Point{56.5, 37.5}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone, found := index.Locate(tt.point)
+			if found != tt.found || zone.ID != tt.want {
+				t.Errorf("Locate(%v) = %d, %v, want %d, %v", tt.point, zone.ID, found, tt.want, tt.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	TypeFeatureCollection = "FeatureCollection"
+	TypeFeature           = "Feature"
+	TypePolygon           = "Polygon"
+)
+
+var (
+	ErrUnsupportedGeometry = errors.New("only Polygon geometries are supported")
+	ErrPolygonHoles        = errors.New("polygons with holes are not supported")
+	ErrTooFewVertices      = errors.New("polygon must have at least 3 distinct vertices")
+)
+
+// FeatureCollection is a GeoJSON feature collection (RFC 7946)
+type FeatureCollection struct {
+	Type     string    `json:"type" enum:"FeatureCollection" doc:"GeoJSON object type"`
+	Features []Feature `json:"features" doc:"List of features"`
+}
+
+// Feature is a GeoJSON feature with free-form properties
+type Feature struct {
+	Type       string         `json:"type" enum:"Feature" doc:"GeoJSON object type"`
+	Geometry   Geometry       `json:"geometry" doc:"Feature geometry"`
+	Properties map[string]any `json:"properties,omitempty" doc:"Feature properties"`
+}
+
+// Geometry is a GeoJSON Polygon geometry. Coordinates are [longitude, latitude]
+// pairs grouped into linear rings.
+type Geometry struct {
+	Type        string        `json:"type" enum:"Polygon" doc:"Geometry type"`
+	Coordinates [][][]float64 `json:"coordinates" doc:"Polygon linear rings of [lng, lat] positions"`
+}
+
+// PolygonFromGeometry converts a GeoJSON Polygon geometry to Polygon.
+// The ring may be closed or open; the closing position is dropped.
+func PolygonFromGeometry(g Geometry) (Polygon, error) {
+	if g.Type != TypePolygon {
+		return nil, ErrUnsupportedGeometry
+	}
+	if len(g.Coordinates) == 0 {
+		return nil, ErrTooFewVertices
+	}
+	if len(g.Coordinates) > 1 {
+		return nil, ErrPolygonHoles
+	}
+
+	ring := g.Coordinates[0]
+	polygon := make(Polygon, 0, len(ring))
+	for i, position := range ring {
+		if len(position) < 2 {
+			return nil, fmt.Errorf("position %d must contain longitude and latitude", i)
+		}
+		polygon = append(polygon, Point{Lat: position[1], Lng: position[0]})
+	}
+
+	if len(polygon) > 1 && polygon[0] == polygon[len(polygon)-1] {
+		polygon = polygon[:len(polygon)-1]
+	}
+	if len(polygon) < 3 {
+		return nil, ErrTooFewVertices
+	}
+
+	return polygon, nil
+}
+
+// Geometry converts the polygon to a closed GeoJSON Polygon geometry
+func (p Polygon) Geometry() Geometry {
+	ring := make([][]float64, 0, len(p)+1)
+	for _, pt := range p {
+		ring = append(ring, []float64{pt.Lng, pt.Lat})
+	}
+	if len(p) > 0 {
+		ring = append(ring, []float64{p[0].Lng, p[0].Lat})
+	}
+
+	return Geometry{
+		Type:        TypePolygon,
+		Coordinates: [][][]float64{ring},
+	}
+}
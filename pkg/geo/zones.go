@@ -0,0 +1,79 @@
+package geo
+
+import (
+	"context"
+	"smartDriver/internal/db"
+)
+
+// Zone is a delivery area served by a branch
+type Zone struct {
+	ID             int64
+	BranchID       int64
+	BranchLocation Point
+	Area           Polygon
+
+	min, max Point
+}
+
+// ZoneIndex resolves which branch serves a point
+type ZoneIndex struct {
+	zones []Zone
+}
+
+// NewZoneIndex builds an index over the given zones
+func NewZoneIndex(zones []Zone) *ZoneIndex {
+	index := &ZoneIndex{zones: make([]Zone, 0, len(zones))}
+	for _, zone := range zones {
+		zone.min, zone.max = zone.Area.Bounds()
+		index.zones = append(index.zones, zone)
+	}
+	return index
+}
+
+// Locate returns the zone containing the point. When zones of several
+// branches overlap, the zone whose branch is closest to the point wins.
+func (i *ZoneIndex) Locate(pt Point) (Zone, bool) {
+	var (
+		best     Zone
+		bestDist float64
+		found    bool
+	)
+
+	for _, zone := range i.zones {
+		if pt.Lat < zone.min.Lat || pt.Lat > zone.max.Lat ||
+			pt.Lng < zone.min.Lng || pt.Lng > zone.max.Lng {
+			continue
+		}
+		if !zone.Area.Contains(pt) {
+			continue
+		}
+
+		dist := Distance(pt, zone.BranchLocation)
+		if !found || dist < bestDist {
+			best, bestDist, found = zone, dist, true
+		}
+	}
+
+	return best, found
+}
+
+// LoadZoneIndex builds the delivery zone index of all branches of an
+// organization
+func LoadZoneIndex(ctx context.Context, q *db.Queries, organizationID int64) (*ZoneIndex, error) {
+	rows, err := q.ListOrganizationDeliveryZones(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make([]Zone, 0, len(rows))
+	for _, row := range rows {
+		zones = append(zones, Zone{
+			ID:             row.ID,
+			BranchID:       row.BranchID,
+			BranchLocation: PointFromPg(row.BranchLocation),
+			Area:           PolygonFromPg(row.Area),
+		})
+	}
+
+	return NewZoneIndex(zones), nil
+}
@@ -0,0 +1,121 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"smartDriver/pkg/geo"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache wraps a Geocoder and remembers its answers, including addresses
+// that were not found, for a limited time
+type Cache struct {
+	next    Geocoder
+	ttl     time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	location    geo.Point
+	suggestions []Suggestion
+	notFound    bool
+	expiresAt   time.Time
+}
+
+// NewCache creates a cache holding at most maxSize answers for ttl each
+func NewCache(next Geocoder, ttl time.Duration, maxSize int) *Cache {
+	return &Cache{
+		next:    next,
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Geocode implements Geocoder
+func (c *Cache) Geocode(ctx context.Context, address string) (geo.Point, error) {
+	key := "geocode:" + normalizeQuery(address)
+	if entry, ok := c.get(key); ok {
+		if entry.notFound {
+			return geo.Point{}, ErrNotFound
+		}
+		return entry.location, nil
+	}
+
+	location, err := c.next.Geocode(ctx, address)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.set(key, cacheEntry{notFound: true})
+		return geo.Point{}, err
+	case err != nil:
+		return geo.Point{}, err
+	}
+
+	c.set(key, cacheEntry{location: location})
+	return location, nil
+}
+
+// Suggest implements Geocoder
+func (c *Cache) Suggest(ctx context.Context, query string, limit int) ([]Suggestion, error) {
+	key := fmt.Sprintf("suggest:%d:%s", limit, normalizeQuery(query))
+	if entry, ok := c.get(key); ok {
+		return entry.suggestions, nil
+	}
+
+	suggestions, err := c.next.Suggest(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	c.set(key, cacheEntry{suggestions: suggestions})
+	return suggestions, nil
+}
+
+func (c *Cache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *Cache) set(key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.maxSize {
+		// Drop expired answers first, then an arbitrary one
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.maxSize {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+
+	entry.expiresAt = now.Add(c.ttl)
+	c.entries[key] = entry
+}
+
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
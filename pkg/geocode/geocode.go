@@ -0,0 +1,61 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"smartDriver/internal/config"
+	"smartDriver/pkg/geo"
+	"strings"
+)
+
+// Where the location of an order comes from
+const (
+	LocationProvided = "provided"
+	LocationGeocoded = "geocoded"
+	LocationMissing  = "missing"
+)
+
+// ErrNotFound is returned when an address cannot be resolved
+var ErrNotFound = errors.New("address not found")
+
+// Suggestion is an address matching a search query
+type Suggestion struct {
+	Address  string    `json:"address"`
+	Location geo.Point `json:"location"`
+}
+
+// Geocoder resolves addresses to coordinates
+type Geocoder interface {
+	// Geocode returns the location of the best match for the address
+	Geocode(ctx context.Context, address string) (geo.Point, error)
+	// Suggest returns up to limit addresses matching a partial query
+	Suggest(ctx context.Context, query string, limit int) ([]Suggestion, error)
+}
+
+// Default is the geocoder configured by Init
+var Default Geocoder
+
+// Init creates the Default geocoder from the configuration: a Nominatim
+// compatible HTTP geocoder wrapped in a cache.
+func Init(cfg *config.Config) {
+	nominatim := NewNominatim(NominatimOptions{
+		BaseURL:      cfg.Geocoder.URL,
+		UserAgent:    cfg.Geocoder.UserAgent,
+		Language:     cfg.Geocoder.Language,
+		CountryCodes: cfg.Geocoder.CountryCodes,
+		Timeout:      cfg.Geocoder.Timeout,
+		MinInterval:  cfg.Geocoder.MinInterval,
+	})
+	Default = NewCache(nominatim, cfg.Geocoder.CacheTTL, cfg.Geocoder.CacheSize)
+}
+
+// FormatAddress joins the non-empty address parts into a search query
+func FormatAddress(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, ", ")
+}
@@ -0,0 +1,144 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"smartDriver/pkg/geo"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NominatimOptions configures a Nominatim geocoder
+type NominatimOptions struct {
+	// BaseURL of the Nominatim API, e.g. https://nominatim.openstreetmap.org
+	// or a local instance
+	BaseURL string
+	// UserAgent identifies the application as the Nominatim usage policy requires
+	UserAgent string
+	// Language of the returned addresses
+	Language string
+	// CountryCodes limits results to the comma separated ISO 3166-1 codes
+	CountryCodes string
+	// Timeout of a single request
+	Timeout time.Duration
+	// MinInterval between requests, the public instance allows one per second
+	MinInterval time.Duration
+}
+
+// Nominatim is a Geocoder talking to a Nominatim compatible search API
+type Nominatim struct {
+	httpClient *http.Client
+	opts       NominatimOptions
+
+	mu       sync.Mutex
+	lastCall time.Time
+}
+
+type nominatimPlace struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	DisplayName string `json:"display_name"`
+}
+
+// NewNominatim creates a Nominatim geocoder
+func NewNominatim(opts NominatimOptions) *Nominatim {
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	return &Nominatim{
+		httpClient: &http.Client{Timeout: opts.Timeout},
+		opts:       opts,
+	}
+}
+
+// Geocode implements Geocoder
+func (n *Nominatim) Geocode(ctx context.Context, address string) (geo.Point, error) {
+	suggestions, err := n.search(ctx, address, 1)
+	if err != nil {
+		return geo.Point{}, err
+	}
+	if len(suggestions) == 0 {
+		return geo.Point{}, ErrNotFound
+	}
+	return suggestions[0].Location, nil
+}
+
+// Suggest implements Geocoder
+func (n *Nominatim) Suggest(ctx context.Context, query string, limit int) ([]Suggestion, error) {
+	return n.search(ctx, query, limit)
+}
+
+func (n *Nominatim) search(ctx context.Context, query string, limit int) ([]Suggestion, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "jsonv2")
+	params.Set("limit", strconv.Itoa(limit))
+	if n.opts.CountryCodes != "" {
+		params.Set("countrycodes", n.opts.CountryCodes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.opts.BaseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", n.opts.UserAgent)
+	if n.opts.Language != "" {
+		req.Header.Set("Accept-Language", n.opts.Language)
+	}
+
+	if err := n.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nominatim responded with status %d", resp.StatusCode)
+	}
+
+	var places []nominatimPlace
+	if err := json.NewDecoder(resp.Body).Decode(&places); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	suggestions := make([]Suggestion, 0, len(places))
+	for _, place := range places {
+		lat, errLat := strconv.ParseFloat(place.Lat, 64)
+		lng, errLng := strconv.ParseFloat(place.Lon, 64)
+		if errLat != nil || errLng != nil {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			Address:  place.DisplayName,
+			Location: geo.Point{Lat: lat, Lng: lng},
+		})
+	}
+
+	return suggestions, nil
+}
+
+// wait throttles requests to MinInterval
+func (n *Nominatim) wait(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if delay := time.Until(n.lastCall.Add(n.opts.MinInterval)); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	n.lastCall = time.Now()
+	return nil
+}
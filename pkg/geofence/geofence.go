@@ -0,0 +1,106 @@
+package geofence
+
+import (
+	"smartDriver/pkg/geo"
+	"sync"
+)
+
+// Kind tells what a fence surrounds
+type Kind string
+
+const (
+	KindBranch Kind = "branch"
+	KindStop   Kind = "stop"
+)
+
+// Event tells whether the driver entered or left a fence
+type Event string
+
+const (
+	EventEnter Event = "enter"
+	EventExit  Event = "exit"
+)
+
+// exitFactor widens a fence for leaving it, so positions jittering around the
+// border do not produce a stream of events
+const exitFactor = 1.5
+
+// MaxAccuracy is the worst accuracy in meters of a position used for fences.
+// Coarse positions could fire arrivals far from the address.
+const MaxAccuracy = 150.0
+
+// Fence is a circle around a branch or the location of an order of a ride
+type Fence struct {
+	Kind    Kind
+	OrderID int64 // zero for the branch
+	Center  geo.Point
+	Radius  float64 // meters
+}
+
+// Transition is a fence the driver entered or left
+type Transition struct {
+	Fence Fence
+	Event Event
+}
+
+type fenceKey struct {
+	kind    Kind
+	orderID int64
+}
+
+// Tracker remembers which fences each driver is inside
+type Tracker struct {
+	mu     sync.Mutex
+	inside map[int64]map[fenceKey]bool
+}
+
+// Default is the tracker of the server process
+var Default = NewTracker()
+
+// NewTracker creates a tracker that knows no driver
+func NewTracker() *Tracker {
+	return &Tracker{inside: make(map[int64]map[fenceKey]bool)}
+}
+
+// Update moves the driver to the point and returns the fences entered or
+// left. A fence seen for the first time reports an entry when the driver is
+// already inside and nothing otherwise. Fences missing from fences are
+// forgotten, e.g. finished stops.
+func (t *Tracker) Update(driverID int64, fences []Fence, pt geo.Point, accuracy *float64) []Transition {
+	if accuracy != nil && *accuracy > MaxAccuracy {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous := t.inside[driverID]
+	current := make(map[fenceKey]bool, len(fences))
+
+	var transitions []Transition
+	for _, fence := range fences {
+		if fence.Radius <= 0 || !fence.Center.Valid() {
+			continue
+		}
+
+		key := fenceKey{kind: fence.Kind, orderID: fence.OrderID}
+		distance := geo.Distance(pt, fence.Center)
+		wasInside := previous[key]
+
+		if wasInside {
+			current[key] = distance <= fence.Radius*exitFactor
+			if !current[key] {
+				transitions = append(transitions, Transition{Fence: fence, Event: EventExit})
+			}
+			continue
+		}
+
+		current[key] = distance <= fence.Radius
+		if current[key] {
+			transitions = append(transitions, Transition{Fence: fence, Event: EventEnter})
+		}
+	}
+
+	t.inside[driverID] = current
+	return transitions
+}
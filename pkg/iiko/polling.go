@@ -9,9 +9,16 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"math/big"
 	"net/http"
 	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/customers"
+	"smartDriver/pkg/geo"
+	"smartDriver/pkg/geocode"
+	"smartDriver/pkg/log"
+	"smartDriver/pkg/orderstatus"
+	"smartDriver/pkg/payment"
+	"smartDriver/pkg/rides"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,6 +27,14 @@ import (
 
 const (
 	DeliveryByCourier = "DeliveryByCourier"
+
+	// EventSource marks order events reported by iiko
+	EventSource = "iiko"
+
+	// OrderSource marks orders imported from iiko
+	OrderSource = "iiko"
+
+	timeLayout = "2006-01-02 15:04:05.000"
 )
 
 var (
@@ -37,6 +52,7 @@ type OrderPollingService struct {
 	db         *pgxpool.Pool
 	queries    *db.Queries // sqlc generated queries
 	iikoClient *IikoClient
+	publisher  *centrifugo.Client
 	//centrifuge   *gocent.Client
 	pollInterval    time.Duration
 	tokenCache      sync.Map // Cache for organization tokens
@@ -88,6 +104,46 @@ type Address struct {
 	Doorphone string `json:"doorphone"`
 }
 
+type Product struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type OrderItem struct {
+	Type             string  `json:"type"`
+	Product          Product `json:"product"`
+	PrimaryComponent *struct {
+		Product Product `json:"product"`
+	} `json:"primaryComponent"`
+	Amount  float64 `json:"amount"`
+	Price   float64 `json:"price"`
+	Cost    float64 `json:"cost"`
+	Comment string  `json:"comment"`
+}
+
+type Payment struct {
+	PaymentType struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Kind string `json:"kind"`
+	} `json:"paymentType"`
+	Sum                   float64 `json:"sum"`
+	IsPreliminary         bool    `json:"isPreliminary"`
+	IsProcessedExternally bool    `json:"isProcessedExternally"`
+}
+
+type CourierInfo struct {
+	Courier *struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Phone string `json:"phone"`
+	} `json:"courier"`
+}
+
+type GuestsInfo struct {
+	Count int32 `json:"count"`
+}
+
 type Order struct {
 	ID             string `json:"id"`
 	OrganizationID string `json:"organizationId"`
@@ -110,9 +166,93 @@ type Order struct {
 		OrderType struct {
 			OrderServiceType string `json:"orderServiceType"`
 		} `json:"orderType"`
+
+		// Status transition timestamps
+		WhenConfirmed        string `json:"whenConfirmed"`
+		WhenCookingCompleted string `json:"whenCookingCompleted"`
+		WhenSended           string `json:"whenSended"`
+		WhenDelivered        string `json:"whenDelivered"`
+		WhenClosed           string `json:"whenClosed"`
+		CancelInfo           *struct {
+			WhenCancelled string `json:"whenCancelled"`
+		} `json:"cancelInfo"`
+
+		Items       []OrderItem  `json:"items"`
+		Payments    []Payment    `json:"payments"`
+		CourierInfo *CourierInfo `json:"courierInfo"`
+		GuestsInfo  *GuestsInfo  `json:"guestsInfo"`
 	} `json:"order"`
 }
 
+// statusTime returns when the order entered its current status according to
+// iiko, falling back to the current time when iiko does not report it. iiko
+// reports times in the organization's timezone.
+func (o Order) statusTime(timezone *time.Location) time.Time {
+	var when string
+	switch o.Info.Status {
+	case "Unconfirmed":
+		when = o.Info.CreatedAt
+	case "WaitCooking", "ReadyForCooking":
+		when = o.Info.WhenConfirmed
+	case "CookingCompleted", "Waiting":
+		when = o.Info.WhenCookingCompleted
+	case "OnWay":
+		when = o.Info.WhenSended
+	case "Delivered":
+		when = o.Info.WhenDelivered
+	case "Closed":
+		when = o.Info.WhenClosed
+	case "Cancelled":
+		if o.Info.CancelInfo != nil {
+			when = o.Info.CancelInfo.WhenCancelled
+		}
+	}
+
+	if t, err := time.ParseInLocation(timeLayout, when, timezone); err == nil {
+		return t.UTC()
+	}
+	return time.Now().UTC()
+}
+
+// paymentParts converts iiko payments to payment kinds. Preliminary and
+// externally processed payments are already paid and count as prepaid.
+func (o Order) paymentParts() []payment.Part {
+	parts := make([]payment.Part, 0, len(o.Info.Payments))
+	for _, p := range o.Info.Payments {
+		kind := payment.KindCard
+		switch {
+		case p.IsPreliminary || p.IsProcessedExternally:
+			kind = payment.KindPrepaid
+		case p.PaymentType.Kind == "Cash":
+			kind = payment.KindCash
+		}
+		parts = append(parts, payment.Part{Kind: kind, Sum: p.Sum})
+	}
+	return parts
+}
+
+// location returns the delivery coordinates reported by iiko
+func (o Order) location() geo.Point {
+	return geo.Point{
+		Lat: o.Info.DeliveryPoint.Coordinates.Latitude,
+		Lng: o.Info.DeliveryPoint.Coordinates.Longitude,
+	}
+}
+
+// sameAddress reports whether iiko reports the address an order was stored
+// with
+func (o Order) sameAddress(stored db.Order) bool {
+	return derefString(stored.City) == o.Info.DeliveryPoint.Address.Street.City.Name &&
+		derefString(stored.Street) == o.Info.DeliveryPoint.Address.Street.Name &&
+		derefString(stored.Building) == o.Info.DeliveryPoint.Building
+}
+
+// orderLocation is where an order is delivered and how the location was found
+type orderLocation struct {
+	Point  geo.Point
+	Source string
+}
+
 // NewOrderPollingService creates a new polling service instance
 func NewOrderPollingService(
 	db *pgxpool.Pool,
@@ -125,6 +265,7 @@ func NewOrderPollingService(
 		db:         db,
 		queries:    queries,
 		iikoClient: NewIikoClient("https://api-ru.iiko.services"),
+		publisher:  centrifugo.NewClient(centrifugeURL, centrifugeAPIKey),
 		//centrifuge:   gocent.New(centrifugeURL, gocent.WithAPIKey(centrifugeAPIKey)),
 		pollInterval:    pollInterval,
 		organizationIds: make(map[int64][]string),
@@ -236,7 +377,7 @@ GettingOrders:
 	fmt.Printf("Received orders len=%d by last rev value: %d\n", len(orders), maxRevision)
 
 	// Process orders and update database
-	if err := s.processOrders(ctx, orders); err != nil {
+	if err := s.processOrders(ctx, org, orders, maxRevision); err != nil {
 		return fmt.Errorf("failed to process orders: %w", err)
 	}
 	fmt.Println("Processed orders:", orders)
@@ -277,7 +418,13 @@ func (s *OrderPollingService) getAuthToken(ctx context.Context, org db.Organizat
 }
 
 // processOrders updates the database with new order information
-func (s *OrderPollingService) processOrders(ctx context.Context, orders []Order) error {
+func (s *OrderPollingService) processOrders(ctx context.Context, org db.Organization, orders []Order, revision int64) error {
+	// Geocoding is throttled, it must not hold the transaction open
+	locations, err := s.locateOrders(ctx, org, orders)
+	if err != nil {
+		return err
+	}
+
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return err
@@ -286,6 +433,20 @@ func (s *OrderPollingService) processOrders(ctx context.Context, orders []Order)
 
 	qtx := s.queries.WithTx(tx)
 
+	zones, err := geo.LoadZoneIndex(ctx, qtx, org.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load delivery zones: %w", err)
+	}
+
+	// Detachments are published once the transaction is committed
+	var detachments []rides.Detachment
+
+	// iiko reports times in the organization's local time
+	timezone, err := time.LoadLocation(org.Timezone)
+	if err != nil {
+		timezone = time.UTC
+	}
+
 	for _, order := range orders {
 		// Skip the order if it's not a delivery
 		if order.Info.OrderType.OrderServiceType != DeliveryByCourier {
@@ -293,17 +454,15 @@ func (s *OrderPollingService) processOrders(ctx context.Context, orders []Order)
 		}
 
 		// Try to get existing order first
-		existingOrder, err := qtx.GetOrderByExternalID(ctx, order.ID)
+		existingOrder, err := qtx.GetOrderByExternalID(ctx, db.GetOrderByExternalIDParams{
+			OrganizationID: org.ID,
+			ExternalID:     order.ID,
+		})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to check existing order: %w", err)
 		}
 
-		cost := pgtype.Numeric{
-			Int:   big.NewInt(int64(order.Info.Sum)),
-			Exp:   0,
-			NaN:   false,
-			Valid: true,
-		}
+		cost := db.NumericFromFloat(order.Info.Sum)
 
 		floor64, _ := strconv.ParseInt(order.Info.DeliveryPoint.Floor, 10, 32)
 		floor := int32(floor64)
@@ -311,25 +470,116 @@ func (s *OrderPollingService) processOrders(ctx context.Context, orders []Order)
 		entrance64, _ := strconv.ParseInt(order.Info.DeliveryPoint.Entrance, 10, 32)
 		entrance := int32(entrance64)
 
-		createdAt, _ := time.Parse("2006-01-02 15:04:05.000", order.Info.CreatedAt)
+		createdAt, parseErr := time.ParseInLocation(timeLayout, order.Info.CreatedAt, timezone)
+		if parseErr != nil {
+			log.SugaredLogger.Warnw("failed to parse iiko order creation time",
+				"organization_id", org.ID, "external_id", order.ID, "created_at", order.Info.CreatedAt, "error", parseErr)
+			createdAt = time.Now()
+		}
+		createdAt = createdAt.UTC()
+
+		var promisedAt pgtype.Timestamp
+		if completeBefore, err := time.ParseInLocation(timeLayout, order.Info.CompleteBefore, timezone); err == nil {
+			promisedAt = pgtype.Timestamp{Time: completeBefore.UTC(), Valid: true}
+		}
+
+		location, locationSource := order.location(), geocode.LocationProvided
+		if located, ok := locations[order.ID]; ok {
+			location, locationSource = located.Point, located.Source
+		}
+
+		// Route the order to the branch whose delivery zone contains it. Known
+		// orders keep their branch unless they moved and are not on a ride.
+		branchID, outOfZone := existingOrder.BranchID, existingOrder.OutOfZone
+		reroute := err != nil
+		if err == nil && geo.PointFromPg(existingOrder.Location) != location {
+			_, rideErr := qtx.GetOrderActiveRideID(ctx, existingOrder.ID)
+			if rideErr != nil && !errors.Is(rideErr, pgx.ErrNoRows) {
+				return fmt.Errorf("failed to check active ride of order: %w", rideErr)
+			}
+			reroute = rideErr != nil
+		}
+		if reroute {
+			zone, inZone := zones.Locate(location)
+			branchID, outOfZone = nil, !inZone
+			if inZone {
+				branchID = &zone.BranchID
+			}
+		}
+
+		// Payment breakdown, courier and guests
+		payments := order.paymentParts()
+		cashToCollect := db.NumericFromFloat(payment.CashToCollect(order.Info.Sum, payments))
+
+		var guestCount *int32
+		if order.Info.GuestsInfo != nil {
+			guestCount = &order.Info.GuestsInfo.Count
+		}
+
+		var courierName, courierPhone *string
+		if order.Info.CourierInfo != nil && order.Info.CourierInfo.Courier != nil {
+			courierName = &order.Info.CourierInfo.Courier.Name
+			courierPhone = &order.Info.CourierInfo.Courier.Phone
+		}
+
+		// Link the order to the customer profile when it is new or its phone
+		// changed, status updates must not touch the customer
+		customerID := existingOrder.CustomerID
+		if err != nil || derefString(existingOrder.Phone) != order.Info.Phone {
+			var linkErr error
+			customerID, linkErr = customers.Link(ctx, qtx, org.ID, order.Info.Customer.Name, order.Info.Phone)
+			if linkErr != nil {
+				return fmt.Errorf("failed to link customer: %w", linkErr)
+			}
+		}
+
+		// Map the iiko statuses to the internal status
+		status, known := orderstatus.Default.Map(order.Info.Status, order.Info.DeliveryStatus)
+		if !known {
+			status = orderstatus.New
+		}
 
 		// If order exists, check if status has changed
 		if err == nil {
-			if *existingOrder.Status != order.Info.Status {
+			oldStatus := orderstatus.Status(existingOrder.Status)
+
+			switch {
+			case !known || status == oldStatus:
+				// Nothing changed for us
+			case !orderstatus.CanTransition(oldStatus, status):
+				// e.g. iiko reports the kitchen progress of an order that is
+				// already assigned to a ride
+				log.SugaredLogger.Warnw("skipping invalid order status transition",
+					"order_id", existingOrder.ID, "from", oldStatus, "to", status, "iiko_status", order.Info.Status)
+			default:
 				// Update order status
 				if err := qtx.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
 					ID:     existingOrder.ID,
-					Status: &order.Info.Status,
+					Status: string(status),
 				}); err != nil {
 					return fmt.Errorf("failed to update order status: %w", err)
 				}
 
+				// Record the transition in the order history
+				if err := s.recordStatusEvent(ctx, qtx, existingOrder.ID, &existingOrder.Status, status, order, timezone, revision); err != nil {
+					return fmt.Errorf("failed to record status event: %w", err)
+				}
+
+				// Finished orders must disappear from the rides drivers are on
+				if status.IsTerminal() {
+					detached, err := rides.DetachFinishedOrder(ctx, qtx, existingOrder, string(status), EventSource)
+					if err != nil {
+						return fmt.Errorf("failed to detach finished order: %w", err)
+					}
+					detachments = append(detachments, detached...)
+				}
+
 				// Publish status change to Centrifugo
 				statusUpdate := OrderStatusUpdate{
 					OrderID:    existingOrder.ID,
 					ExternalID: order.ID,
-					OldStatus:  *existingOrder.Status,
-					NewStatus:  order.Info.Status,
+					OldStatus:  existingOrder.Status,
+					NewStatus:  string(status),
 					UpdatedAt:  time.Now(),
 				}
 
@@ -340,45 +590,74 @@ func (s *OrderPollingService) processOrders(ctx context.Context, orders []Order)
 
 			// Update other order details if needed
 			if err := qtx.UpdateOrder(ctx, db.UpdateOrderParams{
-				ID:           existingOrder.ID,
-				CustomerName: strings.TrimSpace(order.Info.Customer.Name),
-				Phone:        &order.Info.Phone,
-				City:         &order.Info.DeliveryPoint.Address.Street.City.Name,
-				Street:       &order.Info.DeliveryPoint.Address.Street.Name,
-				Apartment:    &order.Info.DeliveryPoint.Address.Flat,
-				Doorphone:    &order.Info.DeliveryPoint.Address.Doorphone,
-				Building:     &order.Info.DeliveryPoint.Building,
-				Floor:        &floor,
-				Entrance:     &entrance,
-				Comment:      &order.Info.Comment,
-				Cost:         cost,
-				Point:        order.Info.DeliveryPoint.Coordinates.Longitude,
-				Point_2:      order.Info.DeliveryPoint.Coordinates.Latitude,
+				ID:                 existingOrder.ID,
+				CustomerName:       strings.TrimSpace(order.Info.Customer.Name),
+				Phone:              &order.Info.Phone,
+				City:               &order.Info.DeliveryPoint.Address.Street.City.Name,
+				Street:             &order.Info.DeliveryPoint.Address.Street.Name,
+				Apartment:          &order.Info.DeliveryPoint.Address.Flat,
+				Doorphone:          &order.Info.DeliveryPoint.Address.Doorphone,
+				Building:           &order.Info.DeliveryPoint.Building,
+				Floor:              &floor,
+				Entrance:           &entrance,
+				Comment:            &order.Info.Comment,
+				Cost:               cost,
+				Point:              location.Lng,
+				Point_2:            location.Lat,
+				BranchID:           branchID,
+				OutOfZone:          outOfZone,
+				GuestCount:         guestCount,
+				CourierName:        courierName,
+				CourierPhone:       courierPhone,
+				CashToCollect:      cashToCollect,
+				PromisedAt:         promisedAt,
+				IikoStatus:         &order.Info.Status,
+				IikoDeliveryStatus: &order.Info.DeliveryStatus,
+				LocationSource:     locationSource,
+				CustomerID:         customerID,
 			}); err != nil {
 				return fmt.Errorf("failed to update order: %w", err)
 			}
 
+			if err := s.saveOrderContents(ctx, qtx, existingOrder.ID, order, payments); err != nil {
+				return fmt.Errorf("failed to save order contents: %w", err)
+			}
+
 			continue
 		}
 
 		// Create new order if it doesn't exist
 		params := db.CreateOrderParams{
-			ExternalID:   order.ID,
-			CustomerName: strings.TrimSpace(order.Info.Customer.Name),
-			Phone:        &order.Info.Phone,
-			City:         &order.Info.DeliveryPoint.Address.Street.City.Name,
-			Street:       &order.Info.DeliveryPoint.Address.Street.Name,
-			Apartment:    &order.Info.DeliveryPoint.Address.Flat,
-			Doorphone:    &order.Info.DeliveryPoint.Address.Doorphone,
-			Building:     &order.Info.DeliveryPoint.Building,
-			Floor:        &floor,
-			Entrance:     &entrance,
-			Comment:      &order.Info.Comment,
-			Cost:         cost,
-			Status:       &order.Info.Status,
-			Point:        order.Info.DeliveryPoint.Coordinates.Longitude,
-			Point_2:      order.Info.DeliveryPoint.Coordinates.Latitude,
-			CreatedAt:    pgtype.Timestamp{Time: createdAt, Valid: true},
+			ExternalID:         order.ID,
+			CustomerName:       strings.TrimSpace(order.Info.Customer.Name),
+			Phone:              &order.Info.Phone,
+			City:               &order.Info.DeliveryPoint.Address.Street.City.Name,
+			Street:             &order.Info.DeliveryPoint.Address.Street.Name,
+			Apartment:          &order.Info.DeliveryPoint.Address.Flat,
+			Doorphone:          &order.Info.DeliveryPoint.Address.Doorphone,
+			Building:           &order.Info.DeliveryPoint.Building,
+			Floor:              &floor,
+			Entrance:           &entrance,
+			Comment:            &order.Info.Comment,
+			Cost:               cost,
+			Status:             string(status),
+			Point:              location.Lng,
+			Point_2:            location.Lat,
+			CreatedAt:          pgtype.Timestamp{Time: createdAt, Valid: true},
+			BranchID:           branchID,
+			OutOfZone:          outOfZone,
+			OrganizationID:     org.ID,
+			IikoOrganizationID: &order.OrganizationID,
+			GuestCount:         guestCount,
+			CourierName:        courierName,
+			CourierPhone:       courierPhone,
+			CashToCollect:      cashToCollect,
+			PromisedAt:         promisedAt,
+			Source:             OrderSource,
+			IikoStatus:         &order.Info.Status,
+			IikoDeliveryStatus: &order.Info.DeliveryStatus,
+			LocationSource:     locationSource,
+			CustomerID:         customerID,
 		}
 
 		newOrder, err := qtx.CreateOrder(ctx, params)
@@ -386,6 +665,30 @@ func (s *OrderPollingService) processOrders(ctx context.Context, orders []Order)
 			return fmt.Errorf("failed to create order: %w", err)
 		}
 
+		if customerID != nil {
+			if err := customers.RememberAddress(ctx, qtx, *customerID, customers.Address{
+				City:      order.Info.DeliveryPoint.Address.Street.City.Name,
+				Street:    order.Info.DeliveryPoint.Address.Street.Name,
+				Building:  order.Info.DeliveryPoint.Building,
+				Apartment: order.Info.DeliveryPoint.Address.Flat,
+				Entrance:  &entrance,
+				Floor:     &floor,
+				Doorphone: order.Info.DeliveryPoint.Address.Doorphone,
+				Location:  location,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// Start the order history with its initial status
+		if err := s.recordStatusEvent(ctx, qtx, newOrder.ID, nil, status, order, timezone, revision); err != nil {
+			return fmt.Errorf("failed to record status event: %w", err)
+		}
+
+		if err := s.saveOrderContents(ctx, qtx, newOrder.ID, order, payments); err != nil {
+			return fmt.Errorf("failed to save order contents: %w", err)
+		}
+
 		// Publish new order creation to Centrifugo
 		if err := s.publishNewOrder(newOrder); err != nil {
 			return fmt.Errorf("failed to publish new order: %w", err)
@@ -394,7 +697,139 @@ func (s *OrderPollingService) processOrders(ctx context.Context, orders []Order)
 		fmt.Println("Created new order:", order)
 	}
 
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	if err := rides.PublishDetachments(ctx, s.publisher, org.ID, detachments); err != nil {
+		log.SugaredLogger.Warnw("failed to publish ride detachments",
+			"organization_id", org.ID, "detachments", len(detachments), "error", err)
+	}
+
+	return nil
+}
+
+// locateOrders finds the delivery location of orders iiko reports without
+// usable coordinates. Known orders keep their stored location unless their
+// address changed, so each address is geocoded once.
+func (s *OrderPollingService) locateOrders(ctx context.Context, org db.Organization, orders []Order) (map[string]orderLocation, error) {
+	locations := make(map[string]orderLocation)
+	for _, order := range orders {
+		if order.Info.OrderType.OrderServiceType != DeliveryByCourier || order.location().Valid() {
+			continue
+		}
+
+		existingOrder, err := s.queries.GetOrderByExternalID(ctx, db.GetOrderByExternalIDParams{
+			OrganizationID: org.ID,
+			ExternalID:     order.ID,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to check existing order: %w", err)
+		}
+		if err == nil && order.sameAddress(existingOrder) {
+			locations[order.ID] = orderLocation{
+				Point:  geo.PointFromPg(existingOrder.Location),
+				Source: existingOrder.LocationSource,
+			}
+			continue
+		}
+
+		point, source := s.geocodeOrder(ctx, order)
+		locations[order.ID] = orderLocation{Point: point, Source: source}
+	}
+	return locations, nil
+}
+
+// geocodeOrder resolves the delivery address of an order that came without
+// usable coordinates. The order keeps the (0, 0) placeholder when the
+// address cannot be resolved.
+func (s *OrderPollingService) geocodeOrder(ctx context.Context, order Order) (geo.Point, string) {
+	address := geocode.FormatAddress(
+		order.Info.DeliveryPoint.Address.Street.City.Name,
+		order.Info.DeliveryPoint.Address.Street.Name,
+		order.Info.DeliveryPoint.Building,
+	)
+	if address == "" || geocode.Default == nil {
+		return geo.Point{}, geocode.LocationMissing
+	}
+
+	location, err := geocode.Default.Geocode(ctx, address)
+	if err != nil {
+		log.SugaredLogger.Warnw("failed to geocode order address",
+			"external_id", order.ID, "address", address, "error", err)
+		return geo.Point{}, geocode.LocationMissing
+	}
+
+	return location, geocode.LocationGeocoded
+}
+
+// recordStatusEvent stores an order status transition. The transition time is
+// taken from iiko when it reports one for the new status.
+func (s *OrderPollingService) recordStatusEvent(ctx context.Context, q *db.Queries, orderID int64, oldStatus *string, newStatus orderstatus.Status, order Order, timezone *time.Location, revision int64) error {
+	_, err := q.CreateOrderEvent(ctx, db.CreateOrderEventParams{
+		OrderID:      orderID,
+		OldStatus:    oldStatus,
+		NewStatus:    string(newStatus),
+		Source:       EventSource,
+		IikoRevision: &revision,
+		OccurredAt:   pgtype.Timestamp{Time: order.statusTime(timezone), Valid: true},
+	})
+	return err
+}
+
+// saveOrderContents replaces the stored items and payments of an order with
+// the ones reported by iiko
+func (s *OrderPollingService) saveOrderContents(ctx context.Context, q *db.Queries, orderID int64, order Order, payments []payment.Part) error {
+	if err := q.DeleteOrderItems(ctx, orderID); err != nil {
+		return err
+	}
+
+	for i, item := range order.Info.Items {
+		product := item.Product
+		if product.Name == "" && item.PrimaryComponent != nil {
+			product = item.PrimaryComponent.Product
+		}
+
+		var productID *string
+		if product.ID != "" {
+			productID = &product.ID
+		}
+
+		var comment *string
+		if item.Comment != "" {
+			comment = &item.Comment
+		}
+
+		if err := q.CreateOrderItem(ctx, db.CreateOrderItemParams{
+			OrderID:   orderID,
+			Position:  int32(i),
+			ProductID: productID,
+			Name:      product.Name,
+			Amount:    db.NumericFromFloat(item.Amount),
+			Price:     db.NumericFromFloat(item.Price),
+			Cost:      db.NumericFromFloat(item.Cost),
+			Comment:   comment,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := q.DeleteOrderPayments(ctx, orderID); err != nil {
+		return err
+	}
+
+	for i, part := range payments {
+		if err := q.CreateOrderPayment(ctx, db.CreateOrderPaymentParams{
+			OrderID:         orderID,
+			Kind:            part.Kind,
+			PaymentTypeName: &order.Info.Payments[i].PaymentType.Name,
+			Sum:             db.NumericFromFloat(part.Sum),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 type OrderStatusUpdate struct {
@@ -603,7 +1038,7 @@ func (c *IikoClient) doRequest(ctx context.Context, method, path string, reqBody
 		if errorResponse.ErrorType == "TOO_OLD_REVISION" {
 			return OldRevision
 		}
-		return fmt.Errorf("failed to do request: %w", errorResponse.Error())
+		return fmt.Errorf("failed to do request: %w", &errorResponse)
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
@@ -612,3 +1047,11 @@ func (c *IikoClient) doRequest(ctx context.Context, method, path string, reqBody
 
 	return nil
 }
+
+// derefString returns the value of an optional string, empty when missing
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
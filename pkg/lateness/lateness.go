@@ -0,0 +1,133 @@
+package lateness
+
+import (
+	"context"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/log"
+	"time"
+)
+
+// Lateness levels of an order relative to its promised delivery time
+const (
+	OnTime = "on_time"
+	AtRisk = "at_risk"
+	Late   = "late"
+
+	// Event types published to dispatchers
+	EventOrderAtRisk = "order_at_risk"
+	EventOrderLate   = "order_late"
+)
+
+var rank = map[string]int{
+	OnTime: 0,
+	AtRisk: 1,
+	Late:   2,
+}
+
+// Classify returns the lateness level of an order promised at promisedAt.
+// An order is at risk once less than atRiskThreshold remains.
+func Classify(now, promisedAt time.Time, atRiskThreshold time.Duration) string {
+	remaining := promisedAt.Sub(now)
+	switch {
+	case remaining <= 0:
+		return Late
+	case remaining <= atRiskThreshold:
+		return AtRisk
+	default:
+		return OnTime
+	}
+}
+
+// Event is published to dispatchers when an order becomes at risk or late
+type Event struct {
+	Type             string    `json:"type"`
+	OrderID          int64     `json:"order_id"`
+	ExternalID       string    `json:"external_id"`
+	BranchID         *int64    `json:"branch_id"`
+	PromisedAt       time.Time `json:"promised_at"`
+	SecondsRemaining int64     `json:"seconds_remaining"`
+}
+
+// Monitor periodically checks open orders against their promised time and
+// alerts dispatchers when an order crosses a lateness threshold. Each
+// threshold is reported once per promise.
+type Monitor struct {
+	queries         *db.Queries
+	publisher       *centrifugo.Client
+	interval        time.Duration
+	atRiskThreshold time.Duration
+}
+
+// NewMonitor creates a new lateness monitor
+func NewMonitor(queries *db.Queries, publisher *centrifugo.Client, interval, atRiskThreshold time.Duration) *Monitor {
+	return &Monitor{
+		queries:         queries,
+		publisher:       publisher,
+		interval:        interval,
+		atRiskThreshold: atRiskThreshold,
+	}
+}
+
+// Start runs the checks in the background until the context is cancelled
+func (m *Monitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := m.check(ctx); err != nil {
+				log.SugaredLogger.Errorw("failed to check order lateness", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (m *Monitor) check(ctx context.Context) error {
+	orders, err := m.queries.ListOpenPromisedOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list open orders: %w", err)
+	}
+
+	now := time.Now()
+	for _, order := range orders {
+		level := Classify(now, order.PromisedAt.Time, m.atRiskThreshold)
+		if rank[level] <= rank[order.Lateness] {
+			continue
+		}
+
+		if err := m.queries.UpdateOrderLateness(ctx, db.UpdateOrderLatenessParams{
+			ID:       order.ID,
+			Lateness: level,
+		}); err != nil {
+			return fmt.Errorf("failed to update order lateness: %w", err)
+		}
+
+		event := Event{
+			Type:             EventOrderAtRisk,
+			OrderID:          order.ID,
+			ExternalID:       order.ExternalID,
+			BranchID:         order.BranchID,
+			PromisedAt:       order.PromisedAt.Time,
+			SecondsRemaining: int64(order.PromisedAt.Time.Sub(now).Seconds()),
+		}
+		if level == Late {
+			event.Type = EventOrderLate
+		}
+
+		channel := centrifugo.OrganizationChannel(order.OrganizationID)
+		if err := m.publisher.Publish(ctx, channel, event); err != nil {
+			log.SugaredLogger.Warnw("failed to publish order lateness",
+				"event", event.Type, "order_id", order.ID, "organization_id", order.OrganizationID, "error", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package orderstatus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"smartDriver/internal/config"
+)
+
+// Mapping converts iiko order statuses to internal statuses. A delivery
+// status mapping takes precedence over the order status one.
+type Mapping struct {
+	Statuses         map[string]Status `json:"statuses"`
+	DeliveryStatuses map[string]Status `json:"delivery_statuses"`
+}
+
+// Default is the mapping configured by Init
+var Default = DefaultMapping()
+
+// DefaultMapping returns the mapping of standard iiko delivery statuses
+func DefaultMapping() Mapping {
+	return Mapping{
+		Statuses: map[string]Status{
+			"Unconfirmed":      New,
+			"WaitCooking":      New,
+			"ReadyForCooking":  New,
+			"CookingStarted":   Cooking,
+			"CookingCompleted": Ready,
+			"Waiting":          Ready,
+			"OnWay":            OnWay,
+			"Delivered":        Delivered,
+			"Closed":           Delivered,
+			"Cancelled":        Cancelled,
+		},
+		DeliveryStatuses: map[string]Status{},
+	}
+}
+
+// Init loads the mapping file from the configuration into Default. Values
+// from the file override the default mapping.
+func Init(cfg *config.Config) error {
+	if cfg.OrderStatus.MappingFile == "" {
+		return nil
+	}
+
+	mapping, err := LoadMapping(cfg.OrderStatus.MappingFile)
+	if err != nil {
+		return err
+	}
+
+	Default = mapping
+	return nil
+}
+
+// LoadMapping reads a JSON mapping file on top of the default mapping
+func LoadMapping(path string) (Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Mapping{}, fmt.Errorf("failed to read status mapping: %w", err)
+	}
+
+	var custom Mapping
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return Mapping{}, fmt.Errorf("failed to parse status mapping: %w", err)
+	}
+
+	mapping := DefaultMapping()
+	for iikoStatus, status := range custom.Statuses {
+		if !status.Valid() {
+			return Mapping{}, fmt.Errorf("unknown status %q for iiko status %q", status, iikoStatus)
+		}
+		mapping.Statuses[iikoStatus] = status
+	}
+	for deliveryStatus, status := range custom.DeliveryStatuses {
+		if !status.Valid() {
+			return Mapping{}, fmt.Errorf("unknown status %q for iiko delivery status %q", status, deliveryStatus)
+		}
+		mapping.DeliveryStatuses[deliveryStatus] = status
+	}
+
+	return mapping, nil
+}
+
+// Map returns the internal status of an iiko order. ok is false when
+// neither of the iiko statuses is mapped.
+func (m Mapping) Map(iikoStatus, deliveryStatus string) (Status, bool) {
+	if status, ok := m.DeliveryStatuses[deliveryStatus]; ok && deliveryStatus != "" {
+		return status, true
+	}
+	status, ok := m.Statuses[iikoStatus]
+	return status, ok
+}
@@ -0,0 +1,100 @@
+package orderstatus
+
+// Status is the internal order status. iiko statuses are mapped to it so
+// orders from every source share one lifecycle.
+type Status string
+
+const (
+	New       Status = "new"
+	Cooking   Status = "cooking"
+	Ready     Status = "ready"
+	Assigned  Status = "assigned"
+	OnWay     Status = "on_way"
+	Delivered Status = "delivered"
+	Cancelled Status = "cancelled"
+	Failed    Status = "failed"
+)
+
+// All lists the statuses in lifecycle order
+var All = []Status{New, Cooking, Ready, Assigned, OnWay, Delivered, Cancelled, Failed}
+
+// transitions lists the statuses an order may move to from each status.
+// Steps may be skipped because kitchens do not always report every step.
+var transitions = map[Status][]Status{
+	New:      {Cooking, Ready, Assigned, OnWay, Delivered, Cancelled},
+	Cooking:  {Ready, Assigned, OnWay, Delivered, Cancelled},
+	Ready:    {Assigned, OnWay, Delivered, Cancelled},
+	Assigned: {OnWay, Delivered, Cancelled, Failed},
+	OnWay:    {Delivered, Cancelled, Failed},
+}
+
+var labels = map[string]map[Status]string{
+	"ru": {
+		New:       "Новый",
+		Cooking:   "Готовится",
+		Ready:     "Готов",
+		Assigned:  "Назначен водителю",
+		OnWay:     "В пути",
+		Delivered: "Доставлен",
+		Cancelled: "Отменён",
+		Failed:    "Не доставлен",
+	},
+	"en": {
+		New:       "New",
+		Cooking:   "Cooking",
+		Ready:     "Ready",
+		Assigned:  "Assigned to driver",
+		OnWay:     "On the way",
+		Delivered: "Delivered",
+		Cancelled: "Cancelled",
+		Failed:    "Delivery failed",
+	},
+}
+
+// DefaultLanguage is used for labels in unsupported languages
+const DefaultLanguage = "ru"
+
+// Valid reports whether s is a known status
+func (s Status) Valid() bool {
+	_, ok := labels[DefaultLanguage][s]
+	return ok
+}
+
+// IsTerminal reports whether an order with the status no longer needs
+// delivering
+func (s Status) IsTerminal() bool {
+	return s == Delivered || s == Cancelled || s == Failed
+}
+
+// Next returns the statuses an order may move to from s
+func (s Status) Next() []Status {
+	return transitions[s]
+}
+
+// CanTransition reports whether an order may move from one status to another
+func CanTransition(from, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Label returns the human readable name of the status in the language,
+// falling back to DefaultLanguage
+func (s Status) Label(lang string) string {
+	l, ok := labels[lang]
+	if !ok {
+		l = labels[DefaultLanguage]
+	}
+	if label, ok := l[s]; ok {
+		return label
+	}
+	return string(s)
+}
+
+// Languages lists the languages labels are available in
+func Languages() []string {
+	return []string{"ru", "en"}
+}
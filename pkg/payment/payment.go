@@ -0,0 +1,33 @@
+package payment
+
+// Payment kinds stored for orders
+const (
+	// KindCash is paid in cash to the courier on delivery
+	KindCash = "cash"
+	// KindCard is paid by card to the courier on delivery
+	KindCard = "card"
+	// KindPrepaid was paid before the delivery, online or at the restaurant
+	KindPrepaid = "prepaid"
+)
+
+// Part is a single payment of an order
+type Part struct {
+	Kind string
+	Sum  float64
+}
+
+// CashToCollect returns how much cash the courier has to collect for an
+// order. An order without payments is expected to be paid in cash in full.
+func CashToCollect(cost float64, parts []Part) float64 {
+	if len(parts) == 0 {
+		return cost
+	}
+
+	var cash float64
+	for _, part := range parts {
+		if part.Kind == KindCash {
+			cash += part.Sum
+		}
+	}
+	return cash
+}
@@ -0,0 +1,8 @@
+package payment
+
+import "math"
+
+// Round rounds an amount of money to kopecks
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
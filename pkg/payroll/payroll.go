@@ -0,0 +1,46 @@
+package payroll
+
+import (
+	"smartDriver/pkg/payment"
+	"time"
+)
+
+// Rule is how an organization or a branch pays couriers for a ride
+type Rule struct {
+	PerOrder float64 // per delivered order
+	PerKm    float64 // per kilometer driven
+	PerHour  float64 // per hour on the road
+	// MinimumPerRide tops up rides that earned less
+	MinimumPerRide float64
+}
+
+// Ride is the work done during a returned ride
+type Ride struct {
+	Orders   int
+	Distance float64 // meters
+	Duration time.Duration
+}
+
+// Earnings is the pay of a ride before bonuses and tips
+type Earnings struct {
+	OrderPay     float64
+	DistancePay  float64
+	TimePay      float64
+	GuaranteePay float64
+	Total        float64
+}
+
+// Calculate applies a rule to a ride. Amounts are rounded to kopecks.
+func Calculate(rule Rule, ride Ride) Earnings {
+	earnings := Earnings{
+		OrderPay:    payment.Round(rule.PerOrder * float64(ride.Orders)),
+		DistancePay: payment.Round(rule.PerKm * ride.Distance / 1000),
+		TimePay:     payment.Round(rule.PerHour * ride.Duration.Hours()),
+	}
+	earned := earnings.OrderPay + earnings.DistancePay + earnings.TimePay
+	if earned < rule.MinimumPerRide {
+		earnings.GuaranteePay = payment.Round(rule.MinimumPerRide - earned)
+	}
+	earnings.Total = payment.Round(earned + earnings.GuaranteePay)
+	return earnings
+}
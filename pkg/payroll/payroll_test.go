@@ -0,0 +1,71 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		ride Ride
+		want Earnings
+	}{
+		{
+			name: "zero rule",
+			ride: Ride{Orders: 3, Distance: 5000, Duration: time.Hour},
+			want: Earnings{},
+		},
+		{
+			name: "per order",
+			rule: Rule{PerOrder: 50},
+			ride: Ride{Orders: 3, Distance: 5000, Duration: time.Hour},
+			want: Earnings{OrderPay: 150, Total: 150},
+		},
+		{
+			name: "all rates",
+			rule: Rule{PerOrder: 50, PerKm: 10, PerHour: 200},
+			ride: Ride{Orders: 2, Distance: 12345, Duration: 90 * time.Minute},
+			want: Earnings{OrderPay: 100, DistancePay: 123.45, TimePay: 300, Total: 523.45},
+		},
+		{
+			name: "rounded to kopecks",
+			rule: Rule{PerKm: 10, PerHour: 100},
+			ride: Ride{Distance: 1234.567, Duration: 10 * time.Minute},
+			want: Earnings{DistancePay: 12.35, TimePay: 16.67, Total: 29.02},
+		},
+		{
+			name: "guarantee tops up",
+			rule: Rule{PerOrder: 50, PerKm: 10, PerHour: 200, MinimumPerRide: 300},
+			ride: Ride{Orders: 2, Distance: 3456, Duration: 45 * time.Minute},
+			want: Earnings{OrderPay: 100, DistancePay: 34.56, TimePay: 150, GuaranteePay: 15.44, Total: 300},
+		},
+		{
+			name: "guarantee only",
+			rule: Rule{MinimumPerRide: 300},
+			ride: Ride{Orders: 1},
+			want: Earnings{GuaranteePay: 300, Total: 300},
+		},
+		{
+			name: "earned exactly the guarantee",
+			rule: Rule{PerOrder: 150, MinimumPerRide: 300},
+			ride: Ride{Orders: 2},
+			want: Earnings{OrderPay: 300, Total: 300},
+		},
+		{
+			name: "earned above the guarantee",
+			rule: Rule{PerOrder: 200, MinimumPerRide: 300},
+			ride: Ride{Orders: 2},
+			want: Earnings{OrderPay: 400, Total: 400},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Calculate(tt.rule, tt.ride); got != tt.want {
+				t.Errorf("Calculate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
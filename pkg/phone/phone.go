@@ -0,0 +1,32 @@
+package phone
+
+import "strings"
+
+// Normalize converts a phone number to E.164-like form: "+" followed by
+// digits. Numbers written with a "+" keep their country code. Russian numbers
+// written with a leading 8 or without a country code get the +7 prefix.
+// Returns an empty string when the input has too few digits to be a phone
+// number.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(s), "+"):
+		// The country code is explicit
+	case len(digits) == 11 && digits[0] == '8':
+		digits = "7" + digits[1:]
+	case len(digits) == 10:
+		digits = "7" + digits
+	}
+
+	if len(digits) < 10 {
+		return ""
+	}
+	return "+" + digits
+}
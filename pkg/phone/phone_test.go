@@ -0,0 +1,34 @@
+package phone
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"e164", "+79161234567", "+79161234567"},
+		{"formatted", "+7 (916) 123-45-67", "+79161234567"},
+		{"leading eight", "8 916 123 45 67", "+79161234567"},
+		{"without country code", "9161234567", "+79161234567"},
+		{"landline without country code", "495 123-45-67", "+74951234567"},
+		{"landline with leading eight", "8 495 123-45-67", "+74951234567"},
+		{"landline e164", "+7 495 123-45-67", "+74951234567"},
+		{"foreign", "+44 20 7946 0958", "+442079460958"},
+		{"foreign starting with eight", "+81 3 1234 5678", "+81312345678"},
+		{"foreign with ten digits", "+1 212 555 0123", "+12125550123"},
+		{"too short", "123-45-67", ""},
+		{"nine digits", "916123456", ""},
+		{"no digits", "n/a", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.input); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package rides
+
+import (
+	"context"
+	"fmt"
+	"smartDriver/internal/db"
+	"smartDriver/pkg/centrifugo"
+	"smartDriver/pkg/orderstatus"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// EventOrderDetached is published when an order is removed from a ride
+const EventOrderDetached = "order_detached"
+
+// Detachment describes an order removed from an active ride
+type Detachment struct {
+	Type       string    `json:"type"`
+	RideID     int64     `json:"ride_id"`
+	OrderID    int64     `json:"order_id"`
+	ExternalID string    `json:"external_id"`
+	Reason     string    `json:"reason"`
+	Source     string    `json:"source"`
+	DetachedAt time.Time `json:"detached_at"`
+}
+
+// DetachFinishedOrder removes the order from every active ride and records
+// the reason, usually the terminal status the order reached. Orders delivered
+// or failed on the road stay on the ride as finished stops instead.
+func DetachFinishedOrder(ctx context.Context, q *db.Queries, order db.Order, reason, source string) ([]Detachment, error) {
+	now := pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
+	finish := db.FinishOrderStopsOnRoadParams{OrderID: order.ID}
+	switch orderstatus.Status(reason) {
+	case orderstatus.Delivered:
+		finish.StopStatus = string(StopDelivered)
+		finish.DeliveredAt = now
+	case orderstatus.Failed:
+		finish.StopStatus = string(StopFailed)
+		finish.FailedAt = now
+	}
+	if finish.StopStatus != "" {
+		finishedRideIDs, err := q.FinishOrderStopsOnRoad(ctx, finish)
+		if err != nil {
+			return nil, fmt.Errorf("failed to finish order stops: %w", err)
+		}
+		if err := bumpVersions(ctx, q, finishedRideIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	rideIDs, err := q.DetachOrderFromActiveRides(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detach order: %w", err)
+	}
+	if err := bumpVersions(ctx, q, rideIDs); err != nil {
+		return nil, err
+	}
+
+	detachments := make([]Detachment, 0, len(rideIDs))
+	for _, rideID := range rideIDs {
+		record, err := q.CreateRideOrderDetachment(ctx, db.CreateRideOrderDetachmentParams{
+			RideID:  rideID,
+			OrderID: order.ID,
+			Reason:  reason,
+			Source:  source,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to record detachment: %w", err)
+		}
+
+		detachments = append(detachments, Detachment{
+			Type:       EventOrderDetached,
+			RideID:     rideID,
+			OrderID:    order.ID,
+			ExternalID: order.ExternalID,
+			Reason:     reason,
+			Source:     source,
+			DetachedAt: record.DetachedAt.Time,
+		})
+	}
+
+	return detachments, nil
+}
+
+// bumpVersions marks the rides as changed so clients editing them reload
+func bumpVersions(ctx context.Context, q *db.Queries, rideIDs []int64) error {
+	for _, rideID := range rideIDs {
+		if _, err := q.BumpRideVersion(ctx, rideID); err != nil {
+			return fmt.Errorf("failed to bump ride version: %w", err)
+		}
+	}
+	return nil
+}
+
+// PublishDetachments notifies the drivers of the rides and the dispatchers
+// of the organization. Call it after the detachments are committed.
+func PublishDetachments(ctx context.Context, publisher *centrifugo.Client, organizationID int64, detachments []Detachment) error {
+	for _, d := range detachments {
+		if err := publisher.Publish(ctx, centrifugo.RideChannel(d.RideID), d); err != nil {
+			return err
+		}
+		if err := publisher.Publish(ctx, centrifugo.OrganizationChannel(organizationID), d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
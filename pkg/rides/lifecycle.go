@@ -0,0 +1,111 @@
+package rides
+
+import (
+	"context"
+	"smartDriver/pkg/centrifugo"
+	"time"
+)
+
+// Status is the lifecycle state of a ride
+type Status string
+
+const (
+	StatusPlanned    Status = "planned"
+	StatusDeparted   Status = "departed"
+	StatusInProgress Status = "in_progress"
+	StatusReturned   Status = "returned"
+	StatusCancelled  Status = "cancelled"
+)
+
+// transitions lists the states a ride may move to from each state. A
+// departed ride starts when the driver reaches the first stop.
+var transitions = map[Status][]Status{
+	StatusPlanned:    {StatusDeparted, StatusCancelled},
+	StatusDeparted:   {StatusInProgress, StatusReturned},
+	StatusInProgress: {StatusReturned},
+}
+
+// CanTransition reports whether a ride may move from one state to another
+func CanTransition(from, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOnRoad reports whether the driver has left the branch and not yet
+// returned
+func (s Status) IsOnRoad() bool {
+	return s == StatusDeparted || s == StatusInProgress
+}
+
+// IsFinished reports whether the ride is over
+func (s Status) IsFinished() bool {
+	return s == StatusReturned || s == StatusCancelled
+}
+
+// StopStatus is the state of an order within a ride
+type StopStatus string
+
+const (
+	StopPending   StopStatus = "pending"
+	StopArrived   StopStatus = "arrived"
+	StopDelivered StopStatus = "delivered"
+	StopFailed    StopStatus = "failed"
+)
+
+// stopTransitions lists the states a stop may move to from each state.
+// Drivers may finish a stop without reporting the arrival.
+var stopTransitions = map[StopStatus][]StopStatus{
+	StopPending: {StopArrived, StopDelivered, StopFailed},
+	StopArrived: {StopDelivered, StopFailed},
+}
+
+// CanAdvanceStop reports whether a stop may move from one state to another
+func CanAdvanceStop(from, to StopStatus) bool {
+	for _, next := range stopTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinished reports whether the driver is done with the stop
+func (s StopStatus) IsFinished() bool {
+	return s == StopDelivered || s == StopFailed
+}
+
+// EventRideStatusChanged is published when a ride moves to another state
+const EventRideStatusChanged = "ride_status_changed"
+
+// EventStopStatusChanged is published when a stop of a ride moves to another
+// state
+const EventStopStatusChanged = "ride_stop_status_changed"
+
+// StatusChange describes a ride or stop transition. OrderID is set for stop
+// transitions only.
+type StatusChange struct {
+	Type      string    `json:"type"`
+	RideID    int64     `json:"ride_id"`
+	OrderID   *int64    `json:"order_id,omitempty"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// PublishStatusChanges notifies the driver of the ride and the dispatchers
+// of the organization. Call it after the changes are committed.
+func PublishStatusChanges(ctx context.Context, publisher *centrifugo.Client, organizationID int64, changes []StatusChange) error {
+	for _, c := range changes {
+		if err := publisher.Publish(ctx, centrifugo.RideChannel(c.RideID), c); err != nil {
+			return err
+		}
+		if err := publisher.Publish(ctx, centrifugo.OrganizationChannel(organizationID), c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package route
+
+import (
+	"math"
+	"smartDriver/internal/config"
+	"smartDriver/pkg/geo"
+	"time"
+)
+
+// latePenalty is how many seconds of driving a second of lateness is worth
+// when comparing routes. Keeping promises matters more than a short route.
+const latePenalty = 10
+
+// maxPasses bounds the improvement passes for large rides
+const maxPasses = 50
+
+// Options describe how fast a driver moves between stops
+type Options struct {
+	// Speed is the average speed in kilometers per hour
+	Speed float64
+	// DetourFactor converts great-circle distances to road distances
+	DetourFactor float64
+	// ServiceTime is spent at each stop handing over the order
+	ServiceTime time.Duration
+}
+
+// DefaultOptions are the options configured by Init
+var DefaultOptions = Options{
+	Speed:        25,
+	DetourFactor: 1.3,
+	ServiceTime:  5 * time.Minute,
+}
+
+// Init applies the route configuration to DefaultOptions
+func Init(cfg *config.Config) {
+	DefaultOptions = Options{
+		Speed:        float64(cfg.Route.AverageSpeed),
+		DetourFactor: float64(cfg.Route.DetourPercent) / 100,
+		ServiceTime:  cfg.Route.ServiceTime,
+	}
+}
+
+// Stop is an order the driver has to visit
+type Stop struct {
+	ID       int64
+	Location geo.Point
+	// PromisedAt is the promised delivery time, zero when not promised
+	PromisedAt time.Time
+}
+
+// Leg is the way to a stop from the previous one, or from the origin
+type Leg struct {
+	StopID int64
+	// Distance is the estimated road distance in meters, zero for stops
+	// without a usable location
+	Distance float64
+	Arrival  time.Time
+	// Late is how long after the promised time the driver arrives
+	Late time.Duration
+}
+
+// Plan is the visiting sequence of a ride
+type Plan struct {
+	Legs     []Leg
+	Distance float64
+}
+
+// Optimize finds a good sequence to visit the stops starting from origin at
+// departure. The route starts with a nearest neighbour tour and is improved
+// with 2-opt and stop relocation moves, weighing driving time against
+// lateness. Stops without a usable location are visited last, in the given
+// order.
+func Optimize(origin geo.Point, stops []Stop, departure time.Time, opts Options) Plan {
+	var located, unlocated []Stop
+	for _, stop := range stops {
+		if stop.Location.Valid() {
+			located = append(located, stop)
+		} else {
+			unlocated = append(unlocated, stop)
+		}
+	}
+
+	p := planner{origin: origin, departure: departure, opts: opts}
+	sequence := p.nearestNeighbour(located)
+	sequence = p.improve(sequence)
+
+	return p.plan(append(sequence, unlocated...))
+}
+
+// Evaluate returns the plan for visiting the stops in the given order
+func Evaluate(origin geo.Point, stops []Stop, departure time.Time, opts Options) Plan {
+	p := planner{origin: origin, departure: departure, opts: opts}
+	return p.plan(stops)
+}
+
+type planner struct {
+	origin    geo.Point
+	departure time.Time
+	opts      Options
+}
+
+// RoadDistance estimates the road distance between two points in meters
+func (o Options) RoadDistance(a, b geo.Point) float64 {
+	factor := o.DetourFactor
+	if factor <= 0 {
+		factor = 1
+	}
+	return geo.Distance(a, b) * factor
+}
+
+// TravelTime estimates the driving time over a road distance in meters
+func (o Options) TravelTime(meters float64) time.Duration {
+	speed := o.Speed
+	if speed <= 0 {
+		speed = DefaultOptions.Speed
+	}
+	return time.Duration(meters / (speed / 3.6) * float64(time.Second))
+}
+
+// Helper function to estimate the road distance between two points
+func (p planner) distance(a, b geo.Point) float64 {
+	return p.opts.RoadDistance(a, b)
+}
+
+// Helper function to estimate the driving time over a road distance
+func (p planner) travel(meters float64) time.Duration {
+	return p.opts.TravelTime(meters)
+}
+
+// Helper function to lay out the legs of a sequence
+func (p planner) plan(sequence []Stop) Plan {
+	plan := Plan{Legs: make([]Leg, 0, len(sequence))}
+	position, clock := p.origin, p.departure
+	for i, stop := range sequence {
+		leg := Leg{StopID: stop.ID}
+		if stop.Location.Valid() {
+			leg.Distance = p.distance(position, stop.Location)
+			position = stop.Location
+		}
+		if i > 0 {
+			clock = clock.Add(p.opts.ServiceTime)
+		}
+		clock = clock.Add(p.travel(leg.Distance))
+		leg.Arrival = clock
+		if !stop.PromisedAt.IsZero() && clock.After(stop.PromisedAt) {
+			leg.Late = clock.Sub(stop.PromisedAt)
+		}
+		plan.Legs = append(plan.Legs, leg)
+		plan.Distance += leg.Distance
+	}
+	return plan
+}
+
+// Helper function to score a sequence, lower is better
+func (p planner) cost(sequence []Stop) float64 {
+	var cost float64
+	position, clock := p.origin, p.departure
+	for i, stop := range sequence {
+		meters := p.distance(position, stop.Location)
+		if i > 0 {
+			clock = clock.Add(p.opts.ServiceTime)
+		}
+		clock = clock.Add(p.travel(meters))
+		position = stop.Location
+
+		cost += p.travel(meters).Seconds()
+		if !stop.PromisedAt.IsZero() && clock.After(stop.PromisedAt) {
+			cost += latePenalty * clock.Sub(stop.PromisedAt).Seconds()
+		}
+	}
+	return cost
+}
+
+// Helper function to build a tour always going to the cheapest next stop
+func (p planner) nearestNeighbour(stops []Stop) []Stop {
+	remaining := append([]Stop(nil), stops...)
+	sequence := make([]Stop, 0, len(stops))
+	position, clock := p.origin, p.departure
+
+	for len(remaining) > 0 {
+		best, bestCost := 0, math.Inf(1)
+		for i, stop := range remaining {
+			meters := p.distance(position, stop.Location)
+			arrival := clock.Add(p.travel(meters))
+			cost := p.travel(meters).Seconds()
+			if !stop.PromisedAt.IsZero() && arrival.After(stop.PromisedAt) {
+				cost += latePenalty * arrival.Sub(stop.PromisedAt).Seconds()
+			}
+			if cost < bestCost {
+				best, bestCost = i, cost
+			}
+		}
+
+		stop := remaining[best]
+		meters := p.distance(position, stop.Location)
+		clock = clock.Add(p.travel(meters)).Add(p.opts.ServiceTime)
+		position = stop.Location
+		sequence = append(sequence, stop)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+
+	return sequence
+}
+
+// Helper function to improve a sequence with 2-opt and relocation moves
+// until no move makes it cheaper
+func (p planner) improve(sequence []Stop) []Stop {
+	best := p.cost(sequence)
+	candidate := make([]Stop, len(sequence))
+
+	for pass := 0; pass < maxPasses; pass++ {
+		improved := false
+
+		// 2-opt: reverse the segment between i and j
+		for i := 0; i < len(sequence)-1; i++ {
+			for j := i + 1; j < len(sequence); j++ {
+				copy(candidate, sequence)
+				for l, r := i, j; l < r; l, r = l+1, r-1 {
+					candidate[l], candidate[r] = candidate[r], candidate[l]
+				}
+				if cost := p.cost(candidate); cost < best-1e-9 {
+					best = cost
+					copy(sequence, candidate)
+					improved = true
+				}
+			}
+		}
+
+		// Relocation: move a single stop to another position
+		for i := range sequence {
+			for j := range sequence {
+				if i == j {
+					continue
+				}
+				moveStop(candidate, sequence, i, j)
+				if cost := p.cost(candidate); cost < best-1e-9 {
+					best = cost
+					copy(sequence, candidate)
+					improved = true
+				}
+			}
+		}
+
+		if !improved {
+			break
+		}
+	}
+
+	return sequence
+}
+
+// Helper function to copy src into dst with the stop at from moved to to
+func moveStop(dst, src []Stop, from, to int) {
+	stop := src[from]
+	k := 0
+	for i := range src {
+		if i == from {
+			continue
+		}
+		if k == to {
+			dst[k] = stop
+			k++
+		}
+		dst[k] = src[i]
+		k++
+	}
+	if k == to {
+		dst[k] = stop
+	}
+}
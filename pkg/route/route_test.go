@@ -0,0 +1,209 @@
+package route
+
+import (
+	"math"
+	"smartDriver/pkg/geo"
+	"testing"
+	"time"
+)
+
+var (
+	departure = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	origin    = geo.Point{Lat: 55.7, Lng: 37.6}
+	// opts drive 10 meters a second along great circles
+	opts = Options{Speed: 36, DetourFactor: 1, ServiceTime: 5 * time.Minute}
+)
+
+// north returns the point about km kilometers north of origin
+func north(km float64) geo.Point {
+	return geo.Point{Lat: origin.Lat + km*0.009, Lng: origin.Lng}
+}
+
+// Helper function to list the stop ids of a plan
+func stopIDs(plan Plan) []int64 {
+	ids := make([]int64, 0, len(plan.Legs))
+	for _, leg := range plan.Legs {
+		ids = append(ids, leg.StopID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name  string
+		stops []Stop
+		want  []int64
+	}{
+		{
+			name: "empty",
+			want: []int64{},
+		},
+		{
+			name:  "one stop",
+			stops: []Stop{{ID: 1, Location: north(2)}},
+			want:  []int64{1},
+		},
+		{
+			name: "along a line",
+			stops: []Stop{
+				{ID: 3, Location: north(3)},
+				{ID: 1, Location: north(1)},
+				{ID: 2, Location: north(2)},
+			},
+			want: []int64{1, 2, 3},
+		},
+		{
+			name: "both directions",
+			stops: []Stop{
+				{ID: 1, Location: north(-1)},
+				{ID: 2, Location: north(1)},
+				{ID: 3, Location: north(4)},
+			},
+			want: []int64{1, 2, 3},
+		},
+		{
+			name: "promise comes first",
+			stops: []Stop{
+				{ID: 1, Location: north(1)},
+				{ID: 2, Location: north(5), PromisedAt: departure.Add(10 * time.Minute)},
+			},
+			want: []int64{2, 1},
+		},
+		{
+			name: "generous promise keeps the short route",
+			stops: []Stop{
+				{ID: 2, Location: north(5), PromisedAt: departure.Add(2 * time.Hour)},
+				{ID: 1, Location: north(1)},
+			},
+			want: []int64{1, 2},
+		},
+		{
+			name: "stops without a location go last",
+			stops: []Stop{
+				{ID: 1},
+				{ID: 2, Location: north(2)},
+				{ID: 3, Location: geo.Point{Lat: 95, Lng: 37.6}},
+				{ID: 4, Location: north(1)},
+			},
+			want: []int64{4, 2, 1, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := Optimize(origin, tt.stops, departure, opts)
+			if got := stopIDs(plan); !equalIDs(got, tt.want) {
+				t.Errorf("Optimize() visits %v, want %v", got, tt.want)
+			}
+
+			var distance float64
+			clock := departure
+			for _, leg := range plan.Legs {
+				if leg.Arrival.Before(clock) {
+					t.Errorf("arrival at %d goes back in time", leg.StopID)
+				}
+				clock = leg.Arrival
+				distance += leg.Distance
+			}
+			if math.Abs(distance-plan.Distance) > 1e-6 {
+				t.Errorf("Distance = %.1f, legs sum to %.1f", plan.Distance, distance)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	stops := []Stop{
+		{ID: 1, Location: north(2), PromisedAt: departure.Add(time.Minute)},
+		{ID: 2},
+		{ID: 3, Location: north(1)},
+	}
+	plan := Evaluate(origin, stops, departure, opts)
+
+	first := geo.Distance(origin, north(2))
+	second := geo.Distance(north(2), north(1))
+	tests := []struct {
+		name     string
+		leg      Leg
+		distance float64
+		arrival  time.Time
+		late     time.Duration
+	}{
+		{"first stop", plan.Legs[0], first, departure.Add(opts.TravelTime(first)), opts.TravelTime(first) - time.Minute},
+		{"without location", plan.Legs[1], 0, plan.Legs[0].Arrival.Add(opts.ServiceTime), 0},
+		{"back from the last location", plan.Legs[2], second, plan.Legs[1].Arrival.Add(opts.ServiceTime + opts.TravelTime(second)), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if math.Abs(tt.leg.Distance-tt.distance) > 1e-6 {
+				t.Errorf("Distance = %.1f, want %.1f", tt.leg.Distance, tt.distance)
+			}
+			if !tt.leg.Arrival.Equal(tt.arrival) {
+				t.Errorf("Arrival = %v, want %v", tt.leg.Arrival, tt.arrival)
+			}
+			if tt.leg.Late != tt.late {
+				t.Errorf("Late = %v, want %v", tt.leg.Late, tt.late)
+			}
+		})
+	}
+	if got := stopIDs(plan); !equalIDs(got, []int64{1, 2, 3}) {
+		t.Errorf("Evaluate() visits %v, want the given order", got)
+	}
+}
+
+func TestTravelTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		meters float64
+		want   time.Duration
+	}{
+		{"nowhere", opts, 0, 0},
+		{"ten meters a second", opts, 1000, 100 * time.Second},
+		{"zero speed uses the default", Options{}, 1000, time.Duration(1000 / (DefaultOptions.Speed / 3.6) * float64(time.Second))},
+		{"negative speed uses the default", Options{Speed: -5}, 1000, time.Duration(1000 / (DefaultOptions.Speed / 3.6) * float64(time.Second))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.TravelTime(tt.meters); got != tt.want {
+				t.Errorf("TravelTime(%.0f) = %v, want %v", tt.meters, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoadDistance(t *testing.T) {
+	straight := geo.Distance(origin, north(1))
+	tests := []struct {
+		name   string
+		factor float64
+		want   float64
+	}{
+		{"detour", 1.3, straight * 1.3},
+		{"straight", 1, straight},
+		{"zero factor is straight", 0, straight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Options{DetourFactor: tt.factor}.RoadDistance(origin, north(1))
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("RoadDistance() = %.1f, want %.1f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package tracking
+
+import "smartDriver/pkg/geo"
+
+// MaxAccuracy is the worst accuracy in meters of a position counted in the
+// distance of a track
+const MaxAccuracy = 150.0
+
+// MaxSpeed is the fastest a courier plausibly moves between two positions of
+// a track in meters per second. Faster jumps are GPS glitches.
+const MaxSpeed = 50.0
+
+// Distance measures the distance driven along a track in meters. Positions
+// coarser than MaxAccuracy and jumps faster than MaxSpeed are skipped, and
+// moves within the accuracy of a position are treated as jitter until the
+// courier gets further away.
+func Distance(track []Position) float64 {
+	var (
+		distance float64
+		last     *Position
+	)
+	for i := range track {
+		p := &track[i]
+		if p.Accuracy != nil && *p.Accuracy > MaxAccuracy {
+			continue
+		}
+		if last == nil {
+			last = p
+			continue
+		}
+
+		step := geo.Distance(last.Location, p.Location)
+		if p.Accuracy != nil && step < *p.Accuracy {
+			continue
+		}
+		if step > MaxSpeed*p.RecordedAt.Sub(last.RecordedAt).Seconds() {
+			continue
+		}
+		distance += step
+		last = p
+	}
+	return distance
+}
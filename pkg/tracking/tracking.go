@@ -0,0 +1,66 @@
+package tracking
+
+import (
+	"smartDriver/pkg/geo"
+	"sync"
+	"time"
+)
+
+// EventDriverPosition is published when a driver reports a newer position
+const EventDriverPosition = "driver_position"
+
+// MaxClockSkew is how far in the future of the server clock a reported
+// position may be recorded
+const MaxClockSkew = 5 * time.Minute
+
+// Position is a location reported by the courier app
+type Position struct {
+	DriverID   int64     `json:"driver_id"`
+	RideID     *int64    `json:"ride_id"`
+	Location   geo.Point `json:"location"`
+	Accuracy   *float64  `json:"accuracy,omitempty"`
+	Speed      *float64  `json:"speed,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Event tells dispatchers and the ride channel where the driver is
+type Event struct {
+	Type string `json:"type"`
+	Position
+}
+
+// Index keeps the latest position of every driver in memory
+type Index struct {
+	mu        sync.RWMutex
+	positions map[int64]Position
+}
+
+// Latest is the index of the server process
+var Latest = NewIndex()
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{positions: make(map[int64]Position)}
+}
+
+// Update stores the position unless a newer one of the driver is known.
+// It reports whether the position was stored.
+func (i *Index) Update(p Position) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if current, ok := i.positions[p.DriverID]; ok && !p.RecordedAt.After(current.RecordedAt) {
+		return false
+	}
+	i.positions[p.DriverID] = p
+	return true
+}
+
+// Get returns the latest known position of the driver
+func (i *Index) Get(driverID int64) (Position, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	p, ok := i.positions[driverID]
+	return p, ok
+}
@@ -6,7 +6,7 @@ INSERT INTO branches (name, location, organization_id)
 SELECT * FROM branches;
 
 -- name: GetBranch :one
-SELECT * FROM branches WHERE id = $1;
+SELECT * FROM branches WHERE id = $1 AND organization_id = $2;
 
 -- name: UpdateBranch :one
 UPDATE branches
@@ -0,0 +1,52 @@
+-- name: ListVehicleLimits :many
+SELECT *
+FROM vehicle_limits
+WHERE organization_id = $1
+ORDER BY vehicle_type;
+
+-- name: GetVehicleLimits :one
+SELECT *
+FROM vehicle_limits
+WHERE organization_id = $1 AND vehicle_type = $2;
+
+-- name: UpsertVehicleLimits :one
+INSERT INTO vehicle_limits (organization_id, vehicle_type, max_orders, max_weight, max_volume, max_distance)
+VALUES (@organization_id, @vehicle_type, sqlc.narg('max_orders'), sqlc.narg('max_weight'),
+        sqlc.narg('max_volume'), sqlc.narg('max_distance'))
+ON CONFLICT (organization_id, vehicle_type) DO UPDATE
+    SET max_orders   = excluded.max_orders,
+        max_weight   = excluded.max_weight,
+        max_volume   = excluded.max_volume,
+        max_distance = excluded.max_distance
+RETURNING *;
+
+-- name: ListProductSizes :many
+SELECT *
+FROM product_sizes
+WHERE organization_id = $1
+ORDER BY product_id;
+
+-- name: UpsertProductSize :one
+INSERT INTO product_sizes (organization_id, product_id, weight, volume)
+VALUES (@organization_id, @product_id, @weight, @volume)
+ON CONFLICT (organization_id, product_id) DO UPDATE
+    SET weight = excluded.weight,
+        volume = excluded.volume
+RETURNING *;
+
+-- name: DeleteProductSize :execrows
+DELETE FROM product_sizes
+WHERE organization_id = $1 AND product_id = $2;
+
+-- name: ListOrderSizes :many
+-- Total size of the items of each order, products missing from the catalog
+-- count with the default unit size. Orders without items are not returned.
+SELECT oi.order_id,
+       SUM(oi.amount * COALESCE(ps.weight, @default_weight::integer))::float8 AS weight,
+       SUM(oi.amount * COALESCE(ps.volume, @default_volume::integer))::float8 AS volume
+FROM order_items oi
+         JOIN orders o ON o.id = oi.order_id
+         LEFT JOIN product_sizes ps ON ps.organization_id = o.organization_id AND ps.product_id = oi.product_id
+WHERE o.organization_id = @organization_id
+  AND oi.order_id = ANY (@order_ids::bigint[])
+GROUP BY oi.order_id;
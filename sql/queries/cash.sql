@@ -0,0 +1,74 @@
+-- name: ListRideCashOrders :many
+-- Orders the driver of a ride handed over: delivered while on the ride or
+-- confirmed with a proof of delivery from the ride
+SELECT o.*
+FROM orders o
+WHERE o.id IN (SELECT d.order_id FROM ride_order_detachments d WHERE d.ride_id = @ride_id
+               UNION
+               SELECT rto.order_id FROM rides_to_orders rto WHERE rto.ride_id = @ride_id
+               UNION
+               SELECT dc.order_id FROM delivery_confirmations dc WHERE dc.ride_id = @ride_id)
+  AND (o.status = 'delivered'
+    OR EXISTS (SELECT 1 FROM delivery_confirmations dc WHERE dc.order_id = o.id AND dc.ride_id = @ride_id))
+ORDER BY o.id;
+
+-- name: GetRideCashReport :one
+SELECT * FROM ride_cash_reports
+WHERE ride_id = $1;
+
+-- name: UpsertRideCashReport :one
+-- Drivers may correct their report until the branch confirms the handover
+INSERT INTO ride_cash_reports (
+    ride_id,
+    expected,
+    collected,
+    reported_by,
+    driver_comment
+) VALUES (
+    @ride_id, @expected, @collected, @reported_by, @driver_comment
+)
+ON CONFLICT (ride_id) DO UPDATE
+    SET expected       = EXCLUDED.expected,
+        collected      = EXCLUDED.collected,
+        reported_by    = EXCLUDED.reported_by,
+        reported_at    = CURRENT_TIMESTAMP,
+        driver_comment = EXCLUDED.driver_comment
+WHERE ride_cash_reports.confirmed_at IS NULL
+RETURNING *;
+
+-- name: ConfirmRideCashHandover :one
+UPDATE ride_cash_reports
+SET expected       = @expected,
+    received       = @received,
+    confirmed_by   = @confirmed_by,
+    confirmed_at   = CURRENT_TIMESTAMP,
+    branch_comment = @branch_comment
+WHERE ride_id = @ride_id
+  AND confirmed_at IS NULL
+RETURNING *;
+
+-- name: ListCashDiscrepancies :many
+-- Sums cash reports per ride driver and local day of the ride start
+SELECT r.driver_id,
+       COALESCE(u.name, '')::text                                              AS driver_name,
+       COALESCE(u.surname, '')::text                                           AS driver_surname,
+       (r.created_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date       AS day,
+       COUNT(*)                                                                AS ride_count,
+       COUNT(rcr.confirmed_at)                                                 AS confirmed_count,
+       SUM(rcr.expected)::numeric                                              AS expected,
+       SUM(rcr.collected)::numeric                                             AS collected,
+       COALESCE(SUM(rcr.received), 0)::numeric                                 AS received,
+       SUM(COALESCE(rcr.received, rcr.collected) - rcr.expected)::numeric      AS discrepancy
+FROM ride_cash_reports rcr
+         JOIN rides r ON r.id = rcr.ride_id
+         JOIN branches b ON b.id = r.branch_id
+         JOIN organizations org ON org.id = b.organization_id
+         LEFT JOIN drivers d ON d.id = r.driver_id
+         LEFT JOIN users u ON u.id = d.user_id
+WHERE b.organization_id = @organization_id
+  AND (r.created_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date BETWEEN @date_from::date AND @date_to::date
+  AND (sqlc.narg('driver_id')::bigint IS NULL OR r.driver_id = sqlc.narg('driver_id'))
+GROUP BY r.driver_id, u.name, u.surname, day
+HAVING NOT @only_discrepancies::boolean
+    OR SUM(COALESCE(rcr.received, rcr.collected) - rcr.expected) <> 0
+ORDER BY day DESC, r.driver_id;
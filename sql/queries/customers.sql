@@ -0,0 +1,80 @@
+-- name: UpsertCustomer :one
+-- Keeps the latest non-empty name the customer gave, the customer only counts
+-- as updated when the name changes
+INSERT INTO customers (organization_id, phone, name)
+VALUES (@organization_id, @phone, @name)
+ON CONFLICT (organization_id, phone) DO UPDATE
+    SET name       = CASE WHEN EXCLUDED.name <> '' THEN EXCLUDED.name ELSE customers.name END,
+        updated_at = CASE
+                         WHEN EXCLUDED.name <> '' AND EXCLUDED.name <> customers.name THEN CURRENT_TIMESTAMP
+                         ELSE customers.updated_at END
+RETURNING *;
+
+-- name: UpsertCustomerAddress :exec
+-- Counts how often an address is used and keeps its latest real coordinates
+INSERT INTO customer_addresses (customer_id, city, street, building, apartment, entrance, floor, doorphone, location)
+VALUES (@customer_id, @city, @street, @building, @apartment, @entrance, @floor, @doorphone, point(@lng::float8, @lat::float8))
+ON CONFLICT (customer_id, city, street, building, apartment) DO UPDATE
+    SET entrance     = COALESCE(EXCLUDED.entrance, customer_addresses.entrance),
+        floor        = COALESCE(EXCLUDED.floor, customer_addresses.floor),
+        doorphone    = COALESCE(NULLIF(EXCLUDED.doorphone, ''), customer_addresses.doorphone),
+        location     = CASE
+                           WHEN EXCLUDED.location ~= point(0, 0) THEN customer_addresses.location
+                           ELSE EXCLUDED.location END,
+        use_count    = customer_addresses.use_count + 1,
+        last_used_at = CURRENT_TIMESTAMP;
+
+-- name: GetCustomer :one
+SELECT * FROM customers
+WHERE id = $1 AND organization_id = $2;
+
+-- name: ListCustomers :many
+SELECT c.*, COUNT(o.id) AS order_count
+FROM customers c
+         LEFT JOIN orders o ON o.customer_id = c.id
+WHERE c.organization_id = @organization_id
+  AND (sqlc.narg('search')::text IS NULL
+    OR c.phone LIKE '%' || sqlc.narg('search') || '%'
+    OR c.name ILIKE '%' || sqlc.narg('search') || '%')
+  AND (sqlc.narg('flagged')::boolean IS NULL
+    OR EXISTS (SELECT 1 FROM customer_flags f WHERE f.customer_id = c.id) = sqlc.narg('flagged'))
+GROUP BY c.id
+ORDER BY c.updated_at DESC, c.id DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: UpdateCustomer :one
+UPDATE customers
+SET name       = $3,
+    notes      = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND organization_id = $2
+RETURNING *;
+
+-- name: ListCustomerAddresses :many
+SELECT * FROM customer_addresses
+WHERE customer_id = $1
+ORDER BY last_used_at DESC, id DESC;
+
+-- name: ListCustomerOrders :many
+SELECT * FROM orders
+WHERE customer_id = $1 AND organization_id = $2
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $4;
+
+-- name: CreateCustomerFlag :one
+INSERT INTO customer_flags (customer_id, flag, comment, created_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (customer_id, flag) DO UPDATE
+    SET comment    = EXCLUDED.comment,
+        created_by = EXCLUDED.created_by,
+        created_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: ListCustomerFlags :many
+SELECT * FROM customer_flags
+WHERE customer_id = $1
+ORDER BY created_at, id;
+
+-- name: DeleteCustomerFlag :execrows
+DELETE FROM customer_flags
+WHERE id = $1 AND customer_id = $2;
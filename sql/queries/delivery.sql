@@ -0,0 +1,46 @@
+-- name: CreateDeliveryConfirmation :one
+INSERT INTO delivery_confirmations (
+    order_id,
+    ride_id,
+    confirmed_by,
+    delivered_at,
+    location,
+    accuracy,
+    recipient_name,
+    comment
+) VALUES (
+    @order_id, @ride_id, @confirmed_by, @delivered_at,
+    point(@lng::float8, @lat::float8), @accuracy, @recipient_name, @comment
+)
+ON CONFLICT (order_id) DO NOTHING
+RETURNING *;
+
+-- name: GetDeliveryConfirmation :one
+SELECT dc.*
+FROM delivery_confirmations dc
+         JOIN orders o ON o.id = dc.order_id
+WHERE dc.order_id = $1 AND o.organization_id = $2;
+
+-- name: CreateDeliveryAttachment :one
+INSERT INTO delivery_attachments (
+    confirmation_id,
+    kind,
+    storage_key,
+    content_type,
+    size
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListDeliveryAttachments :many
+SELECT * FROM delivery_attachments
+WHERE confirmation_id = $1
+ORDER BY id;
+
+-- name: GetDeliveryAttachment :one
+SELECT da.*
+FROM delivery_attachments da
+         JOIN delivery_confirmations dc ON dc.id = da.confirmation_id
+         JOIN orders o ON o.id = dc.order_id
+WHERE da.id = @id AND dc.order_id = @order_id AND o.organization_id = @organization_id;
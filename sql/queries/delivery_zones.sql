@@ -0,0 +1,19 @@
+-- name: CreateDeliveryZone :one
+INSERT INTO delivery_zones (branch_id, name, area)
+    VALUES ($1, $2, $3) RETURNING *;
+
+-- name: ListBranchDeliveryZones :many
+SELECT * FROM delivery_zones
+WHERE branch_id = $1
+ORDER BY id;
+
+-- name: ListOrganizationDeliveryZones :many
+SELECT dz.*, b.location AS branch_location
+FROM delivery_zones dz
+         JOIN branches b ON b.id = dz.branch_id
+WHERE b.organization_id = $1
+ORDER BY dz.id;
+
+-- name: DeleteBranchDeliveryZones :exec
+DELETE FROM delivery_zones
+WHERE branch_id = $1;
@@ -0,0 +1,49 @@
+-- name: GetDispatchSettings :one
+SELECT id, auto_dispatch, ride_capacity, dispatch_radius, dispatch_window_minutes
+FROM organizations
+WHERE id = $1;
+
+-- name: UpdateDispatchSettings :one
+UPDATE organizations
+SET auto_dispatch           = @auto_dispatch,
+    ride_capacity           = @ride_capacity,
+    dispatch_radius         = @dispatch_radius,
+    dispatch_window_minutes = @dispatch_window_minutes
+WHERE id = @id
+RETURNING id, auto_dispatch, ride_capacity, dispatch_radius, dispatch_window_minutes;
+
+-- name: ListAutoDispatchBranches :many
+-- Branches of paying organizations that let rides be created automatically
+SELECT b.*
+FROM branches b
+         JOIN organizations org ON org.id = b.organization_id
+WHERE org.auto_dispatch AND org.balance > 0
+ORDER BY b.id;
+
+-- name: ListDispatchableOrders :many
+-- Ready orders of a branch waiting for a ride that have a usable location
+SELECT o.*
+FROM orders o
+         LEFT JOIN rides_to_orders rto ON rto.order_id = o.id
+WHERE rto.ride_id IS NULL
+  AND o.organization_id = @organization_id
+  AND o.branch_id = @branch_id
+  AND o.status = 'ready'
+  AND o.location_source <> 'missing'
+ORDER BY o.id;
+
+-- name: ListIdleDrivers :many
+-- Drivers on duty without an active ride, drivers of the branch first
+SELECT d.*
+FROM drivers d
+WHERE d.organization_id = @organization_id
+  AND d.status = 'on_duty'
+  AND NOT EXISTS (SELECT 1 FROM rides r WHERE r.driver_id = d.id AND r.ended_at IS NULL)
+ORDER BY d.home_branch_id IS DISTINCT FROM sqlc.narg('branch_id')::bigint, d.updated_at;
+
+-- name: CountOrdersOnActiveRides :one
+SELECT COUNT(*)
+FROM rides_to_orders rto
+         JOIN rides r ON r.id = rto.ride_id
+WHERE r.ended_at IS NULL
+  AND rto.order_id = ANY (@order_ids::bigint[]);
@@ -0,0 +1,61 @@
+-- name: CreateDriver :one
+-- Only users of the organization can become its drivers
+INSERT INTO drivers (
+    organization_id,
+    user_id,
+    phone,
+    vehicle_type,
+    home_branch_id,
+    status
+)
+SELECT u.organization_id, u.id, @phone, @vehicle_type, sqlc.narg('home_branch_id'), @status
+FROM users u
+WHERE u.id = @user_id AND u.organization_id = @organization_id
+RETURNING *;
+
+-- name: GetDriver :one
+SELECT d.*,
+       COALESCE(u.name, '')::text    AS name,
+       COALESCE(u.surname, '')::text AS surname,
+       ar.id                         AS active_ride_id
+FROM drivers d
+         JOIN users u ON u.id = d.user_id
+         LEFT JOIN rides ar ON ar.driver_id = d.id AND ar.ended_at IS NULL
+WHERE d.id = $1 AND d.organization_id = $2;
+
+-- name: ListDrivers :many
+SELECT d.*,
+       COALESCE(u.name, '')::text    AS name,
+       COALESCE(u.surname, '')::text AS surname,
+       ar.id                         AS active_ride_id
+FROM drivers d
+         JOIN users u ON u.id = d.user_id
+         LEFT JOIN rides ar ON ar.driver_id = d.id AND ar.ended_at IS NULL
+WHERE d.organization_id = @organization_id
+  AND (sqlc.narg('status')::text IS NULL OR d.status = sqlc.narg('status'))
+  AND (sqlc.narg('branch_id')::bigint IS NULL OR d.home_branch_id = sqlc.narg('branch_id'))
+ORDER BY d.id;
+
+-- name: UpdateDriver :one
+UPDATE drivers
+SET phone          = @phone,
+    vehicle_type   = @vehicle_type,
+    home_branch_id = sqlc.narg('home_branch_id'),
+    status         = @status,
+    updated_at     = CURRENT_TIMESTAMP
+WHERE id = @id AND organization_id = @organization_id
+RETURNING *;
+
+-- name: GetDriverActiveRideID :one
+SELECT id FROM rides
+WHERE driver_id = $1 AND ended_at IS NULL;
+
+-- name: SetRideDriver :exec
+UPDATE rides
+SET driver_id = $2
+WHERE id = $1;
+
+-- name: GetDriverByUserID :one
+SELECT *
+FROM drivers
+WHERE user_id = $1 AND organization_id = $2;
@@ -0,0 +1,29 @@
+-- name: ListVehicleProfiles :many
+SELECT *
+FROM vehicle_profiles
+WHERE organization_id = $1
+ORDER BY vehicle_type;
+
+-- name: GetVehicleProfile :one
+SELECT *
+FROM vehicle_profiles
+WHERE organization_id = $1 AND vehicle_type = $2;
+
+-- name: UpsertVehicleProfile :one
+INSERT INTO vehicle_profiles (organization_id, vehicle_type, speed, service_time)
+VALUES (@organization_id, @vehicle_type, @speed, @service_time)
+ON CONFLICT (organization_id, vehicle_type) DO UPDATE
+    SET speed        = excluded.speed,
+        service_time = excluded.service_time
+RETURNING *;
+
+-- name: UpdateRideStopETA :exec
+UPDATE rides_to_orders
+SET eta = @eta
+WHERE ride_id = @ride_id AND order_id = @order_id;
+
+-- name: GetOrderStopETA :one
+-- Estimate of the stop the driver is on the way to, if any
+SELECT eta
+FROM rides_to_orders
+WHERE order_id = $1 AND stop_status IN ('pending', 'arrived');
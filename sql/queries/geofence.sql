@@ -0,0 +1,22 @@
+-- name: GetGeofenceSettings :one
+SELECT id, stop_radius, branch_radius
+FROM organizations
+WHERE id = $1;
+
+-- name: UpdateGeofenceSettings :one
+UPDATE organizations
+SET stop_radius   = @stop_radius,
+    branch_radius = @branch_radius
+WHERE id = @id
+RETURNING id, stop_radius, branch_radius;
+
+-- name: CreateRideGeofenceEvent :one
+INSERT INTO ride_geofence_events (ride_id, kind, order_id, event, location, occurred_at)
+VALUES (@ride_id, @kind, sqlc.narg('order_id'), @event, point(@lng::float8, @lat::float8), @occurred_at)
+RETURNING *;
+
+-- name: ListRideGeofenceEvents :many
+SELECT *
+FROM ride_geofence_events
+WHERE ride_id = $1
+ORDER BY occurred_at, id;
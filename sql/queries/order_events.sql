@@ -0,0 +1,25 @@
+-- name: CreateOrderEvent :one
+INSERT INTO order_events (
+    order_id,
+    old_status,
+    new_status,
+    source,
+    iiko_revision,
+    occurred_at,
+    user_id
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7
+         )
+RETURNING *;
+
+-- name: ListOrderEvents :many
+SELECT * FROM order_events
+WHERE order_id = $1
+ORDER BY occurred_at, id;
+
+-- name: GetStatusBeforeAssignment :one
+SELECT old_status
+FROM order_events
+WHERE order_id = $1 AND new_status = 'assigned'
+ORDER BY occurred_at DESC, id DESC
+LIMIT 1;
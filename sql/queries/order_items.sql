@@ -0,0 +1,12 @@
+-- name: CreateOrderItem :exec
+INSERT INTO order_items (order_id, position, product_id, name, amount, price, cost, comment)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListOrderItems :many
+SELECT * FROM order_items
+WHERE order_id = $1
+ORDER BY position;
+
+-- name: DeleteOrderItems :exec
+DELETE FROM order_items
+WHERE order_id = $1;
\ No newline at end of file
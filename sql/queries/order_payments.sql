@@ -0,0 +1,12 @@
+-- name: CreateOrderPayment :exec
+INSERT INTO order_payments (order_id, kind, payment_type_name, sum)
+    VALUES ($1, $2, $3, $4);
+
+-- name: ListOrderPayments :many
+SELECT * FROM order_payments
+WHERE order_id = $1
+ORDER BY id;
+
+-- name: DeleteOrderPayments :exec
+DELETE FROM order_payments
+WHERE order_id = $1;
\ No newline at end of file
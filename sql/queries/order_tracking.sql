@@ -0,0 +1,29 @@
+-- name: CreateOrderTrackingLink :one
+-- Returns the existing link of the order, so the token stays stable
+INSERT INTO order_tracking_links (order_id, token)
+VALUES (@order_id, @token)
+ON CONFLICT (order_id) DO UPDATE SET order_id = excluded.order_id
+RETURNING *;
+
+-- name: GetOrderByTrackingToken :one
+SELECT sqlc.embed(o)
+FROM order_tracking_links otl
+         JOIN orders o ON o.id = otl.order_id
+WHERE otl.token = $1;
+
+-- name: GetOrderTrackingStop :one
+-- The latest stop of the order together with the ride and its driver
+SELECT rto.ride_id,
+       rto.stop_status,
+       rto.planned_arrival,
+       rto.eta,
+       r.status AS ride_status,
+       r.driver_id,
+       u.name   AS driver_name
+FROM rides_to_orders rto
+         JOIN rides r ON r.id = rto.ride_id
+         LEFT JOIN drivers d ON d.id = r.driver_id
+         LEFT JOIN users u ON u.id = d.user_id
+WHERE rto.order_id = $1
+ORDER BY rto.id DESC
+LIMIT 1;
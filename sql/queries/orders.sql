@@ -19,9 +19,24 @@ INSERT INTO orders (
     status,
     location,
     created_at,
-    external_id
+    external_id,
+    branch_id,
+    out_of_zone,
+    organization_id,
+    iiko_organization_id,
+    guest_count,
+    courier_name,
+    courier_phone,
+    cash_to_collect,
+    promised_at,
+    source,
+    iiko_status,
+    iiko_delivery_status,
+    location_source,
+    customer_id
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, point($13, $14), $15, $16
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, point($13, $14), $15, $16, $17, $18, $19, $20,
+             $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
          )
 RETURNING *;
 
@@ -39,53 +54,123 @@ SET
     entrance = $10,
     comment = $11,
     cost = $12,
-    location = point($13, $14)
+    location = point($13, $14),
+    branch_id = $15,
+    out_of_zone = $16,
+    guest_count = $17,
+    courier_name = $18,
+    courier_phone = $19,
+    cash_to_collect = $20,
+    -- A new promise gives the order a fresh chance to be on time
+    lateness = CASE WHEN promised_at IS DISTINCT FROM $21 THEN 'on_time' ELSE lateness END,
+    promised_at = $21,
+    iiko_status = $22,
+    iiko_delivery_status = $23,
+    location_source = $24,
+    customer_id = $25
 WHERE id = $1;
 
 -- name: ListOrders :many
 SELECT * FROM orders
+WHERE organization_id = $1
 ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+LIMIT $2 OFFSET $3;
 
 -- name: GetOrder :one
 SELECT * FROM orders
-WHERE id = $1;
+WHERE id = $1 AND organization_id = $2;
 
 -- name: GetOrdersByStatus :many
 SELECT * FROM orders
-WHERE status = $1
+WHERE organization_id = $1 AND status = $2
 ORDER BY created_at DESC;
 
 -- name: CountOrdersByStatus :one
 SELECT COUNT(*) FROM orders
-WHERE status = $1;
+WHERE organization_id = $1 AND status = $2;
 
 -- name: GetOrderByExternalID :one
 SELECT * FROM orders
-WHERE external_id = $1;
+WHERE organization_id = $1 AND external_id = $2;
 
 -- name: GetUnboundOrders :many
 SELECT o.*
 FROM orders o
          LEFT JOIN rides_to_orders rto ON rto.order_id = o.id
 WHERE rto.ride_id IS NULL
-  AND (o.status = $1)
-  AND (o.created_at >= $2)
-  AND (o.created_at <= $3)
+  AND o.organization_id = @organization_id
+  AND (sqlc.narg('status')::text IS NULL OR o.status = sqlc.narg('status'))
+  AND (sqlc.narg('from_date')::timestamp IS NULL OR o.created_at >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::timestamp IS NULL OR o.created_at <= sqlc.narg('to_date'))
 ORDER BY o.created_at DESC
-LIMIT $4 OFFSET $5;
+LIMIT @row_limit OFFSET @row_offset;
 
 -- name: CountUnboundOrders :one
 SELECT COUNT(*)
 FROM orders o
          LEFT JOIN rides_to_orders rto ON rto.order_id = o.id
 WHERE rto.ride_id IS NULL
-  AND (o.status = $1)
-  AND (o.created_at >= $2)
-  AND (o.created_at <= $3);
+  AND o.organization_id = @organization_id
+  AND (sqlc.narg('status')::text IS NULL OR o.status = sqlc.narg('status'))
+  AND (sqlc.narg('from_date')::timestamp IS NULL OR o.created_at >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::timestamp IS NULL OR o.created_at <= sqlc.narg('to_date'));
+
+-- name: FilterOrders :many
+-- Keyset pagination: the cursor holds the sort key and id of the last row
+-- of the previous page. Sort is one of created_at, -created_at, cost, -cost.
+-- Only the latest stop of an order counts, like GetOrderRideID.
+SELECT sqlc.embed(o), rto.ride_id, CASE WHEN rto.stop_status IN ('pending', 'arrived') THEN rto.eta END::timestamp AS eta
+FROM orders o
+         LEFT JOIN rides_to_orders rto ON rto.id = (SELECT max(id)
+                                                    FROM rides_to_orders
+                                                    WHERE order_id = o.id)
+WHERE o.organization_id = @organization_id
+  AND (sqlc.narg('statuses')::text[] IS NULL OR o.status = ANY (sqlc.narg('statuses')::text[]))
+  AND (sqlc.narg('from_date')::timestamp IS NULL OR o.created_at >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::timestamp IS NULL OR o.created_at <= sqlc.narg('to_date'))
+  AND (sqlc.narg('branch_id')::bigint IS NULL OR o.branch_id = sqlc.narg('branch_id'))
+  AND (sqlc.narg('phone')::text IS NULL OR o.phone ILIKE '%' || sqlc.narg('phone') || '%')
+  AND (sqlc.narg('min_cost')::float8 IS NULL OR o.cost >= sqlc.narg('min_cost'))
+  AND (sqlc.narg('max_cost')::float8 IS NULL OR o.cost <= sqlc.narg('max_cost'))
+  AND (sqlc.narg('bound')::boolean IS NULL OR (rto.ride_id IS NOT NULL) = sqlc.narg('bound'))
+  AND (sqlc.narg('ride_id')::bigint IS NULL OR rto.ride_id = sqlc.narg('ride_id'))
+  AND (sqlc.narg('source')::text IS NULL OR o.source = sqlc.narg('source'))
+  AND (sqlc.narg('cursor_id')::bigint IS NULL OR CASE @sort::text
+           WHEN 'created_at' THEN (o.created_at, o.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id'))
+           WHEN 'cost' THEN (o.cost, o.id) > (sqlc.narg('cursor_cost')::numeric, sqlc.narg('cursor_id'))
+           WHEN '-cost' THEN (o.cost, o.id) < (sqlc.narg('cursor_cost'), sqlc.narg('cursor_id'))
+           ELSE (o.created_at, o.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id'))
+      END)
+ORDER BY CASE WHEN @sort = 'created_at' THEN o.created_at END,
+         CASE WHEN @sort = '-created_at' THEN o.created_at END DESC,
+         CASE WHEN @sort = 'cost' THEN o.cost END,
+         CASE WHEN @sort = '-cost' THEN o.cost END DESC,
+         CASE WHEN @sort IN ('created_at', 'cost') THEN o.id END,
+         o.id DESC
+LIMIT @row_limit;
+
+-- name: GetOrderRideID :one
+-- The latest ride of the order, finished stops of earlier rides are kept
+SELECT ride_id
+FROM rides_to_orders
+WHERE order_id = $1
+ORDER BY id DESC
+LIMIT 1;
 
 -- name: GetOrderStatuses :many
 SELECT DISTINCT status
 FROM orders
-WHERE status IS NOT NULL
-ORDER BY status;
\ No newline at end of file
+WHERE organization_id = $1 AND status IS NOT NULL
+ORDER BY status;
+
+-- name: ListOpenPromisedOrders :many
+SELECT * FROM orders
+WHERE promised_at IS NOT NULL
+  AND lateness <> 'late'
+  AND status NOT IN ('delivered', 'cancelled', 'failed')
+ORDER BY promised_at;
+
+-- name: UpdateOrderLateness :exec
+UPDATE orders
+SET lateness = $2
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,135 @@
+-- name: ListPayRules :many
+SELECT *
+FROM pay_rules
+WHERE organization_id = $1
+ORDER BY branch_id NULLS FIRST;
+
+-- name: UpsertPayRule :one
+INSERT INTO pay_rules (organization_id, branch_id, per_order, per_km, per_hour, minimum_per_ride)
+VALUES (@organization_id, sqlc.narg('branch_id'), @per_order, @per_km, @per_hour, @minimum_per_ride)
+ON CONFLICT (organization_id, coalesce(branch_id, 0)) DO UPDATE
+    SET per_order        = excluded.per_order,
+        per_km           = excluded.per_km,
+        per_hour         = excluded.per_hour,
+        minimum_per_ride = excluded.minimum_per_ride,
+        updated_at       = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeletePayRule :execrows
+DELETE FROM pay_rules
+WHERE id = $1 AND organization_id = $2;
+
+-- name: GetBranchPayRule :one
+-- The rule of the branch, or the organization rule when the branch has none
+SELECT *
+FROM pay_rules
+WHERE organization_id = @organization_id
+  AND (branch_id = @branch_id OR branch_id IS NULL)
+ORDER BY branch_id NULLS LAST
+LIMIT 1;
+
+-- name: CountDeliveredRideStops :one
+SELECT COUNT(*)
+FROM rides_to_orders
+WHERE ride_id = $1 AND stop_status = 'delivered';
+
+-- name: UpsertRideEarnings :one
+INSERT INTO ride_earnings (ride_id, driver_id, pay_rule_id, orders, distance, duration,
+                           order_pay, distance_pay, time_pay, guarantee_pay, total)
+VALUES (@ride_id, @driver_id, sqlc.narg('pay_rule_id'), @orders, @distance, @duration,
+        @order_pay, @distance_pay, @time_pay, @guarantee_pay, @total)
+ON CONFLICT (ride_id) DO UPDATE
+    SET driver_id     = excluded.driver_id,
+        pay_rule_id   = excluded.pay_rule_id,
+        orders        = excluded.orders,
+        distance      = excluded.distance,
+        duration      = excluded.duration,
+        order_pay     = excluded.order_pay,
+        distance_pay  = excluded.distance_pay,
+        time_pay      = excluded.time_pay,
+        guarantee_pay = excluded.guarantee_pay,
+        total         = excluded.total,
+        calculated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetRideEarnings :one
+SELECT *
+FROM ride_earnings
+WHERE ride_id = $1;
+
+-- name: CreateEarningAdjustment :one
+INSERT INTO earning_adjustments (driver_id, kind, amount, ride_id, order_id, comment, occurred_at, created_by)
+VALUES (@driver_id, @kind, @amount, sqlc.narg('ride_id'), sqlc.narg('order_id'), @comment, @occurred_at,
+        sqlc.narg('created_by'))
+RETURNING *;
+
+-- name: DeleteEarningAdjustment :execrows
+DELETE FROM earning_adjustments ea
+    USING drivers d
+WHERE ea.id = @id
+  AND ea.driver_id = @driver_id
+  AND d.id = ea.driver_id
+  AND d.organization_id = @organization_id;
+
+-- name: ListRideEarningAdjustments :many
+SELECT *
+FROM earning_adjustments
+WHERE ride_id = $1
+ORDER BY occurred_at, id;
+
+-- name: ListDriverEarningAdjustments :many
+SELECT ea.*
+FROM earning_adjustments ea
+         JOIN drivers d ON d.id = ea.driver_id
+         JOIN organizations org ON org.id = d.organization_id
+WHERE ea.driver_id = @driver_id
+  AND d.organization_id = @organization_id
+  AND (ea.occurred_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date BETWEEN @date_from::date AND @date_to::date
+ORDER BY ea.occurred_at, ea.id;
+
+-- name: ListPayrollRides :many
+-- Sums the earnings of rides per driver and local day of the ride return
+SELECT re.driver_id,
+       COUNT(*)                                AS ride_count,
+       SUM(re.orders)::bigint                  AS orders,
+       SUM(re.distance)::float8                AS distance,
+       SUM(re.duration)::bigint                AS duration,
+       SUM(re.order_pay)::numeric              AS order_pay,
+       SUM(re.distance_pay)::numeric           AS distance_pay,
+       SUM(re.time_pay)::numeric               AS time_pay,
+       SUM(re.guarantee_pay)::numeric          AS guarantee_pay,
+       SUM(re.total)::numeric                  AS total
+FROM ride_earnings re
+         JOIN rides r ON r.id = re.ride_id
+         JOIN branches b ON b.id = r.branch_id
+         JOIN organizations org ON org.id = b.organization_id
+WHERE b.organization_id = @organization_id
+  AND (r.ended_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date BETWEEN @date_from::date AND @date_to::date
+  AND (sqlc.narg('driver_id')::bigint IS NULL OR re.driver_id = sqlc.narg('driver_id'))
+  AND (sqlc.narg('branch_id')::bigint IS NULL OR r.branch_id = sqlc.narg('branch_id'))
+GROUP BY re.driver_id;
+
+-- name: ListPayrollAdjustments :many
+-- Sums bonuses and tips per driver. Adjustments of rides of other branches
+-- are left out when filtering by branch.
+SELECT ea.driver_id,
+       COALESCE(SUM(ea.amount) FILTER (WHERE ea.kind = 'bonus'), 0)::numeric AS bonuses,
+       COALESCE(SUM(ea.amount) FILTER (WHERE ea.kind = 'tip'), 0)::numeric   AS tips
+FROM earning_adjustments ea
+         JOIN drivers d ON d.id = ea.driver_id
+         JOIN organizations org ON org.id = d.organization_id
+         LEFT JOIN rides r ON r.id = ea.ride_id
+WHERE d.organization_id = @organization_id
+  AND (ea.occurred_at AT TIME ZONE 'UTC' AT TIME ZONE org.timezone)::date BETWEEN @date_from::date AND @date_to::date
+  AND (sqlc.narg('driver_id')::bigint IS NULL OR ea.driver_id = sqlc.narg('driver_id'))
+  AND (sqlc.narg('branch_id')::bigint IS NULL OR COALESCE(r.branch_id, d.home_branch_id) = sqlc.narg('branch_id'))
+GROUP BY ea.driver_id;
+
+-- name: ListPayrollDrivers :many
+SELECT d.id,
+       COALESCE(u.name, '')::text    AS name,
+       COALESCE(u.surname, '')::text AS surname
+FROM drivers d
+         JOIN users u ON u.id = d.user_id
+WHERE d.organization_id = @organization_id
+  AND d.id = ANY (@driver_ids::bigint[]);
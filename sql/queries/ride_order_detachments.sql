@@ -0,0 +1,32 @@
+-- name: DetachOrderFromActiveRides :many
+-- Stops the driver already finished stay on the ride as its history
+DELETE FROM rides_to_orders rto
+USING rides r
+WHERE rto.order_id = $1
+  AND r.id = rto.ride_id
+  AND r.ended_at IS NULL
+  AND rto.stop_status IN ('pending', 'arrived')
+RETURNING rto.ride_id;
+
+-- name: FinishOrderStopsOnRoad :many
+-- Orders finished while their ride is on the road stay on it as finished stops
+UPDATE rides_to_orders rto
+SET stop_status  = @stop_status,
+    delivered_at = sqlc.narg('delivered_at'),
+    failed_at    = sqlc.narg('failed_at')
+FROM rides r
+WHERE rto.order_id = @order_id
+  AND r.id = rto.ride_id
+  AND r.status IN ('departed', 'in_progress')
+  AND rto.stop_status IN ('pending', 'arrived')
+RETURNING rto.ride_id;
+
+-- name: CreateRideOrderDetachment :one
+INSERT INTO ride_order_detachments (ride_id, order_id, reason, source)
+    VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListRideOrderDetachments :many
+SELECT * FROM ride_order_detachments
+WHERE ride_id = $1
+ORDER BY detached_at, id;
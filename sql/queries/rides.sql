@@ -2,45 +2,142 @@
 INSERT INTO rides (
     branch_id,
     created_at
-) VALUES (
-             $1,
-             CURRENT_TIMESTAMP
-         )
+)
+SELECT b.id,
+       CURRENT_TIMESTAMP
+FROM branches b
+WHERE b.id = @branch_id AND b.organization_id = @organization_id
 RETURNING *;
 
 -- name: GetRide :one
-SELECT * FROM rides
-WHERE id = $1;
+SELECT r.*
+FROM rides r
+         JOIN branches b ON b.id = r.branch_id
+WHERE r.id = $1 AND b.organization_id = $2;
 
--- name: CompleteRide :exec
+-- name: UpdateRideStatus :one
+-- Moves the ride from from_status only, so concurrent transitions fail.
+-- Transition timestamps are kept once set.
 UPDATE rides
-SET ended_at = CURRENT_TIMESTAMP
-WHERE id = $1;
+SET status      = @status,
+    departed_at = COALESCE(departed_at, sqlc.narg('departed_at')),
+    started_at  = COALESCE(started_at, sqlc.narg('started_at')),
+    ended_at    = COALESCE(ended_at, sqlc.narg('ended_at'))
+WHERE id = @id AND status = @from_status
+RETURNING *;
+
+-- name: BumpRideVersion :one
+-- Locks the ride until the end of the transaction, so concurrent changes of
+-- a ride are applied one after another
+UPDATE rides
+SET version = version + 1
+WHERE id = $1
+RETURNING *;
 
--- name: AttachOrderToRide :exec
+-- name: AttachOrderToRide :execrows
 INSERT INTO rides_to_orders (
     ride_id,
     order_id
-) VALUES (
-             $1, $2
-         );
+)
+SELECT r.id, o.id
+FROM rides r
+         JOIN branches b ON b.id = r.branch_id
+         JOIN orders o ON o.organization_id = b.organization_id
+WHERE r.id = @ride_id
+  AND o.id = @order_id
+  AND b.organization_id = @organization_id;
 
 -- name: DetachAllOrdersFromRide :exec
+DELETE FROM rides_to_orders rto
+USING rides r, branches b
+WHERE rto.ride_id = $1
+  AND r.id = rto.ride_id
+  AND b.id = r.branch_id
+  AND b.organization_id = $2;
+
+-- name: DetachOrderFromRide :execrows
 DELETE FROM rides_to_orders
-WHERE ride_id = $1;
+WHERE ride_id = $1 AND order_id = $2;
+
+-- name: GetOrderActiveRideID :one
+SELECT ride_id
+FROM rides_to_orders
+WHERE order_id = $1 AND stop_status IN ('pending', 'arrived');
 
 -- name: GetOrdersByRideID :many
 SELECT o.*
 FROM orders o
          JOIN rides_to_orders rto ON rto.order_id = o.id
-WHERE rto.ride_id = $1
-ORDER BY o.created_at;
+WHERE rto.ride_id = $1 AND o.organization_id = $2
+ORDER BY rto.sequence NULLS LAST, o.created_at;
+
+-- name: ListRideStops :many
+SELECT sqlc.embed(o),
+       rto.sequence,
+       rto.leg_distance,
+       rto.planned_arrival,
+       rto.eta,
+       rto.stop_status,
+       rto.arrived_at,
+       rto.delivered_at,
+       rto.failed_at,
+       rto.failure_reason
+FROM orders o
+         JOIN rides_to_orders rto ON rto.order_id = o.id
+WHERE rto.ride_id = $1 AND o.organization_id = $2
+ORDER BY rto.sequence NULLS LAST, o.created_at;
+
+-- name: UpdateRideStop :exec
+UPDATE rides_to_orders
+SET sequence        = @sequence,
+    leg_distance    = @leg_distance,
+    planned_arrival = @planned_arrival
+WHERE ride_id = @ride_id AND order_id = @order_id;
+
+-- name: GetRideStop :one
+SELECT * FROM rides_to_orders
+WHERE ride_id = $1 AND order_id = $2;
+
+-- name: UpdateRideStopStatus :one
+-- Moves the stop from from_status only, so concurrent transitions fail
+UPDATE rides_to_orders
+SET stop_status    = @stop_status,
+    arrived_at     = COALESCE(arrived_at, sqlc.narg('arrived_at')),
+    delivered_at   = sqlc.narg('delivered_at'),
+    failed_at      = sqlc.narg('failed_at'),
+    failure_reason = @failure_reason
+WHERE ride_id = @ride_id AND order_id = @order_id AND stop_status = @from_status
+RETURNING *;
+
+-- name: ListUnfinishedRideStops :many
+SELECT order_id
+FROM rides_to_orders
+WHERE ride_id = $1 AND stop_status IN ('pending', 'arrived')
+ORDER BY sequence NULLS LAST, order_id;
 
--- name: GetActiveRides :many
-SELECT r.*,
-       COUNT(rto.order_id) as order_count
+-- name: FilterRides :many
+-- Keyset pagination by creation time, newest first. Stops stay on returned
+-- rides, so finished rides report what they delivered.
+SELECT sqlc.embed(r),
+       COALESCE(u.name, '')::text                                             AS driver_name,
+       COALESCE(u.surname, '')::text                                          AS driver_surname,
+       COUNT(rto.order_id)                                                    AS order_count,
+       COUNT(rto.order_id) FILTER (WHERE rto.stop_status = 'delivered')       AS delivered_count,
+       COUNT(rto.order_id) FILTER (WHERE rto.stop_status = 'failed')          AS failed_count,
+       (SELECT COUNT(*) FROM ride_order_detachments rod WHERE rod.ride_id = r.id) AS detached_count
 FROM rides r
-         LEFT JOIN rides_to_orders rto ON r.id = rto.ride_id
-WHERE r.ended_at IS NULL
-GROUP BY r.id
-ORDER BY r.created_at DESC;
\ No newline at end of file
+         JOIN branches b ON b.id = r.branch_id
+         LEFT JOIN drivers d ON d.id = r.driver_id
+         LEFT JOIN users u ON u.id = d.user_id
+         LEFT JOIN rides_to_orders rto ON rto.ride_id = r.id
+WHERE b.organization_id = @organization_id
+  AND (sqlc.narg('branch_id')::bigint IS NULL OR r.branch_id = sqlc.narg('branch_id'))
+  AND (sqlc.narg('driver_id')::bigint IS NULL OR r.driver_id = sqlc.narg('driver_id'))
+  AND (sqlc.narg('statuses')::text[] IS NULL OR r.status = ANY (sqlc.narg('statuses')::text[]))
+  AND (sqlc.narg('from_date')::timestamp IS NULL OR r.created_at >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::timestamp IS NULL OR r.created_at <= sqlc.narg('to_date'))
+  AND (sqlc.narg('cursor_id')::bigint IS NULL OR
+       (r.created_at, r.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')))
+GROUP BY r.id, u.id
+ORDER BY r.created_at DESC, r.id DESC
+LIMIT @row_limit;
@@ -0,0 +1,17 @@
+-- name: CreateDriverPosition :exec
+INSERT INTO driver_positions (driver_id, ride_id, location, accuracy, speed, recorded_at)
+VALUES (@driver_id, sqlc.narg('ride_id'), point(@lng::float8, @lat::float8), sqlc.narg('accuracy'),
+        sqlc.narg('speed'), @recorded_at);
+
+-- name: GetLatestDriverPosition :one
+SELECT *
+FROM driver_positions
+WHERE driver_id = $1
+ORDER BY recorded_at DESC, id DESC
+LIMIT 1;
+
+-- name: ListRideTrack :many
+SELECT *
+FROM driver_positions
+WHERE ride_id = $1
+ORDER BY recorded_at, id;
@@ -0,0 +1,23 @@
+create table delivery_zones
+(
+    id        bigint generated always as identity
+        primary key,
+    branch_id bigint  not null
+        references branches
+            on delete cascade,
+    name      text    not null,
+    area      polygon not null
+);
+
+create index delivery_zones_branch_id_idx
+    on delivery_zones (branch_id);
+
+alter table orders
+    add branch_id bigint
+        references branches;
+
+alter table orders
+    add out_of_zone boolean default false not null;
+
+create index orders_branch_id_idx
+    on orders (branch_id);
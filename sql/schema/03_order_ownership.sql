@@ -0,0 +1,40 @@
+alter table orders
+    add organization_id bigint
+        references organizations;
+
+alter table orders
+    add iiko_organization_id text;
+
+-- Orders routed to a branch before ownership was tracked belong to its organization
+update orders o
+set organization_id = b.organization_id
+from branches b
+where b.id = o.branch_id;
+
+-- Orders without a branch (received before branches were tracked or outside
+-- every zone) can only be attributed when there is a single organization
+do
+$$
+    begin
+        if exists (select 1 from orders where organization_id is null) then
+            if (select count(*) from organizations) <> 1 then
+                raise exception 'cannot backfill organization_id of orders without a branch: % organizations exist',
+                    (select count(*) from organizations);
+            end if;
+
+            update orders
+            set organization_id = (select id from organizations)
+            where organization_id is null;
+        end if;
+    end
+$$;
+
+alter table orders
+    alter column organization_id set not null;
+
+alter table orders
+    drop constraint orders_external_id_key;
+
+alter table orders
+    add constraint orders_organization_id_external_id_key
+        unique (organization_id, external_id);
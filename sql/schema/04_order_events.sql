@@ -0,0 +1,17 @@
+create table order_events
+(
+    id            bigint generated always as identity
+        primary key,
+    order_id      bigint                              not null
+        references orders
+            on delete cascade,
+    old_status    text,
+    new_status    text                                not null,
+    source        text                                not null,
+    iiko_revision bigint,
+    occurred_at   timestamp                           not null,
+    created_at    timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index order_events_order_id_idx
+    on order_events (order_id);
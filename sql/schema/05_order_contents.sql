@@ -0,0 +1,45 @@
+alter table orders
+    add guest_count integer;
+
+alter table orders
+    add courier_name text;
+
+alter table orders
+    add courier_phone text;
+
+alter table orders
+    add cash_to_collect numeric default 0 not null;
+
+create table order_items
+(
+    id         bigint generated always as identity
+        primary key,
+    order_id   bigint  not null
+        references orders
+            on delete cascade,
+    position   integer not null,
+    product_id text,
+    name       text    not null,
+    amount     numeric not null,
+    price      numeric not null,
+    cost       numeric not null,
+    comment    text
+);
+
+create index order_items_order_id_idx
+    on order_items (order_id);
+
+create table order_payments
+(
+    id                bigint generated always as identity
+        primary key,
+    order_id          bigint  not null
+        references orders
+            on delete cascade,
+    kind              text    not null,
+    payment_type_name text,
+    sum               numeric not null
+);
+
+create index order_payments_order_id_idx
+    on order_payments (order_id);
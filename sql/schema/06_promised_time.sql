@@ -0,0 +1,8 @@
+alter table organizations
+    add timezone text default 'Europe/Moscow' not null;
+
+alter table orders
+    add promised_at timestamp;
+
+alter table orders
+    add lateness text default 'on_time' not null;
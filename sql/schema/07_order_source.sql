@@ -0,0 +1,2 @@
+alter table orders
+    add source text default 'iiko' not null;
@@ -0,0 +1,17 @@
+create table ride_order_detachments
+(
+    id          bigint generated always as identity
+        primary key,
+    ride_id     bigint                              not null
+        references rides
+            on delete cascade,
+    order_id    bigint                              not null
+        references orders
+            on delete cascade,
+    reason      text                                not null,
+    source      text                                not null,
+    detached_at timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index ride_order_detachments_ride_id_index
+    on ride_order_detachments (ride_id);
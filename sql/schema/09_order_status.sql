@@ -0,0 +1,50 @@
+-- Orders keep the raw iiko statuses, status holds the internal one
+alter table orders
+    add iiko_status text;
+
+alter table orders
+    add iiko_delivery_status text;
+
+update orders
+set iiko_status = status
+where source = 'iiko';
+
+create function pg_temp.internal_status(iiko_status text) returns text
+    language sql as
+$$
+select case iiko_status
+           when 'CookingStarted' then 'cooking'
+           when 'CookingCompleted' then 'ready'
+           when 'Waiting' then 'ready'
+           when 'OnWay' then 'on_way'
+           when 'Delivered' then 'delivered'
+           when 'Closed' then 'delivered'
+           when 'Cancelled' then 'cancelled'
+           else 'new'
+           end
+$$;
+
+update orders
+set status = pg_temp.internal_status(status);
+
+update orders o
+set status = 'assigned'
+where o.status in ('new', 'cooking', 'ready')
+  and exists (select 1
+              from rides_to_orders rto
+                       join rides r on r.id = rto.ride_id
+              where rto.order_id = o.id
+                and r.ended_at is null);
+
+update order_events
+set old_status = pg_temp.internal_status(old_status)
+where old_status is not null;
+
+update order_events
+set new_status = pg_temp.internal_status(new_status);
+
+alter table orders
+    alter column status set default 'new';
+
+alter table orders
+    alter column status set not null;
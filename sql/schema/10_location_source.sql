@@ -0,0 +1,8 @@
+-- provided: coordinates came with the order, geocoded: resolved from the
+-- address, missing: neither worked and the location is a placeholder
+alter table orders
+    add location_source text default 'provided' not null;
+
+update orders
+set location_source = 'missing'
+where location ~= point(0, 0);
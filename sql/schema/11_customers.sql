@@ -0,0 +1,87 @@
+create table customers
+(
+    id              bigint generated always as identity
+        primary key,
+    organization_id bigint                              not null
+        references organizations
+            on delete cascade,
+    phone           text                                not null,
+    name            text                                not null,
+    notes           text                                not null default '',
+    created_at      timestamp default CURRENT_TIMESTAMP not null,
+    updated_at      timestamp default CURRENT_TIMESTAMP not null,
+    unique (organization_id, phone)
+);
+
+create table customer_addresses
+(
+    id           bigint generated always as identity
+        primary key,
+    customer_id  bigint                              not null
+        references customers
+            on delete cascade,
+    city         text                                not null default '',
+    street       text                                not null default '',
+    building     text                                not null default '',
+    apartment    text                                not null default '',
+    entrance     integer,
+    floor        integer,
+    doorphone    text,
+    location     point                               not null,
+    use_count    integer   default 1                 not null,
+    last_used_at timestamp default CURRENT_TIMESTAMP not null,
+    unique (customer_id, city, street, building, apartment)
+);
+
+create table customer_flags
+(
+    id          bigint generated always as identity
+        primary key,
+    customer_id bigint                              not null
+        references customers
+            on delete cascade,
+    flag        text                                not null,
+    comment     text                                not null default '',
+    created_by  bigint
+        references users
+            on delete set null,
+    created_at  timestamp default CURRENT_TIMESTAMP not null,
+    unique (customer_id, flag)
+);
+
+alter table orders
+    add customer_id bigint
+        references customers
+            on delete set null;
+
+create index orders_customer_id_index
+    on orders (customer_id);
+
+-- Build customers from the existing orders, see pkg/phone for the rules
+create function pg_temp.normalize_phone(phone text) returns text
+    language sql as
+$$
+select case
+           when length(d) = 11 and left(d, 1) = '8' then '+7' || substr(d, 2)
+           when length(d) = 10 and left(d, 1) = '9' then '+7' || d
+           when length(d) >= 10 then '+' || d
+           end
+from (select regexp_replace(coalesce(phone, ''), '\D', '', 'g') as d) p
+$$;
+
+insert into customers (organization_id, phone, name, created_at, updated_at)
+select distinct on (o.organization_id, pg_temp.normalize_phone(o.phone))
+       o.organization_id,
+       pg_temp.normalize_phone(o.phone),
+       o.customer_name,
+       o.created_at,
+       o.created_at
+from orders o
+where pg_temp.normalize_phone(o.phone) is not null
+order by o.organization_id, pg_temp.normalize_phone(o.phone), o.created_at desc;
+
+update orders o
+set customer_id = c.id
+from customers c
+where c.organization_id = o.organization_id
+  and c.phone = pg_temp.normalize_phone(o.phone);
@@ -0,0 +1,38 @@
+create table delivery_confirmations
+(
+    id             bigint generated always as identity
+        primary key,
+    order_id       bigint                              not null
+        unique
+        references orders
+            on delete cascade,
+    ride_id        bigint
+        references rides
+            on delete set null,
+    confirmed_by   bigint
+        references users
+            on delete set null,
+    delivered_at   timestamp                           not null,
+    location       point                               not null,
+    accuracy       double precision,
+    recipient_name text      default ''                not null,
+    comment        text      default ''                not null,
+    created_at     timestamp default CURRENT_TIMESTAMP not null
+);
+
+create table delivery_attachments
+(
+    id              bigint generated always as identity
+        primary key,
+    confirmation_id bigint                              not null
+        references delivery_confirmations
+            on delete cascade,
+    kind            text                                not null,
+    storage_key     text                                not null,
+    content_type    text                                not null,
+    size            bigint                              not null,
+    created_at      timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index delivery_attachments_confirmation_id_index
+    on delivery_attachments (confirmation_id);
@@ -0,0 +1,22 @@
+create table ride_cash_reports
+(
+    id             bigint generated always as identity
+        primary key,
+    ride_id        bigint                              not null
+        unique
+        references rides
+            on delete cascade,
+    expected       numeric                             not null,
+    collected      numeric                             not null,
+    reported_by    bigint
+        references users
+            on delete set null,
+    reported_at    timestamp default CURRENT_TIMESTAMP not null,
+    driver_comment text      default ''                not null,
+    received       numeric,
+    confirmed_by   bigint
+        references users
+            on delete set null,
+    confirmed_at   timestamp,
+    branch_comment text      default ''                not null
+);
@@ -0,0 +1,34 @@
+create table drivers
+(
+    id              bigint generated always as identity
+        primary key,
+    organization_id bigint                              not null
+        references organizations
+            on delete cascade,
+    user_id         bigint                              not null
+        constraint drivers_user_id_key
+            unique
+        references users
+            on delete cascade,
+    phone           text      default ''                not null,
+    vehicle_type    text      default 'car'             not null,
+    home_branch_id  bigint
+        references branches
+            on delete set null,
+    status          text      default 'off_duty'        not null,
+    created_at      timestamp default CURRENT_TIMESTAMP not null,
+    updated_at      timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index drivers_organization_id_index
+    on drivers (organization_id);
+
+alter table rides
+    add driver_id bigint
+        references drivers
+            on delete set null;
+
+-- A driver drives at most one ride at a time
+create unique index rides_active_driver_id_key
+    on rides (driver_id)
+    where ended_at is null;
@@ -0,0 +1,8 @@
+alter table rides_to_orders
+    add sequence integer;
+
+alter table rides_to_orders
+    add leg_distance double precision;
+
+alter table rides_to_orders
+    add planned_arrival timestamp;
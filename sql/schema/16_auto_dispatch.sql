@@ -0,0 +1,11 @@
+alter table organizations
+    add auto_dispatch boolean default false not null;
+
+alter table organizations
+    add ride_capacity integer default 5 not null;
+
+alter table organizations
+    add dispatch_radius integer default 3000 not null;
+
+alter table organizations
+    add dispatch_window_minutes integer default 20 not null;
@@ -0,0 +1,31 @@
+-- Rides move planned -> departed -> in_progress -> returned, planned rides
+-- may be cancelled. ended_at is set when the ride is returned or cancelled.
+alter table rides
+    add status text default 'planned' not null;
+
+alter table rides
+    add departed_at timestamp;
+
+alter table rides
+    add started_at timestamp;
+
+-- Rides ended before the lifecycle existed were either driven or deleted,
+-- their orders are gone so treat them as returned
+update rides
+set status = 'returned'
+where ended_at is not null;
+
+alter table rides_to_orders
+    add stop_status text default 'pending' not null;
+
+alter table rides_to_orders
+    add arrived_at timestamp;
+
+alter table rides_to_orders
+    add delivered_at timestamp;
+
+alter table rides_to_orders
+    add failed_at timestamp;
+
+alter table rides_to_orders
+    add failure_reason text default '' not null;
@@ -0,0 +1,18 @@
+-- Every change of a ride bumps its version, clients send it back as If-Match
+alter table rides
+    add version integer default 1 not null;
+
+-- Keep the newest assignment of orders that ended up on several active rides
+delete
+from rides_to_orders rto
+    using rides_to_orders newer
+where newer.order_id = rto.order_id
+  and newer.id > rto.id
+  and rto.stop_status in ('pending', 'arrived')
+  and newer.stop_status in ('pending', 'arrived');
+
+-- An order is on at most one active ride. Only active rides have unfinished
+-- stops: returned rides finished them and cancelled rides released them.
+create unique index rides_to_orders_active_order_id_key
+    on rides_to_orders (order_id)
+    where stop_status in ('pending', 'arrived');
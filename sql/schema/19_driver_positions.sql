@@ -0,0 +1,22 @@
+create table driver_positions
+(
+    id          bigint generated always as identity
+        primary key,
+    driver_id   bigint                              not null
+        references drivers
+            on delete cascade,
+    ride_id     bigint
+        references rides
+            on delete set null,
+    location    point                               not null,
+    accuracy    double precision,
+    speed       double precision,
+    recorded_at timestamp                           not null,
+    received_at timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index driver_positions_driver_id_recorded_at_index
+    on driver_positions (driver_id, recorded_at);
+
+create index driver_positions_ride_id_recorded_at_index
+    on driver_positions (ride_id, recorded_at);
@@ -0,0 +1,26 @@
+-- Radii in meters of the circles around order locations and branches that
+-- detect arrivals and returns, 0 disables the detection
+alter table organizations
+    add stop_radius integer default 50 not null;
+
+alter table organizations
+    add branch_radius integer default 100 not null;
+
+create table ride_geofence_events
+(
+    id          bigint generated always as identity
+        primary key,
+    ride_id     bigint    not null
+        references rides
+            on delete cascade,
+    kind        text      not null,
+    order_id    bigint
+        references orders
+            on delete cascade,
+    event       text      not null,
+    location    point     not null,
+    occurred_at timestamp not null
+);
+
+create index ride_geofence_events_ride_id_index
+    on ride_geofence_events (ride_id);
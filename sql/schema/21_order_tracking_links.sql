@@ -0,0 +1,15 @@
+-- Unguessable tokens of the public pages customers follow their order on
+create table order_tracking_links
+(
+    id         bigint generated always as identity
+        primary key,
+    order_id   bigint                              not null
+        constraint order_tracking_links_order_id_key
+            unique
+        references orders
+            on delete cascade,
+    token      text                                not null
+        constraint order_tracking_links_token_key
+            unique,
+    created_at timestamp default CURRENT_TIMESTAMP not null
+);
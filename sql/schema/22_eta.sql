@@ -0,0 +1,17 @@
+-- Live estimate of the driver reaching the stop, recalculated from the
+-- driver position
+alter table rides_to_orders
+    add eta timestamp;
+
+-- Speed and handover time of each vehicle type the organization configured,
+-- other vehicle types use the defaults
+create table vehicle_profiles
+(
+    organization_id bigint           not null
+        references organizations
+            on delete cascade,
+    vehicle_type    text             not null,
+    speed           double precision not null,
+    service_time    integer          not null,
+    primary key (organization_id, vehicle_type)
+);
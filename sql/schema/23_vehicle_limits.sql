@@ -0,0 +1,27 @@
+-- What each vehicle type the organization configured can carry in a ride,
+-- null is not limited. Other vehicle types use the defaults.
+create table vehicle_limits
+(
+    organization_id bigint  not null
+        references organizations
+            on delete cascade,
+    vehicle_type    text    not null,
+    max_orders      integer,
+    max_weight      integer,
+    max_volume      integer,
+    max_distance    integer,
+    primary key (organization_id, vehicle_type)
+);
+
+-- Size of one unit of a product in grams and milliliters, order sizes are
+-- derived from their items
+create table product_sizes
+(
+    organization_id bigint  not null
+        references organizations
+            on delete cascade,
+    product_id      text    not null,
+    weight          integer not null,
+    volume          integer not null,
+    primary key (organization_id, product_id)
+);
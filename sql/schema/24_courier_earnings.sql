@@ -0,0 +1,75 @@
+-- How couriers are paid for rides. A rule without a branch applies to the
+-- branches of the organization without their own rule.
+create table pay_rules
+(
+    id               bigint generated always as identity
+        primary key,
+    organization_id  bigint                              not null
+        references organizations
+            on delete cascade,
+    branch_id        bigint
+        references branches
+            on delete cascade,
+    per_order        numeric   default 0                 not null,
+    per_km           numeric   default 0                 not null,
+    per_hour         numeric   default 0                 not null,
+    minimum_per_ride numeric   default 0                 not null,
+    updated_at       timestamp default CURRENT_TIMESTAMP not null
+);
+
+create unique index pay_rules_organization_id_branch_id_key
+    on pay_rules (organization_id, coalesce(branch_id, 0));
+
+-- Pay of returned rides, calculated with the rule in force at the return
+create table ride_earnings
+(
+    ride_id       bigint                              not null
+        primary key
+        references rides
+            on delete cascade,
+    driver_id     bigint                              not null
+        references drivers
+            on delete cascade,
+    pay_rule_id   bigint
+        references pay_rules
+            on delete set null,
+    orders        integer                             not null,
+    distance      double precision                    not null,
+    duration      integer                             not null,
+    order_pay     numeric                             not null,
+    distance_pay  numeric                             not null,
+    time_pay      numeric                             not null,
+    guarantee_pay numeric                             not null,
+    total         numeric                             not null,
+    calculated_at timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index ride_earnings_driver_id_index
+    on ride_earnings (driver_id);
+
+-- Bonuses and tips of couriers, optionally tied to a ride or an order
+create table earning_adjustments
+(
+    id          bigint generated always as identity
+        primary key,
+    driver_id   bigint                              not null
+        references drivers
+            on delete cascade,
+    kind        text                                not null,
+    amount      numeric                             not null,
+    ride_id     bigint
+        references rides
+            on delete set null,
+    order_id    bigint
+        references orders
+            on delete set null,
+    comment     text      default ''                not null,
+    occurred_at timestamp                           not null,
+    created_by  bigint
+        references users
+            on delete set null,
+    created_at  timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index earning_adjustments_driver_id_occurred_at_index
+    on earning_adjustments (driver_id, occurred_at);
@@ -0,0 +1,5 @@
+-- User who caused an order event, empty for iiko and automatic changes
+alter table order_events
+    add user_id bigint
+        references users
+            on delete set null;